@@ -93,6 +93,7 @@ import (
 	ibcporttypes "github.com/cosmos/ibc-go/v6/modules/core/05-port/types"
 	ibchost "github.com/cosmos/ibc-go/v6/modules/core/24-host"
 	ibckeeper "github.com/cosmos/ibc-go/v6/modules/core/keeper"
+	ibctestingtypes "github.com/cosmos/ibc-go/v6/testing/types"
 	"github.com/spf13/cast"
 	abci "github.com/tendermint/tendermint/abci/types"
 	tmjson "github.com/tendermint/tendermint/libs/json"
@@ -136,6 +137,10 @@ func getGovProposalHandlers() []govclient.ProposalHandler {
 		alliancemoduleclient.CreateAllianceProposalHandler,
 		alliancemoduleclient.UpdateAllianceProposalHandler,
 		alliancemoduleclient.DeleteAllianceProposalHandler,
+		alliancemoduleclient.MigrateAllianceProposalHandler,
+		alliancemoduleclient.CreateAssetGroupProposalHandler,
+		alliancemoduleclient.UpdateAssetGroupProposalHandler,
+		alliancemoduleclient.DeleteAssetGroupProposalHandler,
 		// this line is used by starport scaffolding # stargate/app/govProposalHandler
 	)
 
@@ -176,16 +181,19 @@ var (
 
 	// module account permissions
 	maccPerms = map[string][]string{
-		authtypes.FeeCollectorName:          nil,
-		distrtypes.ModuleName:               nil,
-		icatypes.ModuleName:                 nil,
-		minttypes.ModuleName:                {authtypes.Minter},
-		stakingtypes.BondedPoolName:         {authtypes.Burner, authtypes.Staking},
-		stakingtypes.NotBondedPoolName:      {authtypes.Burner, authtypes.Staking},
-		govtypes.ModuleName:                 {authtypes.Burner},
-		ibctransfertypes.ModuleName:         {authtypes.Minter, authtypes.Burner},
-		alliancemoduletypes.ModuleName:      {authtypes.Minter, authtypes.Burner},
-		alliancemoduletypes.RewardsPoolName: nil,
+		authtypes.FeeCollectorName:                   nil,
+		distrtypes.ModuleName:                        nil,
+		icatypes.ModuleName:                          nil,
+		minttypes.ModuleName:                         {authtypes.Minter},
+		stakingtypes.BondedPoolName:                  {authtypes.Burner, authtypes.Staking},
+		stakingtypes.NotBondedPoolName:               {authtypes.Burner, authtypes.Staking},
+		govtypes.ModuleName:                          {authtypes.Burner},
+		ibctransfertypes.ModuleName:                  {authtypes.Minter, authtypes.Burner},
+		alliancemoduletypes.ModuleName:               {authtypes.Minter, authtypes.Burner},
+		alliancemoduletypes.RewardsPoolName:          nil,
+		alliancemoduletypes.RewardForwardingName:     nil,
+		alliancemoduletypes.AssetMigrationEscrowName: nil,
+		alliancemoduletypes.RewardIncentivesName:     nil,
 		// this line is used by starport scaffolding # stargate/app/maccPerms
 	}
 )
@@ -213,6 +221,7 @@ type App struct {
 	cdc               *codec.LegacyAmino
 	appCodec          codec.Codec
 	interfaceRegistry types.InterfaceRegistry
+	txConfig          client.TxConfig
 
 	invCheckPeriod uint
 
@@ -294,6 +303,7 @@ func New(
 		cdc:               cdc,
 		appCodec:          appCodec,
 		interfaceRegistry: interfaceRegistry,
+		txConfig:          encodingConfig.TxConfig,
 		invCheckPeriod:    invCheckPeriod,
 		keys:              keys,
 		tkeys:             tkeys,
@@ -426,6 +436,14 @@ func New(
 		app.BankKeeper,
 		&stakingKeeper,
 		app.DistrKeeper,
+		// the transfer keeper is created below and is only used after the app is built
+		&app.TransferKeeper,
+	)
+	// set the alliance hooks before the keeper is copied into the staking hooks and the alliance module below
+	app.AllianceKeeper.SetHooks(
+		alliancemoduletypes.NewMultiAllianceHooks(
+		// insert alliance hooks receivers here
+		),
 	)
 
 	app.BankKeeper.RegisterKeepers(app.AllianceKeeper, &stakingKeeper)
@@ -460,7 +478,8 @@ func New(
 		scopedTransferKeeper,
 	)
 	transferModule := transfer.NewAppModule(app.TransferKeeper)
-	transferIBCModule := transfer.NewIBCModule(app.TransferKeeper)
+	// the alliance middleware delegates incoming transfers with an alliance memo
+	transferIBCModule := alliancemodule.NewIBCMiddleware(transfer.NewIBCModule(app.TransferKeeper), app.AllianceKeeper)
 
 	app.ICAHostKeeper = icahostkeeper.NewKeeper(
 		appCodec,
@@ -646,6 +665,7 @@ func New(
 
 	app.configurator = module.NewConfigurator(app.appCodec, app.MsgServiceRouter(), app.GRPCQueryRouter())
 	app.mm.RegisterServices(app.configurator)
+	app.setupUpgradeHandlers()
 
 	// create the simulation manager and define the order of the modules for deterministic simulations
 	app.sm = module.NewSimulationManager(
@@ -696,6 +716,8 @@ func New(
 	app.SetBeginBlocker(app.BeginBlocker)
 	app.SetEndBlocker(app.EndBlocker)
 
+	app.setupUpgradeStoreLoaders()
+
 	if loadLatest {
 		if err := app.LoadLatestVersion(); err != nil {
 			tmos.Exit(err.Error())
@@ -715,6 +737,18 @@ func (app *App) Name() string { return app.BaseApp.Name() }
 // GetBaseApp returns the base app of the application
 func (app App) GetBaseApp() *baseapp.BaseApp { return app.BaseApp }
 
+// GetStakingKeeper implements the TestingApp interface of the ibc-go testing package
+func (app *App) GetStakingKeeper() ibctestingtypes.StakingKeeper { return app.StakingKeeper }
+
+// GetIBCKeeper implements the TestingApp interface of the ibc-go testing package
+func (app *App) GetIBCKeeper() *ibckeeper.Keeper { return app.IBCKeeper }
+
+// GetScopedIBCKeeper implements the TestingApp interface of the ibc-go testing package
+func (app *App) GetScopedIBCKeeper() capabilitykeeper.ScopedKeeper { return app.ScopedIBCKeeper }
+
+// GetTxConfig implements the TestingApp interface of the ibc-go testing package
+func (app *App) GetTxConfig() client.TxConfig { return app.txConfig }
+
 // BeginBlocker application updates every begin block
 func (app *App) BeginBlocker(ctx sdk.Context, req abci.RequestBeginBlock) abci.ResponseBeginBlock {
 	return app.mm.BeginBlock(ctx, req)
@@ -756,6 +790,10 @@ func (app *App) BlockedModuleAccountAddrs() map[string]bool {
 	modAccAddrs := app.ModuleAccountAddrs()
 	delete(modAccAddrs, authtypes.NewModuleAddress(govtypes.ModuleName).String())
 	delete(modAccAddrs, authtypes.NewModuleAddress(alliancemoduletypes.ModuleName).String())
+	// the reward forwarding account sends IBC transfers and receives their refunds
+	delete(modAccAddrs, authtypes.NewModuleAddress(alliancemoduletypes.RewardForwardingName).String())
+	// the asset migration escrow is funded by the issuer of the migrated asset
+	delete(modAccAddrs, authtypes.NewModuleAddress(alliancemoduletypes.AssetMigrationEscrowName).String())
 
 	return modAccAddrs
 }
@@ -72,6 +72,13 @@ func (app *App) prepForZeroHeightGenesis(ctx sdk.Context, jailAllowedAddrs []str
 	/* Just to be safe, assert the invariants on current state. */
 	app.CrisisKeeper.AssertInvariants(ctx)
 
+	/* Handle alliance state. */
+
+	// settle the alliance rewards and remove the module delegations before the distribution state is reset
+	if err := app.AllianceKeeper.PrepForZeroHeightGenesis(ctx); err != nil {
+		panic(err)
+	}
+
 	/* Handle fee distribution state. */
 
 	// withdraw all validator commission
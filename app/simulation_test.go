@@ -1,7 +1,9 @@
 package app_test
 
 import (
+	"math/rand"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/cosmos/cosmos-sdk/baseapp"
@@ -11,10 +13,12 @@ import (
 	"github.com/cosmos/cosmos-sdk/types/module"
 	simulationtypes "github.com/cosmos/cosmos-sdk/types/simulation"
 	"github.com/cosmos/cosmos-sdk/x/simulation"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
 	"github.com/stretchr/testify/require"
 	abci "github.com/tendermint/tendermint/abci/types"
 
 	"github.com/terra-money/alliance/app"
+	alliancetypes "github.com/terra-money/alliance/x/alliance/types"
 )
 
 func init() {
@@ -34,6 +38,28 @@ type SimApp interface {
 	InitChainer(ctx sdk.Context, req abci.RequestInitChain) abci.ResponseInitChain
 }
 
+// simulationOperations returns the operations of all modules. The staking operations may pick a delegation of the
+// alliance module account, which has no key in the simulation, so these are skipped instead of failing the run.
+// The staking operations only report the picked delegator in the error, so it is matched against the address of the
+// alliance module account.
+func simulationOperations(simApp *app.App, config simulationtypes.Config) []simulationtypes.WeightedOperation {
+	allianceModuleAddr := simApp.AccountKeeper.GetModuleAddress(alliancetypes.ModuleName).String()
+	var operations []simulationtypes.WeightedOperation
+	for _, wOp := range simapp.SimulationOperations(simApp, simApp.AppCodec(), config) {
+		op := wOp.Op()
+		operations = append(operations, simulation.NewWeightedOperation(wOp.Weight(), func(
+			r *rand.Rand, app *baseapp.BaseApp, ctx sdk.Context, accs []simulationtypes.Account, chainID string,
+		) (simulationtypes.OperationMsg, []simulationtypes.FutureOperation, error) {
+			opMsg, fOps, err := op(r, app, ctx, accs, chainID)
+			if err != nil && opMsg.Route == stakingtypes.ModuleName && strings.Contains(err.Error(), allianceModuleAddr) {
+				return opMsg, fOps, nil
+			}
+			return opMsg, fOps, err
+		}))
+	}
+	return operations
+}
+
 // BenchmarkSimulation run the chain simulation
 // Running using starport command:
 // `starport chain simulate -v --numBlocks 200 --blockSize 50`
@@ -61,7 +87,8 @@ func BenchmarkSimulation(b *testing.B) {
 		true,
 		map[int64]bool{},
 		app.DefaultNodeHome,
-		0,
+		// Assert the invariants registered with the crisis module at the end of every block
+		1,
 		encoding,
 		simapp.EmptyAppOptions{},
 	)
@@ -73,7 +100,7 @@ func BenchmarkSimulation(b *testing.B) {
 		simApp.GetBaseApp(),
 		simapp.AppStateFn(simApp.AppCodec(), simApp.SimulationManager()),
 		simulationtypes.RandomAccounts,
-		simapp.SimulationOperations(simApp, simApp.AppCodec(), config),
+		simulationOperations(simApp, config),
 		simApp.ModuleAccountAddrs(),
 		config,
 		simApp.AppCodec(),
@@ -88,3 +115,55 @@ func BenchmarkSimulation(b *testing.B) {
 		simapp.PrintStats(db)
 	}
 }
+
+// TestFullAppSimulation runs the chain simulation and asserts the registered invariants, including the ones of the
+// alliance module, at the end of every block
+//
+// `go test ./app -run TestFullAppSimulation -NumBlocks=200 -BlockSize 50 -Commit=true -Enabled=true`
+func TestFullAppSimulation(t *testing.T) {
+	config, db, dir, logger, skip, err := simapp.SetupSimulation("goleveldb-app-sim", "Simulation")
+	if skip {
+		t.Skip("skipping application simulation")
+	}
+	require.NoError(t, err, "simulation setup failed")
+
+	t.Cleanup(func() {
+		db.Close()
+		err = os.RemoveAll(dir)
+		require.NoError(t, err)
+	})
+
+	encoding := app.MakeTestEncodingConfig()
+
+	simApp := app.New(
+		logger,
+		db,
+		nil,
+		true,
+		map[int64]bool{},
+		app.DefaultNodeHome,
+		1,
+		encoding,
+		simapp.EmptyAppOptions{},
+	)
+
+	_, simParams, simErr := simulation.SimulateFromSeed(
+		t,
+		os.Stdout,
+		simApp.GetBaseApp(),
+		simapp.AppStateFn(simApp.AppCodec(), simApp.SimulationManager()),
+		simulationtypes.RandomAccounts,
+		simulationOperations(simApp, config),
+		simApp.ModuleAccountAddrs(),
+		config,
+		simApp.AppCodec(),
+	)
+
+	err = simapp.CheckExportSimulation(simApp, config, simParams)
+	require.NoError(t, err)
+	require.NoError(t, simErr)
+
+	if config.Commit {
+		simapp.PrintStats(db)
+	}
+}
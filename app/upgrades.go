@@ -0,0 +1,41 @@
+package app
+
+import (
+	"fmt"
+
+	upgradetypes "github.com/cosmos/cosmos-sdk/x/upgrade/types"
+
+	"github.com/terra-money/alliance/app/upgrades"
+	v6 "github.com/terra-money/alliance/app/upgrades/v6"
+)
+
+// Upgrades lists the named software upgrades of the app. A new upgrade is added in its own package under
+// app/upgrades and appended here.
+var Upgrades = []upgrades.Upgrade{
+	v6.Upgrade,
+}
+
+// setupUpgradeHandlers registers the handlers of all upgrades with the upgrade keeper
+func (app *App) setupUpgradeHandlers() {
+	for _, upgrade := range Upgrades {
+		app.UpgradeKeeper.SetUpgradeHandler(upgrade.UpgradeName, upgrade.CreateUpgradeHandler(app.mm, app.configurator))
+	}
+}
+
+// setupUpgradeStoreLoaders applies the store upgrades of the upgrade that is scheduled for the next block, if the
+// upgrade height is not skipped
+func (app *App) setupUpgradeStoreLoaders() {
+	upgradeInfo, err := app.UpgradeKeeper.ReadUpgradeInfoFromDisk()
+	if err != nil {
+		panic(fmt.Sprintf("failed to read upgrade info from disk: %s", err))
+	}
+	if app.UpgradeKeeper.IsSkipHeight(upgradeInfo.Height) {
+		return
+	}
+	for _, upgrade := range Upgrades {
+		if upgradeInfo.Name == upgrade.UpgradeName {
+			storeUpgrades := upgrade.StoreUpgrades
+			app.SetStoreLoader(upgradetypes.UpgradeStoreLoader(upgradeInfo.Height, &storeUpgrades))
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package upgrades
+
+import (
+	storetypes "github.com/cosmos/cosmos-sdk/store/types"
+	"github.com/cosmos/cosmos-sdk/types/module"
+	upgradetypes "github.com/cosmos/cosmos-sdk/x/upgrade/types"
+)
+
+// Upgrade is a named software upgrade of the app that is scheduled through an x/upgrade proposal. The name of the
+// upgrade plan must match UpgradeName for the handler to run.
+type Upgrade struct {
+	UpgradeName string
+
+	// CreateUpgradeHandler creates the handler that runs the module migrations of the upgrade
+	CreateUpgradeHandler func(*module.Manager, module.Configurator) upgradetypes.UpgradeHandler
+
+	// StoreUpgrades lists the stores that are added, renamed or deleted at the upgrade height
+	StoreUpgrades storetypes.StoreUpgrades
+}
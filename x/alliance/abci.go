@@ -1,31 +1,42 @@
 package alliance
 
 import (
-	"fmt"
-
 	"github.com/terra-money/alliance/x/alliance/keeper"
+	"github.com/terra-money/alliance/x/alliance/types"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	abci "github.com/tendermint/tendermint/abci/types"
 )
 
 // EndBlocker
+// Failing steps are rolled back and reported with an EndBlockerErrorEvent instead of halting the chain
 func EndBlocker(ctx sdk.Context, k keeper.Keeper) []abci.ValidatorUpdate {
 	k.CompleteRedelegations(ctx)
-	if err := k.CompleteUndelegations(ctx); err != nil {
-		panic(fmt.Errorf("failed to complete undelegations from x/alliance module: %s", err))
-	}
+	k.ApplyEndBlockerStep(ctx, types.EndBlockerStepCompleteUndelegations, k.CompleteUndelegations)
+	k.ApplyEndBlockerStep(ctx, types.EndBlockerStepAssetMigration, func(ctx sdk.Context) error {
+		return k.MigrateAssetDelegations(ctx, types.AssetMigrationBatchSize)
+	})
+	k.ApplyEndBlockerStep(ctx, types.EndBlockerStepDelegationLocks, k.CompleteDelegationLocks)
+	k.ApplyEndBlockerStep(ctx, types.EndBlockerStepRewardIncentives, k.DistributeRewardIncentives)
+	k.ApplyEndBlockerStep(ctx, types.EndBlockerStepSweepRewardDust, k.SweepRewardDustHook)
+	k.ApplyEndBlockerStep(ctx, types.EndBlockerStepRewardRateCheckpoint, k.CheckpointRewardRatesHook)
 
 	assets := k.GetAllAssets(ctx)
 	k.InitializeAllianceAssets(ctx, assets)
-	if _, err := k.DeductAssetsHook(ctx, assets); err != nil {
-		panic(fmt.Errorf("failed to deduct take rate from alliance in x/alliance module: %s", err))
-	}
-	if err := k.RewardWeightChangeHook(ctx, assets); err != nil {
-		panic(fmt.Errorf("failed to update assets reward weights in x/alliance module: %s", err))
+	// Hooks update the assets in memory, so they are reloaded when a step is rolled back
+	if !k.ApplyEndBlockerStep(ctx, types.EndBlockerStepDeductTakeRate, func(ctx sdk.Context) error {
+		_, err := k.DeductAssetsHook(ctx, assets)
+		return err
+	}) {
+		assets = k.GetAllAssets(ctx)
 	}
-	if err := k.RebalanceHook(ctx, assets); err != nil {
-		panic(fmt.Errorf("failed to rebalance assets in x/alliance module: %s", err))
+	if !k.ApplyEndBlockerStep(ctx, types.EndBlockerStepRewardWeightChange, func(ctx sdk.Context) error {
+		return k.RewardWeightChangeHook(ctx, assets)
+	}) {
+		assets = k.GetAllAssets(ctx)
 	}
+	k.ApplyEndBlockerStep(ctx, types.EndBlockerStepRebalance, func(ctx sdk.Context) error {
+		return k.RebalanceHook(ctx, assets)
+	})
 	return []abci.ValidatorUpdate{}
 }
@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/cosmos/cosmos-sdk/client"
@@ -13,6 +15,8 @@ import (
 	"github.com/terra-money/alliance/x/alliance/types"
 )
 
+const FlagRewardWeightCurve = "reward-weight-curve"
+
 func CreateAlliance() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "create-alliance denom reward-weight reward-weight-min reward-weight-max take-rate reward-change-rate reward-change-interval",
@@ -65,6 +69,11 @@ func CreateAlliance() *cobra.Command {
 				return err
 			}
 
+			rewardWeightCurve, err := parseRewardWeightCurve(cmd, clientCtx)
+			if err != nil {
+				return err
+			}
+
 			from := clientCtx.GetFromAddress()
 
 			depositStr, err := cmd.Flags().GetString(govcli.FlagDeposit)
@@ -89,6 +98,7 @@ func CreateAlliance() *cobra.Command {
 				takeRate,
 				rewardChangeRate,
 				rewardChangeInterval,
+				rewardWeightCurve,
 			)
 
 			err = content.ValidateBasic()
@@ -113,6 +123,7 @@ func CreateAlliance() *cobra.Command {
 	cmd.Flags().String(govcli.FlagTitle, "", "title of proposal")             //nolint:staticcheck
 	cmd.Flags().String(govcli.FlagDescription, "", "description of proposal") //nolint:staticcheck
 	cmd.Flags().String(govcli.FlagDeposit, "", "deposit of proposal")
+	cmd.Flags().String(FlagRewardWeightCurve, "", "JSON encoded curve deriving the reward weight from the total tokens of the asset")
 	return cmd
 }
 
@@ -158,6 +169,11 @@ func UpdateAlliance() *cobra.Command {
 				return err
 			}
 
+			rewardWeightCurve, err := parseRewardWeightCurve(cmd, clientCtx)
+			if err != nil {
+				return err
+			}
+
 			from := clientCtx.GetFromAddress()
 
 			depositStr, err := cmd.Flags().GetString(govcli.FlagDeposit)
@@ -178,6 +194,7 @@ func UpdateAlliance() *cobra.Command {
 				takeRate,
 				rewardChangeRate,
 				rewardChangeInterval,
+				rewardWeightCurve,
 			)
 
 			err = content.ValidateBasic()
@@ -202,6 +219,7 @@ func UpdateAlliance() *cobra.Command {
 	cmd.Flags().String(govcli.FlagTitle, "", "title of proposal")             //nolint:staticcheck // SA1019: govcli.FlagTitle is deprecated
 	cmd.Flags().String(govcli.FlagDescription, "", "description of proposal") //nolint:staticcheck // SA1019: govcli.FlagDescription is deprecated
 	cmd.Flags().String(govcli.FlagDeposit, "", "deposit of proposal")
+	cmd.Flags().String(FlagRewardWeightCurve, "", "JSON encoded curve deriving the reward weight from the total tokens of the asset")
 	return cmd
 }
 
@@ -269,3 +287,257 @@ func DeleteAlliance() *cobra.Command {
 	cmd.Flags().String(govcli.FlagDeposit, "", "deposit of proposal")
 	return cmd
 }
+
+func MigrateAlliance() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate-alliance from-denom to-denom conversion-rate escrow-recipient",
+		Args:  cobra.ExactArgs(4),
+		Short: "Migrate the delegations of an alliance to a new denom with a fixed conversion rate",
+		Long: "Migrate the delegations of an alliance to a new denom with a fixed conversion rate. The migration escrow " +
+			"has to hold enough to-denom tokens to swap the from-denom tokens held by the alliance module when the " +
+			"proposal passes. The swapped from-denom tokens and the unused to-denom tokens are sent to the escrow recipient.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientTxContext(cmd)
+			if err != nil {
+				return err
+			}
+			title, err := cmd.Flags().GetString(govcli.FlagTitle) //nolint:staticcheck // SA1019: govcli.FlagTitle is deprecated
+			if err != nil {
+				return err
+			}
+
+			description, err := cmd.Flags().GetString(govcli.FlagDescription) //nolint:staticcheck // SA1019: govcli.FlagDescription is deprecated
+			if err != nil {
+				return err
+			}
+
+			conversionRate, err := sdk.NewDecFromStr(args[2])
+			if err != nil {
+				return err
+			}
+
+			from := clientCtx.GetFromAddress()
+
+			depositStr, err := cmd.Flags().GetString(govcli.FlagDeposit)
+			if err != nil {
+				return err
+			}
+
+			deposit, err := sdk.ParseCoinsNormalized(depositStr)
+			if err != nil {
+				return err
+			}
+
+			content := types.NewMsgMigrateAllianceProposal(
+				title,
+				description,
+				args[0],
+				args[1],
+				conversionRate,
+				args[3],
+			)
+
+			err = content.ValidateBasic()
+
+			if err != nil {
+				return err
+			}
+
+			msg, err := govtypes.NewMsgSubmitProposal(content, deposit, from)
+			if err != nil {
+				return err
+			}
+
+			if err = msg.ValidateBasic(); err != nil {
+				return err
+			}
+
+			return tx.GenerateOrBroadcastTxCLI(clientCtx, cmd.Flags(), msg)
+		},
+	}
+
+	cmd.Flags().String(govcli.FlagTitle, "", "title of proposal")             //nolint:staticcheck // SA1019: govcli.FlagTitle is deprecated
+	cmd.Flags().String(govcli.FlagDescription, "", "description of proposal") //nolint:staticcheck // SA1019: govcli.FlagDescription is deprecated
+	cmd.Flags().String(govcli.FlagDeposit, "", "deposit of proposal")
+	return cmd
+}
+
+func CreateAssetGroup() *cobra.Command {
+	return assetGroupProposalCmd(
+		"create-asset-group",
+		"Create an asset group that splits a reward weight among its members in proportion to their value",
+		types.NewMsgCreateAssetGroupProposal,
+	)
+}
+
+func UpdateAssetGroup() *cobra.Command {
+	return assetGroupProposalCmd(
+		"update-asset-group",
+		"Update the reward weight, the members and the update interval of an asset group",
+		types.NewMsgUpdateAssetGroupProposal,
+	)
+}
+
+func DeleteAssetGroup() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete-asset-group name",
+		Args:  cobra.ExactArgs(1),
+		Short: "Delete an asset group, its members keep their last reward weight",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientTxContext(cmd)
+			if err != nil {
+				return err
+			}
+			title, err := cmd.Flags().GetString(govcli.FlagTitle) //nolint:staticcheck // SA1019: govcli.FlagTitle is deprecated
+			if err != nil {
+				return err
+			}
+
+			description, err := cmd.Flags().GetString(govcli.FlagDescription) //nolint:staticcheck // SA1019: govcli.FlagDescription is deprecated
+			if err != nil {
+				return err
+			}
+
+			from := clientCtx.GetFromAddress()
+
+			depositStr, err := cmd.Flags().GetString(govcli.FlagDeposit)
+			if err != nil {
+				return err
+			}
+
+			deposit, err := sdk.ParseCoinsNormalized(depositStr)
+			if err != nil {
+				return err
+			}
+
+			content := types.NewMsgDeleteAssetGroupProposal(title, description, args[0])
+
+			err = content.ValidateBasic()
+
+			if err != nil {
+				return err
+			}
+
+			msg, err := govtypes.NewMsgSubmitProposal(content, deposit, from)
+			if err != nil {
+				return err
+			}
+
+			if err = msg.ValidateBasic(); err != nil {
+				return err
+			}
+
+			return tx.GenerateOrBroadcastTxCLI(clientCtx, cmd.Flags(), msg)
+		},
+	}
+
+	cmd.Flags().String(govcli.FlagTitle, "", "title of proposal")             //nolint:staticcheck // SA1019: govcli.FlagTitle is deprecated
+	cmd.Flags().String(govcli.FlagDescription, "", "description of proposal") //nolint:staticcheck // SA1019: govcli.FlagDescription is deprecated
+	cmd.Flags().String(govcli.FlagDeposit, "", "deposit of proposal")
+	return cmd
+}
+
+func assetGroupProposalCmd(name string, short string, newContent func(title, description, name string, rewardWeight sdk.Dec, members []types.AssetGroupMember, updateInterval time.Duration) govtypes.Content) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   name + " name reward-weight update-interval [denom:conversion-ratio]...",
+		Args:  cobra.MinimumNArgs(4),
+		Short: short,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientTxContext(cmd)
+			if err != nil {
+				return err
+			}
+			title, err := cmd.Flags().GetString(govcli.FlagTitle) //nolint:staticcheck // SA1019: govcli.FlagTitle is deprecated
+			if err != nil {
+				return err
+			}
+
+			description, err := cmd.Flags().GetString(govcli.FlagDescription) //nolint:staticcheck // SA1019: govcli.FlagDescription is deprecated
+			if err != nil {
+				return err
+			}
+
+			rewardWeight, err := sdk.NewDecFromStr(args[1])
+			if err != nil {
+				return err
+			}
+
+			updateInterval, err := time.ParseDuration(args[2])
+			if err != nil {
+				return err
+			}
+
+			members, err := parseAssetGroupMembers(args[3:])
+			if err != nil {
+				return err
+			}
+
+			from := clientCtx.GetFromAddress()
+
+			depositStr, err := cmd.Flags().GetString(govcli.FlagDeposit)
+			if err != nil {
+				return err
+			}
+
+			deposit, err := sdk.ParseCoinsNormalized(depositStr)
+			if err != nil {
+				return err
+			}
+
+			content := newContent(title, description, args[0], rewardWeight, members, updateInterval)
+
+			err = content.ValidateBasic()
+
+			if err != nil {
+				return err
+			}
+
+			msg, err := govtypes.NewMsgSubmitProposal(content, deposit, from)
+			if err != nil {
+				return err
+			}
+
+			if err = msg.ValidateBasic(); err != nil {
+				return err
+			}
+
+			return tx.GenerateOrBroadcastTxCLI(clientCtx, cmd.Flags(), msg)
+		},
+	}
+
+	cmd.Flags().String(govcli.FlagTitle, "", "title of proposal")             //nolint:staticcheck // SA1019: govcli.FlagTitle is deprecated
+	cmd.Flags().String(govcli.FlagDescription, "", "description of proposal") //nolint:staticcheck // SA1019: govcli.FlagDescription is deprecated
+	cmd.Flags().String(govcli.FlagDeposit, "", "deposit of proposal")
+	return cmd
+}
+
+// parseAssetGroupMembers parses members in the format denom:conversion-ratio
+func parseAssetGroupMembers(args []string) ([]types.AssetGroupMember, error) {
+	members := make([]types.AssetGroupMember, 0, len(args))
+	for _, arg := range args {
+		i := strings.LastIndex(arg, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("member %s must be in the format denom:conversion-ratio", arg)
+		}
+		conversionRatio, err := sdk.NewDecFromStr(arg[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, types.NewAssetGroupMember(arg[:i], conversionRatio))
+	}
+	return members, nil
+}
+
+// parseRewardWeightCurve parses the optional JSON encoded reward weight curve, e.g.
+// {"target_tokens":"1000000","points":[{"utilization":"0","reward_weight":"1"},{"utilization":"1","reward_weight":"0.1"}],"update_threshold":"0.01"}
+func parseRewardWeightCurve(cmd *cobra.Command, clientCtx client.Context) (*types.RewardWeightCurve, error) {
+	curveStr, err := cmd.Flags().GetString(FlagRewardWeightCurve)
+	if err != nil || curveStr == "" {
+		return nil, err
+	}
+	var curve types.RewardWeightCurve
+	if err := clientCtx.Codec.UnmarshalJSON([]byte(curveStr), &curve); err != nil {
+		return nil, err
+	}
+	return &curve, nil
+}
@@ -3,6 +3,8 @@ package cli
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 
@@ -10,9 +12,17 @@ import (
 
 	"github.com/cosmos/cosmos-sdk/client"
 	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/version"
 	"github.com/spf13/cobra"
 )
 
+const (
+	FlagRateValidator = "validator"
+	FlagRateWindow    = "window"
+	FlagDenom         = "denom"
+	FlagSortByStaked  = "sort-by-staked"
+)
+
 // GetQueryCmd returns the cli query commands for this module
 func GetQueryCmd() *cobra.Command {
 	cmd := &cobra.Command{
@@ -34,9 +44,31 @@ func GetQueryCmd() *cobra.Command {
 	cmd.AddCommand(CmdQueryAllAlliancesDelegations())
 	cmd.AddCommand(CmdQueryAlliancesDelegation())
 	cmd.AddCommand(CmdQueryAlliancesDelegationByValidator())
+	cmd.AddCommand(CmdQueryValidatorAllianceDelegations())
 	cmd.AddCommand(CmdQueryAllianceDelegation())
 	cmd.AddCommand(CmdQueryRewards())
 
+	cmd.AddCommand(CmdQueryPendingRebalance())
+	cmd.AddCommand(CmdQueryPendingRebalances())
+	cmd.AddCommand(CmdQueryRebalancePreview())
+
+	cmd.AddCommand(CmdQueryRewardForwarding())
+	cmd.AddCommand(CmdQueryRewardForwardingEscrow())
+	cmd.AddCommand(CmdQueryAssetMigration())
+
+	cmd.AddCommand(CmdQueryAssetGroups())
+	cmd.AddCommand(CmdQueryAssetGroup())
+
+	cmd.AddCommand(CmdQueryDelegationLocks())
+
+	cmd.AddCommand(CmdQueryRewardIncentives())
+	cmd.AddCommand(CmdQueryRewardIncentive())
+
+	cmd.AddCommand(CmdQueryRewardDust())
+	cmd.AddCommand(CmdQueryAllianceRewardRates())
+	cmd.AddCommand(CmdQuerySimulateAllianceAction())
+	cmd.AddCommand(CmdQueryDelegatorPortfolio())
+
 	return cmd
 }
 
@@ -117,7 +149,12 @@ func CmdQueryValidator() *cobra.Command {
 
 			query := types.NewQueryClient(ctx)
 
-			req := &types.QueryAllianceValidatorRequest{ValidatorAddr: valAddr.String()}
+			denom, err := cmd.Flags().GetString(FlagDenom)
+			if err != nil {
+				return err
+			}
+
+			req := &types.QueryAllianceValidatorRequest{ValidatorAddr: valAddr.String(), Denom: denom}
 
 			res, err := query.AllianceValidator(cmd.Context(), req)
 			if err != nil {
@@ -128,6 +165,7 @@ func CmdQueryValidator() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().String(FlagDenom, "", "only show the shares, stake and rewards of this alliance asset")
 	flags.AddQueryFlagsToCmd(cmd)
 
 	return cmd
@@ -150,8 +188,19 @@ func CmdQueryValidators() *cobra.Command {
 				return err
 			}
 
+			denom, err := cmd.Flags().GetString(FlagDenom)
+			if err != nil {
+				return err
+			}
+			sortByStaked, err := cmd.Flags().GetBool(FlagSortByStaked)
+			if err != nil {
+				return err
+			}
+
 			req := &types.QueryAllAllianceValidatorsRequest{
-				Pagination: pageReq,
+				Denom:        denom,
+				SortByStaked: sortByStaked,
+				Pagination:   pageReq,
 			}
 
 			res, err := query.AllAllianceValidators(cmd.Context(), req)
@@ -163,7 +212,10 @@ func CmdQueryValidators() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().String(FlagDenom, "", "only show the validators with delegations of this alliance asset")
+	cmd.Flags().Bool(FlagSortByStaked, false, "sort the validators by staked tokens, descending (only offset pagination)")
 	flags.AddQueryFlagsToCmd(cmd)
+	flags.AddPaginationFlagsToCmd(cmd, "validators")
 
 	return cmd
 }
@@ -361,3 +413,567 @@ func CmdQueryRewards() *cobra.Command {
 
 	return cmd
 }
+
+func CmdQueryPendingRebalance() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pending-rebalance validator-addr",
+		Short: "Query the bond tokens that are still to be rebalanced for a validator",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			valAddr, err := sdk.ValAddressFromBech32(args[0])
+			if err != nil {
+				return err
+			}
+			ctx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			query := types.NewQueryClient(ctx)
+
+			req := &types.QueryPendingRebalanceRequest{ValidatorAddr: valAddr.String()}
+
+			res, err := query.PendingRebalance(cmd.Context(), req)
+			if err != nil {
+				return err
+			}
+
+			return ctx.PrintProto(res)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+
+	return cmd
+}
+
+func CmdQueryPendingRebalances() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pending-rebalances",
+		Short: "Query all validators with bond tokens that are still to be rebalanced",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			query := types.NewQueryClient(ctx)
+			pageReq, err := client.ReadPageRequest(cmd.Flags())
+			if err != nil {
+				return err
+			}
+
+			req := &types.QueryAllPendingRebalancesRequest{
+				Pagination: pageReq,
+			}
+
+			res, err := query.AllPendingRebalances(cmd.Context(), req)
+			if err != nil {
+				return err
+			}
+
+			return ctx.PrintProto(res)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+
+	return cmd
+}
+
+func CmdQueryRebalancePreview() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rebalance-preview",
+		Short: "Query what the next rebalance would delegate to or undelegate from each bonded validator",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			queryClient := types.NewQueryClient(clientCtx)
+
+			res, err := queryClient.RebalancePreview(cmd.Context(), &types.QueryRebalancePreviewRequest{})
+			if err != nil {
+				return err
+			}
+
+			return clientCtx.PrintProto(res)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+
+	return cmd
+}
+
+func CmdQueryRewardForwarding() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reward-forwarding delegator-addr validator-addr denom",
+		Short: "Query where the rewards of a delegation are forwarded to",
+		Args:  cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			query := types.NewQueryClient(ctx)
+
+			req := &types.QueryRewardForwardingRequest{
+				DelegatorAddr: args[0],
+				ValidatorAddr: args[1],
+				Denom:         args[2],
+			}
+
+			res, err := query.RewardForwarding(cmd.Context(), req)
+			if err != nil {
+				return err
+			}
+
+			return ctx.PrintProto(res)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+
+	return cmd
+}
+
+func CmdQueryRewardForwardingEscrow() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reward-forwarding-escrow delegator-addr",
+		Short: "Query the rewards of a delegator that could not be forwarded",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			delAddr, err := sdk.AccAddressFromBech32(args[0])
+			if err != nil {
+				return err
+			}
+			ctx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			query := types.NewQueryClient(ctx)
+
+			req := &types.QueryRewardForwardingEscrowRequest{DelegatorAddr: delAddr.String()}
+
+			res, err := query.RewardForwardingEscrow(cmd.Context(), req)
+			if err != nil {
+				return err
+			}
+
+			return ctx.PrintProto(res)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+
+	return cmd
+}
+
+func CmdQueryAssetMigration() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "asset-migration",
+		Short: "Query the asset migration whose delegations are still being moved to the new denom",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			query := types.NewQueryClient(ctx)
+
+			res, err := query.AssetMigration(cmd.Context(), &types.QueryAssetMigrationRequest{})
+			if err != nil {
+				return err
+			}
+
+			return ctx.PrintProto(res)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+
+	return cmd
+}
+
+func CmdQueryAssetGroups() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "asset-groups",
+		Short: "Query paginated asset groups",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			pageReq, err := client.ReadPageRequest(cmd.Flags())
+			if err != nil {
+				return err
+			}
+
+			query := types.NewQueryClient(ctx)
+
+			res, err := query.AssetGroups(cmd.Context(), &types.QueryAssetGroupsRequest{
+				Pagination: pageReq,
+			})
+			if err != nil {
+				return err
+			}
+
+			return ctx.PrintProto(res)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+	flags.AddPaginationFlagsToCmd(cmd, "asset-groups")
+
+	return cmd
+}
+
+func CmdQueryAssetGroup() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "asset-group name",
+		Short: "Query an asset group by name",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			query := types.NewQueryClient(ctx)
+
+			res, err := query.AssetGroup(cmd.Context(), &types.QueryAssetGroupRequest{
+				Name: args[0],
+			})
+			if err != nil {
+				return err
+			}
+
+			return ctx.PrintProto(res)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+
+	return cmd
+}
+
+func CmdQueryDelegationLocks() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delegation-locks delegator-addr",
+		Short: "Query paginated delegation locks of a delegator",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			delAddr, err := sdk.AccAddressFromBech32(args[0])
+			if err != nil {
+				return err
+			}
+			ctx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			pageReq, err := client.ReadPageRequest(cmd.Flags())
+			if err != nil {
+				return err
+			}
+
+			query := types.NewQueryClient(ctx)
+
+			res, err := query.AllianceDelegationLocks(cmd.Context(), &types.QueryAllianceDelegationLocksRequest{
+				DelegatorAddr: delAddr.String(),
+				Pagination:    pageReq,
+			})
+			if err != nil {
+				return err
+			}
+
+			return ctx.PrintProto(res)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+	flags.AddPaginationFlagsToCmd(cmd, "delegation-locks")
+
+	return cmd
+}
+
+func CmdQueryRewardIncentives() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reward-incentives",
+		Short: "Query paginated reward incentives",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			pageReq, err := client.ReadPageRequest(cmd.Flags())
+			if err != nil {
+				return err
+			}
+
+			query := types.NewQueryClient(ctx)
+
+			res, err := query.RewardIncentives(cmd.Context(), &types.QueryRewardIncentivesRequest{
+				Pagination: pageReq,
+			})
+			if err != nil {
+				return err
+			}
+
+			return ctx.PrintProto(res)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+	flags.AddPaginationFlagsToCmd(cmd, "reward-incentives")
+
+	return cmd
+}
+
+func CmdQueryRewardIncentive() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reward-incentive id",
+		Short: "Query a reward incentive and its unspent deposit by id",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			id, err := strconv.ParseUint(args[0], 10, 64)
+			if err != nil {
+				return err
+			}
+			ctx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			query := types.NewQueryClient(ctx)
+
+			res, err := query.RewardIncentive(cmd.Context(), &types.QueryRewardIncentiveRequest{
+				Id: id,
+			})
+			if err != nil {
+				return err
+			}
+
+			return ctx.PrintProto(res)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+
+	return cmd
+}
+
+func CmdQueryRewardDust() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reward-dust",
+		Short: "Query the reward dust truncated from claimed rewards and the swept dust waiting to be recycled",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			query := types.NewQueryClient(ctx)
+
+			res, err := query.RewardDust(cmd.Context(), &types.QueryRewardDustRequest{})
+			if err != nil {
+				return err
+			}
+
+			return ctx.PrintProto(res)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+
+	return cmd
+}
+
+func CmdQueryAllianceRewardRates() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reward-rates denom",
+		Short: "Query the annualised reward rates of an alliance derived from the reward rate checkpoints",
+		Long: strings.TrimSpace(
+			fmt.Sprintf(`Query the reward tokens that a token of an alliance asset earns per year, in total and per validator.
+All checkpoints are used unless --%s is set and the rates of all validators are derived unless --%s is set.
+
+Example:
+$ %s query alliance reward-rates ibc/alliance --%s 168h
+`,
+				FlagRateWindow, FlagRateValidator, version.AppName, FlagRateWindow,
+			),
+		),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+			validator, err := cmd.Flags().GetString(FlagRateValidator)
+			if err != nil {
+				return err
+			}
+			window, err := cmd.Flags().GetString(FlagRateWindow)
+			if err != nil {
+				return err
+			}
+
+			query := types.NewQueryClient(ctx)
+
+			res, err := query.AllianceRewardRates(cmd.Context(), &types.QueryAllianceRewardRatesRequest{
+				Denom:         args[0],
+				ValidatorAddr: validator,
+				Window:        window,
+			})
+			if err != nil {
+				return err
+			}
+
+			return ctx.PrintProto(res)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+	cmd.Flags().String(FlagRateValidator, "", "only derive the reward rate of this validator")
+	cmd.Flags().String(FlagRateWindow, "", "only use the checkpoints within this duration before the last one, e.g. 168h")
+
+	return cmd
+}
+
+func CmdQuerySimulateAllianceAction() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "simulate [delegate|undelegate|redelegate] delegator_addr validator_addr amount [dst_validator_addr]",
+		Short: "Simulate a delegation, undelegation or redelegation together with the rebalance at the end of the block",
+		Long: strings.TrimSpace(
+			fmt.Sprintf(`Simulate an alliance action and query the delegation shares, the tokens left in the delegation, the completion
+time, the rewards that are claimed and the change of the validator voting power. Redelegations need the destination
+validator as the last argument.
+
+Example:
+$ %s query alliance simulate undelegate <delegator_addr> <validator_addr> 1000ibc/alliance
+`,
+				version.AppName,
+			),
+		),
+		Args: cobra.RangeArgs(4, 5),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			action, found := types.AllianceAction_value["ALLIANCE_ACTION_"+strings.ToUpper(args[0])]
+			if !found {
+				return fmt.Errorf("unknown alliance action %s", args[0])
+			}
+			coin, err := sdk.ParseCoinNormalized(args[3])
+			if err != nil {
+				return err
+			}
+			dstValidator := ""
+			if len(args) > 4 {
+				dstValidator = args[4]
+			}
+			ctx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			query := types.NewQueryClient(ctx)
+
+			res, err := query.SimulateAllianceAction(cmd.Context(), &types.QuerySimulateAllianceActionRequest{
+				Action:           types.AllianceAction(action),
+				DelegatorAddr:    args[1],
+				ValidatorAddr:    args[2],
+				DstValidatorAddr: dstValidator,
+				Coin:             coin,
+			})
+			if err != nil {
+				return err
+			}
+
+			return ctx.PrintProto(res)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+
+	return cmd
+}
+
+func CmdQueryDelegatorPortfolio() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "portfolio delegator_addr",
+		Short: "Query the alliance delegations of a delegator with their pending rewards and voting power, and the pending undelegations and redelegations",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			query := types.NewQueryClient(ctx)
+
+			res, err := query.DelegatorPortfolio(cmd.Context(), &types.QueryDelegatorPortfolioRequest{
+				DelegatorAddr: args[0],
+			})
+			if err != nil {
+				return err
+			}
+
+			return ctx.PrintProto(res)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+
+	return cmd
+}
+
+func CmdQueryValidatorAllianceDelegations() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delegations-by-validator validator_addr",
+		Short: "Query all paginated alliance delegations to a validator_addr",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			pageReq, err := client.ReadPageRequest(cmd.Flags())
+			if err != nil {
+				return err
+			}
+			denom, err := cmd.Flags().GetString(FlagDenom)
+			if err != nil {
+				return err
+			}
+			query := types.NewQueryClient(ctx)
+
+			res, err := query.ValidatorAllianceDelegations(cmd.Context(), &types.QueryValidatorAllianceDelegationsRequest{
+				ValidatorAddr: args[0],
+				Denom:         denom,
+				Pagination:    pageReq,
+			})
+			if err != nil {
+				return err
+			}
+
+			return ctx.PrintProto(res)
+		},
+	}
+
+	cmd.Flags().String(FlagDenom, "", "only show the delegations of this alliance asset")
+	flags.AddQueryFlagsToCmd(cmd)
+	flags.AddPaginationFlagsToCmd(cmd, "delegations-by-validator")
+
+	return cmd
+}
@@ -2,7 +2,9 @@ package cli
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/terra-money/alliance/x/alliance/types"
 
@@ -14,6 +16,14 @@ import (
 	"github.com/spf13/cobra"
 )
 
+const (
+	FlagForwardingTimeout  = "timeout"
+	FlagIncentiveValidator = "validator"
+	FlagIncentiveStartTime = "start-time"
+
+	DefaultForwardingTimeout = 10 * time.Minute
+)
+
 func NewTxCmd() *cobra.Command {
 	txCmd := &cobra.Command{
 		Use:                        types.ModuleName,
@@ -22,7 +32,9 @@ func NewTxCmd() *cobra.Command {
 		SuggestionsMinimumDistance: 2,
 		RunE:                       client.ValidateCmd,
 	}
-	txCmd.AddCommand(NewDelegateCmd(), NewRedelegateCmd(), NewUndelegateCmd(), NewClaimDelegationRewardsCmd())
+	txCmd.AddCommand(NewDelegateCmd(), NewRedelegateCmd(), NewUndelegateCmd(), NewClaimDelegationRewardsCmd(),
+		NewSetRewardForwardingCmd(), NewClaimRewardForwardingEscrowCmd(), NewDelegateWithLockCmd(),
+		NewFundAllianceRewardsCmd(), NewRefundAllianceRewardsCmd())
 	return txCmd
 }
 
@@ -73,6 +85,53 @@ $ %s tx alliance delegate %s1l2rsakp388kuv9k8qzq6lrm9taddae7fpx59wm 1000stake --
 	return cmd
 }
 
+func NewDelegateWithLockCmd() *cobra.Command {
+	bech32PrefixValAddr := sdk.GetConfig().GetBech32ValidatorAddrPrefix()
+
+	cmd := &cobra.Command{
+		Use:   "delegate-with-lock [validator-addr] [amount] [lock-duration]",
+		Args:  cobra.ExactArgs(3),
+		Short: "Delegate alliance-enabled tokens to a validator and lock them for boosted rewards",
+		Long: strings.TrimSpace(
+			fmt.Sprintf(`Delegate an amount of liquid alliance-enabled coins to a validator and lock them for one of the lock durations
+allowed by the alliance params. Locked tokens cannot be undelegated or redelegated until the lock ends.
+
+Example:
+$ %s tx alliance delegate-with-lock %s1l2rsakp388kuv9k8qzq6lrm9taddae7fpx59wm 1000stake 720h --from mykey
+`,
+				version.AppName, bech32PrefixValAddr,
+			),
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientTxContext(cmd)
+			if err != nil {
+				return err
+			}
+			amount, err := sdk.ParseCoinNormalized(args[1])
+			if err != nil {
+				return err
+			}
+			lockDuration, err := time.ParseDuration(args[2])
+			if err != nil {
+				return err
+			}
+
+			delAddr := clientCtx.GetFromAddress()
+			valAddr, err := sdk.ValAddressFromBech32(args[0])
+			if err != nil {
+				return err
+			}
+
+			msg := types.NewMsgDelegateWithLock(delAddr.String(), valAddr.String(), amount, lockDuration)
+			return tx.GenerateOrBroadcastTxCLI(clientCtx, cmd.Flags(), msg)
+		},
+	}
+
+	flags.AddTxFlagsToCmd(cmd)
+
+	return cmd
+}
+
 func NewRedelegateCmd() *cobra.Command {
 	bech32PrefixValAddr := sdk.GetConfig().GetBech32ValidatorAddrPrefix()
 
@@ -216,3 +275,163 @@ $ %s tx alliance claim-rewards %s1l2rsakp388kuv9k8qzq6lrm9taddae7fpx59wm stake -
 	flags.AddTxFlagsToCmd(cmd)
 	return cmd
 }
+
+func NewSetRewardForwardingCmd() *cobra.Command {
+	bech32PrefixValAddr := sdk.GetConfig().GetBech32ValidatorAddrPrefix()
+	cmd := &cobra.Command{
+		Use:   "set-reward-forwarding validator-addr denom [channel-id] [receiver]",
+		Args:  cobra.RangeArgs(2, 4),
+		Short: "forward the rewards of a delegation to a receiver on another chain over IBC",
+		Long: strings.TrimSpace(
+			fmt.Sprintf(`Forward the rewards of a delegation to a receiver on another chain over IBC. Rewards that
+fail to be forwarded are kept in escrow until they are claimed with claim-forwarding-escrow.
+Omit the channel and receiver to stop forwarding.
+Example:
+$ %s tx alliance set-reward-forwarding %s1l2rsakp388kuv9k8qzq6lrm9taddae7fpx59wm stake channel-0 terra1... --timeout 10m --from mykey
+`,
+				version.AppName, bech32PrefixValAddr,
+			),
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientTxContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			delAddr := clientCtx.GetFromAddress()
+			valAddr, err := sdk.ValAddressFromBech32(args[0])
+			if err != nil {
+				return err
+			}
+
+			var channelID, receiver string
+			if len(args) > 2 {
+				if len(args) != 4 {
+					return fmt.Errorf("both channel-id and receiver are required to set a forwarding")
+				}
+				channelID, receiver = args[2], args[3]
+			}
+
+			timeout, err := cmd.Flags().GetDuration(FlagForwardingTimeout)
+			if err != nil {
+				return err
+			}
+
+			msg := types.NewMsgSetRewardForwarding(delAddr.String(), valAddr.String(), args[1], channelID, receiver, timeout)
+
+			return tx.GenerateOrBroadcastTxCLI(clientCtx, cmd.Flags(), msg)
+		},
+	}
+	cmd.Flags().Duration(FlagForwardingTimeout, DefaultForwardingTimeout, "time after which a forward times out")
+	flags.AddTxFlagsToCmd(cmd)
+	return cmd
+}
+
+func NewClaimRewardForwardingEscrowCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "claim-forwarding-escrow",
+		Args:  cobra.NoArgs,
+		Short: "claim the rewards that could not be forwarded over IBC",
+		Long: strings.TrimSpace(
+			fmt.Sprintf(`Claim the rewards that could not be forwarded over IBC to the delegator account
+Example:
+$ %s tx alliance claim-forwarding-escrow --from mykey
+`,
+				version.AppName,
+			),
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientTxContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			msg := types.NewMsgClaimRewardForwardingEscrow(clientCtx.GetFromAddress().String())
+
+			return tx.GenerateOrBroadcastTxCLI(clientCtx, cmd.Flags(), msg)
+		},
+	}
+	flags.AddTxFlagsToCmd(cmd)
+	return cmd
+}
+
+func NewFundAllianceRewardsCmd() *cobra.Command {
+	bech32PrefixValAddr := sdk.GetConfig().GetBech32ValidatorAddrPrefix()
+
+	cmd := &cobra.Command{
+		Use:   "fund-rewards [denom] [amount] [end-time]",
+		Args:  cobra.ExactArgs(3),
+		Short: "deposit incentives that are streamed to the delegators of an alliance asset",
+		Long: strings.TrimSpace(
+			fmt.Sprintf(`Deposit incentives that are streamed to the delegators of an alliance asset until the end time (RFC3339).
+The stream starts right away unless --%s is set and is shared by the delegators of all validators unless --%s is set.
+The unspent deposit can be refunded with refund-rewards.
+
+Example:
+$ %s tx alliance fund-rewards ibc/alliance 1000000uluna 2024-01-01T00:00:00Z --%s %s1l2rsakp388kuv9k8qzq6lrm9taddae7fpx59wm --from mykey
+`,
+				FlagIncentiveStartTime, FlagIncentiveValidator, version.AppName, FlagIncentiveValidator, bech32PrefixValAddr,
+			),
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientTxContext(cmd)
+			if err != nil {
+				return err
+			}
+			amount, err := sdk.ParseCoinsNormalized(args[1])
+			if err != nil {
+				return err
+			}
+			endTime, err := time.Parse(time.RFC3339, args[2])
+			if err != nil {
+				return err
+			}
+			var startTime time.Time
+			if start, _ := cmd.Flags().GetString(FlagIncentiveStartTime); start != "" {
+				startTime, err = time.Parse(time.RFC3339, start)
+				if err != nil {
+					return err
+				}
+			}
+			validator, _ := cmd.Flags().GetString(FlagIncentiveValidator)
+
+			msg := types.NewMsgFundAllianceRewards(clientCtx.GetFromAddress().String(), args[0], validator, amount, startTime, endTime)
+			return tx.GenerateOrBroadcastTxCLI(clientCtx, cmd.Flags(), msg)
+		},
+	}
+	cmd.Flags().String(FlagIncentiveValidator, "", "only stream the incentives to the delegators of this validator")
+	cmd.Flags().String(FlagIncentiveStartTime, "", "time (RFC3339) at which the stream starts")
+	flags.AddTxFlagsToCmd(cmd)
+	return cmd
+}
+
+func NewRefundAllianceRewardsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "refund-rewards [id]",
+		Args:  cobra.ExactArgs(1),
+		Short: "stop an incentive stream and refund its unspent deposit",
+		Long: strings.TrimSpace(
+			fmt.Sprintf(`Stop the stream of an incentive that was funded by you and refund the part of the deposit that was not distributed yet
+Example:
+$ %s tx alliance refund-rewards 1 --from mykey
+`,
+				version.AppName,
+			),
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientTxContext(cmd)
+			if err != nil {
+				return err
+			}
+			id, err := strconv.ParseUint(args[0], 10, 64)
+			if err != nil {
+				return err
+			}
+
+			msg := types.NewMsgRefundAllianceRewards(clientCtx.GetFromAddress().String(), id)
+			return tx.GenerateOrBroadcastTxCLI(clientCtx, cmd.Flags(), msg)
+		},
+	}
+	flags.AddTxFlagsToCmd(cmd)
+	return cmd
+}
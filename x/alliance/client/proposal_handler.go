@@ -7,7 +7,11 @@ import (
 )
 
 var (
-	CreateAllianceProposalHandler = govclient.NewProposalHandler(cli.CreateAlliance)
-	UpdateAllianceProposalHandler = govclient.NewProposalHandler(cli.UpdateAlliance)
-	DeleteAllianceProposalHandler = govclient.NewProposalHandler(cli.DeleteAlliance)
+	CreateAllianceProposalHandler   = govclient.NewProposalHandler(cli.CreateAlliance)
+	UpdateAllianceProposalHandler   = govclient.NewProposalHandler(cli.UpdateAlliance)
+	DeleteAllianceProposalHandler   = govclient.NewProposalHandler(cli.DeleteAlliance)
+	MigrateAllianceProposalHandler  = govclient.NewProposalHandler(cli.MigrateAlliance)
+	CreateAssetGroupProposalHandler = govclient.NewProposalHandler(cli.CreateAssetGroup)
+	UpdateAssetGroupProposalHandler = govclient.NewProposalHandler(cli.UpdateAssetGroup)
+	DeleteAssetGroupProposalHandler = govclient.NewProposalHandler(cli.DeleteAssetGroup)
 )
@@ -1,10 +1,17 @@
 package alliance
 
 import (
+	"fmt"
+	"sort"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
 	"github.com/terra-money/alliance/x/alliance/types"
 )
 
-// ValidateGenesis
+// ValidateGenesis checks the genesis state on its own and cross-references the assets, asset groups, validators,
+// delegations, redelegations, undelegations, snapshots, reward forwardings and reward incentives with each other so
+// that corrupted exports are rejected before a restart
 func ValidateGenesis(data *types.GenesisState) error {
 	params := data.Params
 	if params.TakeRateClaimInterval <= 0 {
@@ -19,9 +26,370 @@ func ValidateGenesis(data *types.GenesisState) error {
 	if len(data.Redelegations) > 0 && len(data.Delegations) == 0 {
 		return types.ErrInvalidGenesisState.Wrap("cannot have redelegations without delegations")
 	}
+
+	assets, err := validateGenesisAssets(data.Assets)
+	if err != nil {
+		return err
+	}
+	if err := validateGenesisAssetGroups(data.AssetGroups, assets); err != nil {
+		return err
+	}
+	infos, err := validateGenesisValidatorInfos(data.ValidatorInfos)
+	if err != nil {
+		return err
+	}
+	if err := validateGenesisDelegations(data.Delegations, assets, infos); err != nil {
+		return err
+	}
+
+	var validatorInfos []types.AllianceValidatorInfo
+	for _, info := range data.ValidatorInfos {
+		validatorInfos = append(validatorInfos, info.Validator)
+	}
+	if err := validateValidatorShares(data.Assets, validatorInfos); err != nil {
+		return types.ErrInvalidGenesisState.Wrap(err.Error())
+	}
+	if err := validateDelegatorShares(data.Delegations, infos); err != nil {
+		return types.ErrInvalidGenesisState.Wrap(err.Error())
+	}
+
+	if err := validateGenesisRedelegations(data.Redelegations, data.Delegations, infos); err != nil {
+		return err
+	}
+	if err := validateGenesisUndelegations(data.Undelegations, infos); err != nil {
+		return err
+	}
+	if err := validateGenesisSnapshots(data.RewardWeightChangeSnaphots, assets, infos); err != nil {
+		return err
+	}
+	if err := validateGenesisDelegationLocks(data.DelegationLocks, data.Delegations, infos); err != nil {
+		return err
+	}
+	if err := validateGenesisRewardIncentives(data.RewardIncentives, data.NextRewardIncentiveId); err != nil {
+		return err
+	}
+	if !data.RewardDust.IsValid() {
+		return types.ErrInvalidGenesisState.Wrapf("invalid reward dust %s", data.RewardDust)
+	}
+	if !data.RecycledRewardDust.IsValid() {
+		return types.ErrInvalidGenesisState.Wrapf("invalid recycled reward dust %s", data.RecycledRewardDust)
+	}
+	if err := validateGenesisRewardRateCheckpoints(data.RewardRateCheckpoints, data.RewardWeightsCheckpoints); err != nil {
+		return err
+	}
+	return validateGenesisRewardForwarding(data, data.Delegations)
+}
+
+func validateGenesisAssets(assets []types.AllianceAsset) (map[string]types.AllianceAsset, error) {
+	denoms := make(map[string]types.AllianceAsset, len(assets))
+	for _, asset := range assets {
+		if err := sdk.ValidateDenom(asset.Denom); err != nil {
+			return nil, types.ErrInvalidGenesisState.Wrapf("asset denom %s is invalid: %s", asset.Denom, err)
+		}
+		if _, found := denoms[asset.Denom]; found {
+			return nil, types.ErrInvalidGenesisState.Wrapf("asset %s is duplicated", asset.Denom)
+		}
+		denoms[asset.Denom] = asset
+
+		if asset.RewardWeight.IsNil() || asset.RewardWeightRange.Min.IsNil() || asset.RewardWeightRange.Max.IsNil() {
+			return nil, types.ErrInvalidGenesisState.Wrapf("asset %s must have a reward_weight and reward_weight_range", asset.Denom)
+		}
+		if asset.RewardWeight.LT(asset.RewardWeightRange.Min) || asset.RewardWeight.GT(asset.RewardWeightRange.Max) {
+			return nil, types.ErrInvalidGenesisState.Wrapf("asset %s reward_weight %s is not within reward_weight_range [%s, %s]",
+				asset.Denom, asset.RewardWeight, asset.RewardWeightRange.Min, asset.RewardWeightRange.Max)
+		}
+		if asset.TakeRate.IsNil() || asset.TakeRate.IsNegative() || asset.TakeRate.GTE(sdk.OneDec()) {
+			return nil, types.ErrInvalidGenesisState.Wrapf("asset %s take_rate %s must be more or equal to 0 but strictly less than 1", asset.Denom, asset.TakeRate)
+		}
+		if asset.TotalTokens.IsNil() || asset.TotalTokens.IsNegative() {
+			return nil, types.ErrInvalidGenesisState.Wrapf("asset %s total_tokens must not be negative", asset.Denom)
+		}
+		if asset.TotalValidatorShares.IsNil() || asset.TotalValidatorShares.IsNegative() {
+			return nil, types.ErrInvalidGenesisState.Wrapf("asset %s total_validator_shares must not be negative", asset.Denom)
+		}
+		if asset.ConversionRate != nil && (asset.ConversionRate.IsNil() || !asset.ConversionRate.IsPositive()) {
+			return nil, types.ErrInvalidGenesisState.Wrapf("asset %s conversion_rate must be positive", asset.Denom)
+		}
+		if asset.RewardWeightCurve != nil {
+			if err := asset.RewardWeightCurve.Validate(); err != nil {
+				return nil, types.ErrInvalidGenesisState.Wrapf("asset %s: %s", asset.Denom, err)
+			}
+		}
+	}
+	return denoms, nil
+}
+
+func validateGenesisAssetGroups(groups []types.AssetGroup, assets map[string]types.AllianceAsset) error {
+	names := make(map[string]bool, len(groups))
+	members := make(map[string]string)
+	for _, group := range groups {
+		if err := group.Validate(); err != nil {
+			return types.ErrInvalidGenesisState.Wrap(err.Error())
+		}
+		if names[group.Name] {
+			return types.ErrInvalidGenesisState.Wrapf("asset group %s is duplicated", group.Name)
+		}
+		names[group.Name] = true
+		for _, member := range group.Members {
+			if _, found := assets[member.Denom]; !found {
+				return types.ErrInvalidGenesisState.Wrapf("member %s of asset group %s is not an alliance asset", member.Denom, group.Name)
+			}
+			if other, found := members[member.Denom]; found {
+				return types.ErrInvalidGenesisState.Wrapf("asset %s is a member of both %s and %s", member.Denom, other, group.Name)
+			}
+			members[member.Denom] = group.Name
+		}
+		if err := group.ValidateMemberRanges(assets); err != nil {
+			return types.ErrInvalidGenesisState.Wrap(err.Error())
+		}
+	}
+	return nil
+}
+
+func validateGenesisValidatorInfos(validatorInfos []types.ValidatorInfoState) (map[string]types.AllianceValidatorInfo, error) {
+	infos := make(map[string]types.AllianceValidatorInfo, len(validatorInfos))
+	for _, info := range validatorInfos {
+		if _, err := sdk.ValAddressFromBech32(info.ValidatorAddress); err != nil {
+			return nil, types.ErrInvalidGenesisState.Wrapf("validator info address %s is invalid: %s", info.ValidatorAddress, err)
+		}
+		if _, found := infos[info.ValidatorAddress]; found {
+			return nil, types.ErrInvalidGenesisState.Wrapf("validator info %s is duplicated", info.ValidatorAddress)
+		}
+		infos[info.ValidatorAddress] = info.Validator
+	}
+	return infos, nil
+}
+
+func validateGenesisDelegations(delegations []types.Delegation, assets map[string]types.AllianceAsset, infos map[string]types.AllianceValidatorInfo) error {
+	seen := make(map[string]bool, len(delegations))
+	for _, delegation := range delegations {
+		if _, err := sdk.AccAddressFromBech32(delegation.DelegatorAddress); err != nil {
+			return types.ErrInvalidGenesisState.Wrapf("delegator address %s is invalid: %s", delegation.DelegatorAddress, err)
+		}
+		if _, found := infos[delegation.ValidatorAddress]; !found {
+			return types.ErrInvalidGenesisState.Wrapf("delegation of %s references validator %s without validator info",
+				delegation.DelegatorAddress, delegation.ValidatorAddress)
+		}
+		if _, found := assets[delegation.Denom]; !found {
+			return types.ErrInvalidGenesisState.Wrapf("delegation of %s to %s references unknown asset %s",
+				delegation.DelegatorAddress, delegation.ValidatorAddress, delegation.Denom)
+		}
+		key := delegationKey(delegation.DelegatorAddress, delegation.ValidatorAddress, delegation.Denom)
+		if seen[key] {
+			return types.ErrInvalidGenesisState.Wrapf("delegation of %s %s to %s is duplicated",
+				delegation.DelegatorAddress, delegation.Denom, delegation.ValidatorAddress)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// validateGenesisRedelegations checks that every redelegation still backs a delegation to its destination validator,
+// since the redelegation is slashed through that delegation
+func validateGenesisRedelegations(redelegations []types.RedelegationState, delegations []types.Delegation, infos map[string]types.AllianceValidatorInfo) error {
+	existing := make(map[string]bool, len(delegations))
+	for _, delegation := range delegations {
+		existing[delegationKey(delegation.DelegatorAddress, delegation.ValidatorAddress, delegation.Denom)] = true
+	}
+	for _, state := range redelegations {
+		redelegation := state.Redelegation
+		if _, found := infos[redelegation.SrcValidatorAddress]; !found {
+			return types.ErrInvalidGenesisState.Wrapf("redelegation of %s references source validator %s without validator info",
+				redelegation.DelegatorAddress, redelegation.SrcValidatorAddress)
+		}
+		if !existing[delegationKey(redelegation.DelegatorAddress, redelegation.DstValidatorAddress, redelegation.Balance.Denom)] {
+			return types.ErrInvalidGenesisState.Wrapf("redelegation of %s %s from %s references a missing delegation to %s",
+				redelegation.DelegatorAddress, redelegation.Balance.Denom, redelegation.SrcValidatorAddress, redelegation.DstValidatorAddress)
+		}
+		if !redelegation.Balance.IsValid() {
+			return types.ErrInvalidGenesisState.Wrapf("redelegation of %s from %s has an invalid balance %s",
+				redelegation.DelegatorAddress, redelegation.SrcValidatorAddress, redelegation.Balance)
+		}
+	}
 	return nil
 }
 
+func validateGenesisUndelegations(undelegations []types.UndelegationState, infos map[string]types.AllianceValidatorInfo) error {
+	for _, state := range undelegations {
+		if len(state.Undelegation.Entries) == 0 {
+			continue
+		}
+		// InitGenesis queues all entries under the delegator of the first entry
+		delegator := state.Undelegation.Entries[0].DelegatorAddress
+		for _, entry := range state.Undelegation.Entries {
+			if _, err := sdk.AccAddressFromBech32(entry.DelegatorAddress); err != nil {
+				return types.ErrInvalidGenesisState.Wrapf("undelegation delegator address %s is invalid: %s", entry.DelegatorAddress, err)
+			}
+			if entry.DelegatorAddress != delegator {
+				return types.ErrInvalidGenesisState.Wrapf("undelegations completing at %s mix delegators %s and %s",
+					state.CompletionTime, delegator, entry.DelegatorAddress)
+			}
+			if _, found := infos[entry.ValidatorAddress]; !found {
+				return types.ErrInvalidGenesisState.Wrapf("undelegation of %s references validator %s without validator info",
+					entry.DelegatorAddress, entry.ValidatorAddress)
+			}
+			if !entry.Balance.IsValid() {
+				return types.ErrInvalidGenesisState.Wrapf("undelegation of %s from %s has an invalid balance %s",
+					entry.DelegatorAddress, entry.ValidatorAddress, entry.Balance)
+			}
+		}
+	}
+	return nil
+}
+
+// validateGenesisSnapshots checks that every snapshot belongs to a known asset and validator, and that the reward
+// indices recorded by the snapshots of a validator never decrease with height nor exceed the current indices
+func validateGenesisSnapshots(snapshots []types.RewardWeightChangeSnapshotState, assets map[string]types.AllianceAsset, infos map[string]types.AllianceValidatorInfo) error {
+	byAssetAndValidator := map[string][]types.RewardWeightChangeSnapshotState{}
+	for _, snapshot := range snapshots {
+		if _, found := assets[snapshot.Denom]; !found {
+			return types.ErrInvalidGenesisState.Wrapf("snapshot at height %d references unknown asset %s", snapshot.Height, snapshot.Denom)
+		}
+		if _, found := infos[snapshot.Validator]; !found {
+			return types.ErrInvalidGenesisState.Wrapf("snapshot at height %d references validator %s without validator info",
+				snapshot.Height, snapshot.Validator)
+		}
+		if snapshot.Snapshot.PrevRewardWeight.IsNil() || snapshot.Snapshot.PrevRewardWeight.IsNegative() {
+			return types.ErrInvalidGenesisState.Wrapf("snapshot of %s on %s at height %d has a negative reward weight",
+				snapshot.Denom, snapshot.Validator, snapshot.Height)
+		}
+		key := snapshot.Denom + "/" + snapshot.Validator
+		byAssetAndValidator[key] = append(byAssetAndValidator[key], snapshot)
+	}
+
+	for _, group := range byAssetAndValidator {
+		sort.Slice(group, func(i, j int) bool { return group[i].Height < group[j].Height })
+		current := types.NewRewardHistories(infos[group[0].Validator].GlobalRewardHistory)
+		var previous types.RewardHistories
+		for i, snapshot := range group {
+			if i > 0 && snapshot.Height == group[i-1].Height {
+				return types.ErrInvalidGenesisState.Wrapf("snapshot of %s on %s at height %d is duplicated",
+					snapshot.Denom, snapshot.Validator, snapshot.Height)
+			}
+			for _, history := range snapshot.Snapshot.RewardHistories {
+				if prev, found := previous.GetIndexByAlliance(history.Denom, history.Alliance); found && history.Index.LT(prev.Index) {
+					return types.ErrInvalidGenesisState.Wrapf("snapshot of %s on %s at height %d has a %s reward index lower than at height %d",
+						snapshot.Denom, snapshot.Validator, snapshot.Height, history.Denom, group[i-1].Height)
+				}
+				if latest, found := current.GetIndexByAlliance(history.Denom, history.Alliance); !found || history.Index.GT(latest.Index) {
+					return types.ErrInvalidGenesisState.Wrapf("snapshot of %s on %s at height %d has a %s reward index above the validator reward history",
+						snapshot.Denom, snapshot.Validator, snapshot.Height, history.Denom)
+				}
+			}
+			previous = types.NewRewardHistories(snapshot.Snapshot.RewardHistories)
+		}
+	}
+	return nil
+}
+
+// validateGenesisDelegationLocks checks that the locked shares of a delegation do not exceed its shares and that the
+// boost shares of the locks add up to the boosted delegator shares of each validator
+func validateGenesisDelegationLocks(locks []types.DelegationLock, delegations []types.Delegation, infos map[string]types.AllianceValidatorInfo) error {
+	delegationShares := make(map[string]sdk.Dec, len(delegations))
+	for _, delegation := range delegations {
+		delegationShares[delegationKey(delegation.DelegatorAddress, delegation.ValidatorAddress, delegation.Denom)] = delegation.Shares
+	}
+	lockedShares := make(map[string]sdk.Dec, len(locks))
+	boostShares := make(map[string]sdk.DecCoins, len(infos))
+	seen := make(map[string]bool, len(locks))
+	for _, lock := range locks {
+		key := delegationKey(lock.DelegatorAddress, lock.ValidatorAddress, lock.Denom)
+		shares, found := delegationShares[key]
+		if !found {
+			return types.ErrInvalidGenesisState.Wrapf("delegation lock of %s %s to %s references a missing delegation",
+				lock.DelegatorAddress, lock.Denom, lock.ValidatorAddress)
+		}
+		if !lock.Shares.IsPositive() || lock.BoostShares.IsNegative() {
+			return types.ErrInvalidGenesisState.Wrapf("delegation lock of %s %s to %s has invalid shares %s and boost shares %s",
+				lock.DelegatorAddress, lock.Denom, lock.ValidatorAddress, lock.Shares, lock.BoostShares)
+		}
+		lockKey := fmt.Sprintf("%s/%d", key, lock.EndTime.UnixNano())
+		if seen[lockKey] {
+			return types.ErrInvalidGenesisState.Wrapf("delegation lock of %s %s to %s ending at %s is duplicated",
+				lock.DelegatorAddress, lock.Denom, lock.ValidatorAddress, lock.EndTime)
+		}
+		seen[lockKey] = true
+
+		locked, found := lockedShares[key]
+		if !found {
+			locked = sdk.ZeroDec()
+		}
+		lockedShares[key] = locked.Add(lock.Shares)
+		if lockedShares[key].GT(shares) {
+			return types.ErrInvalidGenesisState.Wrapf("delegation of %s %s to %s has %s locked shares but only %s shares",
+				lock.DelegatorAddress, lock.Denom, lock.ValidatorAddress, lockedShares[key], shares)
+		}
+		boostShares[lock.ValidatorAddress] = boostShares[lock.ValidatorAddress].Add(sdk.NewDecCoinFromDec(lock.Denom, lock.BoostShares))
+	}
+
+	for valAddr, info := range infos {
+		if diff, negative := sdk.DecCoins(info.BoostedDelegatorShares).SafeSub(boostShares[valAddr]); negative || !diff.IsZero() {
+			return types.ErrInvalidGenesisState.Wrapf("validator %s has %s boosted delegator shares but its delegation locks add up to %s",
+				valAddr, sdk.DecCoins(info.BoostedDelegatorShares), boostShares[valAddr])
+		}
+	}
+	return nil
+}
+
+// validateGenesisRewardForwarding checks that every forwarding belongs to a delegation, and that pending forwards and
+// escrows are unique and hold valid coins
+func validateGenesisRewardForwarding(data *types.GenesisState, delegations []types.Delegation) error {
+	existing := make(map[string]bool, len(delegations))
+	for _, delegation := range delegations {
+		existing[delegationKey(delegation.DelegatorAddress, delegation.ValidatorAddress, delegation.Denom)] = true
+	}
+	seen := make(map[string]bool, len(data.RewardForwardings))
+	for _, forwarding := range data.RewardForwardings {
+		if err := forwarding.Validate(); err != nil {
+			return types.ErrInvalidGenesisState.Wrap(err.Error())
+		}
+		key := delegationKey(forwarding.DelegatorAddress, forwarding.ValidatorAddress, forwarding.Denom)
+		if !existing[key] {
+			return types.ErrInvalidGenesisState.Wrapf("reward forwarding of %s %s to %s references a missing delegation",
+				forwarding.DelegatorAddress, forwarding.Denom, forwarding.ValidatorAddress)
+		}
+		if seen[key] {
+			return types.ErrInvalidGenesisState.Wrapf("reward forwarding of %s %s to %s is duplicated",
+				forwarding.DelegatorAddress, forwarding.Denom, forwarding.ValidatorAddress)
+		}
+		seen[key] = true
+	}
+
+	pendings := make(map[string]bool, len(data.PendingRewardForwards))
+	for _, pending := range data.PendingRewardForwards {
+		if _, err := sdk.AccAddressFromBech32(pending.DelegatorAddress); err != nil {
+			return types.ErrInvalidGenesisState.Wrapf("pending reward forward delegator address %s is invalid: %s", pending.DelegatorAddress, err)
+		}
+		if !pending.Amount.IsValid() {
+			return types.ErrInvalidGenesisState.Wrapf("pending reward forward of %s has an invalid amount %s", pending.DelegatorAddress, pending.Amount)
+		}
+		key := fmt.Sprintf("%s/%d", pending.ChannelId, pending.Sequence)
+		if pendings[key] {
+			return types.ErrInvalidGenesisState.Wrapf("pending reward forward on %s with sequence %d is duplicated", pending.ChannelId, pending.Sequence)
+		}
+		pendings[key] = true
+	}
+
+	escrows := make(map[string]bool, len(data.RewardForwardingEscrows))
+	for _, escrow := range data.RewardForwardingEscrows {
+		if _, err := sdk.AccAddressFromBech32(escrow.DelegatorAddress); err != nil {
+			return types.ErrInvalidGenesisState.Wrapf("reward forwarding escrow delegator address %s is invalid: %s", escrow.DelegatorAddress, err)
+		}
+		if err := escrow.Coins.Validate(); err != nil {
+			return types.ErrInvalidGenesisState.Wrapf("reward forwarding escrow of %s is invalid: %s", escrow.DelegatorAddress, err)
+		}
+		if escrows[escrow.DelegatorAddress] {
+			return types.ErrInvalidGenesisState.Wrapf("reward forwarding escrow of %s is duplicated", escrow.DelegatorAddress)
+		}
+		escrows[escrow.DelegatorAddress] = true
+	}
+	return nil
+}
+
+func delegationKey(delegator string, validator string, denom string) string {
+	return delegator + "/" + validator + "/" + denom
+}
+
 func DefaultGenesisState() *types.GenesisState {
 	return &types.GenesisState{
 		Params:                     types.DefaultParams(),
@@ -31,5 +399,44 @@ func DefaultGenesisState() *types.GenesisState {
 		Delegations:                []types.Delegation{},
 		Redelegations:              []types.RedelegationState{},
 		Undelegations:              []types.UndelegationState{},
+		RewardForwardings:          []types.RewardForwarding{},
+		PendingRewardForwards:      []types.PendingRewardForward{},
+		RewardForwardingEscrows:    []types.RewardForwardingEscrow{},
 	}
 }
+
+// validateGenesisRewardIncentives checks the incentives on their own and that their ids are unique and below the next
+// id. Incentives of assets that were removed are kept until they are refunded.
+func validateGenesisRewardIncentives(incentives []types.RewardIncentive, nextID uint64) error {
+	ids := make(map[uint64]bool, len(incentives))
+	for _, incentive := range incentives {
+		if err := incentive.Validate(); err != nil {
+			return types.ErrInvalidGenesisState.Wrapf("reward incentive %d: %s", incentive.Id, err)
+		}
+		if ids[incentive.Id] {
+			return types.ErrInvalidGenesisState.Wrapf("reward incentive %d is duplicated", incentive.Id)
+		}
+		ids[incentive.Id] = true
+		if incentive.Id == 0 || incentive.Id >= nextID {
+			return types.ErrInvalidGenesisState.Wrapf("reward incentive id %d must be between 1 and the next id %d", incentive.Id, nextID)
+		}
+	}
+	return nil
+}
+
+func validateGenesisRewardRateCheckpoints(checkpoints []types.RewardRateCheckpoint, weightsCheckpoints []types.RewardWeightsCheckpoint) error {
+	for _, checkpoint := range checkpoints {
+		if _, err := sdk.ValAddressFromBech32(checkpoint.ValidatorAddress); err != nil {
+			return types.ErrInvalidGenesisState.Wrapf("reward rate checkpoint validator address %s is invalid: %s", checkpoint.ValidatorAddress, err)
+		}
+		if !checkpoint.Tokens.IsValid() {
+			return types.ErrInvalidGenesisState.Wrapf("reward rate checkpoint of %s at %s has invalid tokens %s", checkpoint.ValidatorAddress, checkpoint.Time, checkpoint.Tokens)
+		}
+	}
+	for _, checkpoint := range weightsCheckpoints {
+		if !checkpoint.RewardWeights.IsValid() {
+			return types.ErrInvalidGenesisState.Wrapf("reward weights checkpoint at %s has invalid weights %s", checkpoint.Time, checkpoint.RewardWeights)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package alliance
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	capabilitytypes "github.com/cosmos/cosmos-sdk/x/capability/types"
+	transfertypes "github.com/cosmos/ibc-go/v6/modules/apps/transfer/types"
+	channeltypes "github.com/cosmos/ibc-go/v6/modules/core/04-channel/types"
+	porttypes "github.com/cosmos/ibc-go/v6/modules/core/05-port/types"
+	ibcexported "github.com/cosmos/ibc-go/v6/modules/core/exported"
+
+	"github.com/terra-money/alliance/x/alliance/keeper"
+	"github.com/terra-money/alliance/x/alliance/types"
+)
+
+var _ porttypes.IBCModule = IBCMiddleware{}
+
+// IBCMiddleware wraps the ICS-20 transfer module and delegates the received tokens to alliance when the packet memo
+// contains an alliance object. The transfer and the delegation are atomic: when the delegation fails, an error
+// acknowledgement is returned so that the tokens are refunded to the sender. It also escrows the rewards of reward
+// forwards that failed or timed out.
+type IBCMiddleware struct {
+	app    porttypes.IBCModule
+	keeper keeper.Keeper
+}
+
+func NewIBCMiddleware(app porttypes.IBCModule, k keeper.Keeper) IBCMiddleware {
+	return IBCMiddleware{
+		app:    app,
+		keeper: k,
+	}
+}
+
+func (im IBCMiddleware) OnChanOpenInit(ctx sdk.Context, order channeltypes.Order, connectionHops []string, portID string, channelID string, channelCap *capabilitytypes.Capability, counterparty channeltypes.Counterparty, version string) (string, error) {
+	return im.app.OnChanOpenInit(ctx, order, connectionHops, portID, channelID, channelCap, counterparty, version)
+}
+
+func (im IBCMiddleware) OnChanOpenTry(ctx sdk.Context, order channeltypes.Order, connectionHops []string, portID, channelID string, channelCap *capabilitytypes.Capability, counterparty channeltypes.Counterparty, counterpartyVersion string) (version string, err error) {
+	return im.app.OnChanOpenTry(ctx, order, connectionHops, portID, channelID, channelCap, counterparty, counterpartyVersion)
+}
+
+func (im IBCMiddleware) OnChanOpenAck(ctx sdk.Context, portID, channelID string, counterpartyChannelID string, counterpartyVersion string) error {
+	return im.app.OnChanOpenAck(ctx, portID, channelID, counterpartyChannelID, counterpartyVersion)
+}
+
+func (im IBCMiddleware) OnChanOpenConfirm(ctx sdk.Context, portID, channelID string) error {
+	return im.app.OnChanOpenConfirm(ctx, portID, channelID)
+}
+
+func (im IBCMiddleware) OnChanCloseInit(ctx sdk.Context, portID, channelID string) error {
+	return im.app.OnChanCloseInit(ctx, portID, channelID)
+}
+
+func (im IBCMiddleware) OnChanCloseConfirm(ctx sdk.Context, portID, channelID string) error {
+	return im.app.OnChanCloseConfirm(ctx, portID, channelID)
+}
+
+// OnRecvPacket runs the transfer and the alliance delegation in a cache context which is only written when both succeed
+func (im IBCMiddleware) OnRecvPacket(ctx sdk.Context, packet channeltypes.Packet, relayer sdk.AccAddress) ibcexported.Acknowledgement {
+	var data transfertypes.FungibleTokenPacketData
+	if err := transfertypes.ModuleCdc.UnmarshalJSON(packet.GetData(), &data); err != nil {
+		return im.app.OnRecvPacket(ctx, packet, relayer)
+	}
+	memo, err := types.ParseIBCDelegateMemo(data.GetMemo())
+	if err != nil {
+		return channeltypes.NewErrorAcknowledgement(err)
+	}
+	if memo == nil {
+		return im.app.OnRecvPacket(ctx, packet, relayer)
+	}
+
+	cacheCtx, writeCache := ctx.CacheContext()
+	ack := im.app.OnRecvPacket(cacheCtx, packet, relayer)
+	if ack == nil || !ack.Success() {
+		return ack
+	}
+	if err := im.delegate(cacheCtx, packet, data, *memo); err != nil {
+		return channeltypes.NewErrorAcknowledgement(err)
+	}
+	writeCache()
+	return ack
+}
+
+// OnAcknowledgementPacket lets the transfer module refund failed transfers first, so that the rewards of a failed
+// reward forward are back in the reward forwarding module account when they are escrowed
+func (im IBCMiddleware) OnAcknowledgementPacket(ctx sdk.Context, packet channeltypes.Packet, acknowledgement []byte, relayer sdk.AccAddress) error {
+	if err := im.app.OnAcknowledgementPacket(ctx, packet, acknowledgement, relayer); err != nil {
+		return err
+	}
+	var ack channeltypes.Acknowledgement
+	if err := transfertypes.ModuleCdc.UnmarshalJSON(acknowledgement, &ack); err != nil {
+		return nil
+	}
+	if ack.Success() {
+		im.keeper.OnRewardForwardAcknowledged(ctx, packet.GetSourceChannel(), packet.GetSequence())
+	} else {
+		im.keeper.OnRewardForwardFailed(ctx, packet.GetSourceChannel(), packet.GetSequence(), ack.GetError())
+	}
+	return nil
+}
+
+func (im IBCMiddleware) OnTimeoutPacket(ctx sdk.Context, packet channeltypes.Packet, relayer sdk.AccAddress) error {
+	if err := im.app.OnTimeoutPacket(ctx, packet, relayer); err != nil {
+		return err
+	}
+	im.keeper.OnRewardForwardFailed(ctx, packet.GetSourceChannel(), packet.GetSequence(), "packet timed out")
+	return nil
+}
+
+// delegate delegates the tokens that the transfer module credited to the receiver. The memo receiver has to be the
+// packet receiver so that a memo cannot delegate the tokens of another account.
+func (im IBCMiddleware) delegate(ctx sdk.Context, packet channeltypes.Packet, data transfertypes.FungibleTokenPacketData, memo types.IBCDelegateMemo) error {
+	if memo.Receiver != data.Receiver {
+		return types.ErrInvalidIBCMemo.Wrapf("memo receiver %s does not match the packet receiver %s", memo.Receiver, data.Receiver)
+	}
+	amount, ok := sdk.NewIntFromString(data.Amount)
+	if !ok {
+		return transfertypes.ErrInvalidAmount.Wrapf("unable to parse transfer amount %s", data.Amount)
+	}
+	denom := receivedDenom(packet, data.Denom)
+	if _, found := im.keeper.GetAssetByDenom(ctx, denom); !found {
+		return types.ErrUnknownAsset.Wrapf("%s", denom)
+	}
+
+	delAddr := sdk.MustAccAddressFromBech32(memo.Receiver)
+	valAddr, err := sdk.ValAddressFromBech32(memo.Validator)
+	if err != nil {
+		return err
+	}
+	validator, err := im.keeper.GetAllianceValidator(ctx, valAddr)
+	if err != nil {
+		return err
+	}
+	_, err = im.keeper.Delegate(ctx, delAddr, validator, sdk.NewCoin(denom, amount))
+	return err
+}
+
+// receivedDenom returns the denom of the voucher that the transfer module credits for a packet denom, following the
+// same rules as the transfer module OnRecvPacket
+func receivedDenom(packet channeltypes.Packet, packetDenom string) string {
+	if transfertypes.ReceiverChainIsSource(packet.GetSourcePort(), packet.GetSourceChannel(), packetDenom) {
+		voucherPrefix := transfertypes.GetDenomPrefix(packet.GetSourcePort(), packet.GetSourceChannel())
+		denomTrace := transfertypes.ParseDenomTrace(packetDenom[len(voucherPrefix):])
+		return denomTrace.IBCDenom()
+	}
+	prefixedDenom := transfertypes.GetPrefixedDenom(packet.GetDestPort(), packet.GetDestChannel(), packetDenom)
+	return transfertypes.ParseDenomTrace(prefixedDenom).IBCDenom()
+}
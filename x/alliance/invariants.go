@@ -2,6 +2,7 @@ package alliance
 
 import (
 	"fmt"
+	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 
@@ -12,108 +13,312 @@ import (
 func RegisterInvariants(ir sdk.InvariantRegistry, k keeper.Keeper) {
 	ir.RegisterRoute(types.ModuleName, "validator-shares", ValidatorSharesInvariant(k))
 	ir.RegisterRoute(types.ModuleName, "delegator-shares", DelegatorSharesInvariant(k))
+	ir.RegisterRoute(types.ModuleName, "module-balance", ModuleBalanceInvariant(k))
+	ir.RegisterRoute(types.ModuleName, "rewards-pool", RewardsPoolInvariant(k))
+	ir.RegisterRoute(types.ModuleName, "rebalance-target", RebalanceTargetInvariant(k))
+	ir.RegisterRoute(types.ModuleName, "reward-forwarding-escrow", RewardForwardingEscrowInvariant(k))
+	ir.RegisterRoute(types.ModuleName, "reward-incentives", RewardIncentivesInvariant(k))
+	ir.RegisterRoute(types.ModuleName, "delegation-index", DelegationIndexInvariant(k))
 }
 
+// RunAllInvariants runs every invariant of the module
 func RunAllInvariants(ctx sdk.Context, k keeper.Keeper) (res string, stop bool) {
+	res, stop = RunBlockInvariants(ctx, k)
+	if stop {
+		return res, stop
+	}
+	return RewardsPoolInvariant(k)(ctx)
+}
+
+// RunBlockInvariants runs the invariants that are cheap enough to be asserted after every block. RewardsPoolInvariant
+// calculates the rewards of every delegation, so it is left to the invariant check period of the crisis module.
+func RunBlockInvariants(ctx sdk.Context, k keeper.Keeper) (res string, stop bool) {
 	res, stop = ValidatorSharesInvariant(k)(ctx)
 	if stop {
 		return res, stop
 	}
 	res, stop = DelegatorSharesInvariant(k)(ctx)
+	if stop {
+		return res, stop
+	}
+	res, stop = ModuleBalanceInvariant(k)(ctx)
+	if stop {
+		return res, stop
+	}
+	res, stop = RebalanceTargetInvariant(k)(ctx)
+	if stop {
+		return res, stop
+	}
+	res, stop = RewardForwardingEscrowInvariant(k)(ctx)
+	if stop {
+		return res, stop
+	}
+	res, stop = RewardIncentivesInvariant(k)(ctx)
+	if stop {
+		return res, stop
+	}
+	res, stop = DelegationIndexInvariant(k)(ctx)
 	return res, stop
 }
 
 func ValidatorSharesInvariant(k keeper.Keeper) sdk.Invariant {
+	return func(ctx sdk.Context) (string, bool) {
+		var assets []types.AllianceAsset
+		for _, asset := range k.GetAllAssets(ctx) {
+			assets = append(assets, *asset)
+		}
+		if err := validateValidatorShares(assets, k.GetAllAllianceValidatorInfo(ctx)); err != nil {
+			return sdk.FormatInvariant(types.ModuleName, "validator shares", err.Error()+"\n"), true
+		}
+		return sdk.FormatInvariant(types.ModuleName, "validator shares", ""), false
+	}
+}
+
+func DelegatorSharesInvariant(k keeper.Keeper) sdk.Invariant {
+	return func(ctx sdk.Context) (string, bool) {
+		var delegations []types.Delegation
+		// Delegations that were not moved yet by an asset migration still use the old denom
+		migration, migrating := k.GetAssetMigration(ctx)
+		k.IterateDelegations(ctx, func(delegation types.Delegation) bool {
+			if migrating && delegation.Denom == migration.FromDenom {
+				delegation.Denom = migration.ToDenom
+			}
+			delegations = append(delegations, delegation)
+			return false
+		})
+		infos := map[string]types.AllianceValidatorInfo{}
+		k.IterateAllianceValidatorInfo(ctx, func(valAddr sdk.ValAddress, info types.AllianceValidatorInfo) bool {
+			infos[valAddr.String()] = info
+			return false
+		})
+		if err := validateDelegatorShares(delegations, infos); err != nil {
+			return sdk.FormatInvariant(types.ModuleName, "delegations shares", err.Error()+"\n"), true
+		}
+		return sdk.FormatInvariant(types.ModuleName, "delegations shares", ""), false
+	}
+}
+
+// validateValidatorShares checks that the validator shares of all validators add up to the total validator shares of
+// each asset. It is shared by the invariants and the genesis validation.
+func validateValidatorShares(assets []types.AllianceAsset, infos []types.AllianceValidatorInfo) error {
+	validatorShares := map[string]sdk.Dec{} // {denom: shares}
+	for _, info := range infos {
+		for _, share := range info.ValidatorShares {
+			if share.IsNegative() {
+				return fmt.Errorf("negative validator shares found: %s", share)
+			}
+			if validatorShares[share.Denom].IsNil() {
+				validatorShares[share.Denom] = share.Amount
+			} else {
+				validatorShares[share.Denom] = validatorShares[share.Denom].Add(share.Amount)
+			}
+		}
+	}
+	for _, asset := range assets {
+		if !validatorShares[asset.Denom].IsNil() && !asset.TotalValidatorShares.Equal(validatorShares[asset.Denom]) {
+			return fmt.Errorf("broken alliance validator share invariance: "+
+				"asset (%s) TotalValidatorShares: %s, sum of validator shares: %s",
+				asset.Denom, asset.TotalValidatorShares, validatorShares[asset.Denom])
+		}
+	}
+	return nil
+}
+
+// validateDelegatorShares checks that the delegation shares add up to the total delegator shares of each validator.
+// It is shared by the invariants and the genesis validation.
+func validateDelegatorShares(delegations []types.Delegation, infos map[string]types.AllianceValidatorInfo) error {
+	var validators []string
+	denoms := map[string][]string{}
+	delegatorShares := map[string]map[string]sdk.Dec{} // {validator: {asset: share}}
+	for _, delegation := range delegations {
+		if delegation.Shares.IsNil() || delegation.Shares.IsNegative() {
+			return fmt.Errorf("negative delegation shares found for delegator %s to validator %s",
+				delegation.DelegatorAddress, delegation.ValidatorAddress)
+		}
+		if delegatorShares[delegation.ValidatorAddress] == nil {
+			validators = append(validators, delegation.ValidatorAddress)
+			delegatorShares[delegation.ValidatorAddress] = map[string]sdk.Dec{}
+		}
+		shares, found := delegatorShares[delegation.ValidatorAddress][delegation.Denom]
+		if !found {
+			denoms[delegation.ValidatorAddress] = append(denoms[delegation.ValidatorAddress], delegation.Denom)
+			shares = sdk.ZeroDec()
+		}
+		delegatorShares[delegation.ValidatorAddress][delegation.Denom] = shares.Add(delegation.Shares)
+	}
+
+	for _, val := range validators {
+		if _, err := sdk.ValAddressFromBech32(val); err != nil {
+			return fmt.Errorf("alliance validator address %s invalid: %w", val, err)
+		}
+		info, found := infos[val]
+		if !found {
+			return fmt.Errorf("alliance validator info for %s not found", val)
+		}
+		shares := sdk.NewDecCoins(info.TotalDelegatorShares...)
+		for _, denom := range denoms[val] {
+			amount := delegatorShares[val][denom]
+			if !shares.AmountOf(denom).Equal(amount) {
+				return fmt.Errorf("broken alliance delegation share invariance: "+
+					"validator (%s) TotalDelegatorShares(%s): %s, sum of delegator shares: %s",
+					val, denom, shares.AmountOf(denom), amount)
+			}
+		}
+	}
+	return nil
+}
+
+// ModuleBalanceInvariant checks that the module account holds the total tokens of every asset plus all tokens that
+// are still unbonding. The module account can receive transfers, so a balance above that amount is accepted.
+func ModuleBalanceInvariant(k keeper.Keeper) sdk.Invariant {
 	return func(ctx sdk.Context) (string, bool) {
 		var (
 			msg    string
 			broken bool
 		)
-		assets := k.GetAllAssets(ctx)
-		infos := k.GetAllAllianceValidatorInfo(ctx)
-		validatorShares := map[string]sdk.Dec{} // {denom: shares}
-		for _, info := range infos {
-			for _, share := range info.ValidatorShares {
-				if share.IsNegative() {
-					msg += "negative validator shares found\n"
-					broken = true
-					return sdk.FormatInvariant(types.ModuleName, "validator shares", msg), broken
-				}
-				if validatorShares[share.Denom].IsNil() {
-					validatorShares[share.Denom] = share.Amount
-				} else {
-					validatorShares[share.Denom] = validatorShares[share.Denom].Add(share.Amount)
-				}
-			}
+		expected := sdk.NewCoins()
+		for _, asset := range k.GetAllAssets(ctx) {
+			expected = expected.Add(sdk.NewCoin(asset.Denom, asset.TotalTokens))
 		}
-		for _, asset := range assets {
-			if !validatorShares[asset.Denom].IsNil() && !asset.TotalValidatorShares.Equal(validatorShares[asset.Denom]) {
+		k.IterateUndelegations(ctx, func(undelegation types.QueuedUndelegation, _ time.Time) bool {
+			for _, entry := range undelegation.Entries {
+				expected = expected.Add(entry.Balance)
+			}
+			return false
+		})
+
+		balance := k.GetModuleBalance(ctx)
+		for _, coin := range expected {
+			if balance.AmountOf(coin.Denom).LT(coin.Amount) {
 				broken = true
-				msg += fmt.Sprintf("broken alliance validator share invariance: \n"+
-					"asset.TotalValidatorShares: %s\n"+
-					"sum of validator shares: %s\n", asset.TotalValidatorShares, validatorShares[asset.Denom])
+				msg += fmt.Sprintf("broken alliance module balance invariance: \n"+
+					"module balance(%s): %s\n"+
+					"sum of total tokens and undelegations: %s\n", coin.Denom, balance.AmountOf(coin.Denom), coin.Amount)
 			}
 		}
-		return sdk.FormatInvariant(types.ModuleName, "validator shares", msg), broken
+		return sdk.FormatInvariant(types.ModuleName, "module balance", msg), broken
 	}
 }
 
-func DelegatorSharesInvariant(k keeper.Keeper) sdk.Invariant {
+// RewardsPoolInvariant checks that the rewards pool can pay out the rewards that all delegations can claim. Reward
+// indices are rounded, so each delegation may claim up to 1 token more than its exact share.
+func RewardsPoolInvariant(k keeper.Keeper) sdk.Invariant {
 	return func(ctx sdk.Context) (string, bool) {
 		var (
 			msg    string
 			broken bool
 		)
-		delegatorShares := map[string]map[string]sdk.Dec{} // {validator: {asset: share}}
-		var hasNegativeShares bool
-		k.IterateDelegations(ctx, func(delegation types.Delegation) bool {
-			if delegation.Shares.IsNegative() {
-				hasNegativeShares = true
-				return true
+		claimable, delegations, err := k.TotalClaimableRewards(ctx)
+		if err != nil {
+			msg += fmt.Sprintf("failed to calculate claimable rewards: %s\n", err)
+			return sdk.FormatInvariant(types.ModuleName, "rewards pool", msg), true
+		}
+
+		pool := k.GetRewardsPoolBalance(ctx)
+		tolerance := sdk.NewInt(delegations)
+		for _, coin := range claimable {
+			if pool.AmountOf(coin.Denom).Add(tolerance).LT(coin.Amount) {
+				broken = true
+				msg += fmt.Sprintf("broken alliance rewards pool invariance: \n"+
+					"rewards pool(%s): %s\n"+
+					"sum of claimable rewards: %s\n", coin.Denom, pool.AmountOf(coin.Denom), coin.Amount)
 			}
-			if delegatorShares[delegation.ValidatorAddress] == nil {
-				delegatorShares[delegation.ValidatorAddress] = map[string]sdk.Dec{
-					delegation.Denom: delegation.Shares,
-				}
-			} else {
-				if delegatorShares[delegation.ValidatorAddress][delegation.Denom].IsNil() {
-					delegatorShares[delegation.ValidatorAddress][delegation.Denom] = delegation.Shares
-				} else {
-					delegatorShares[delegation.ValidatorAddress][delegation.Denom] = delegatorShares[delegation.ValidatorAddress][delegation.Denom].Add(delegation.Shares)
-				}
+		}
+		return sdk.FormatInvariant(types.ModuleName, "rewards pool", msg), broken
+	}
+}
+
+// RebalanceTargetInvariant checks that the module delegation to each validator plus its pending rebalance adds up to
+// the target of the last rebalance. The rebalanced amount is truncated, so up to 1 token can be left over.
+func RebalanceTargetInvariant(k keeper.Keeper) sdk.Invariant {
+	return func(ctx sdk.Context) (string, bool) {
+		var (
+			msg    string
+			broken bool
+		)
+		k.IterateRebalanceTargets(ctx, func(valAddr sdk.ValAddress, target sdk.Dec) bool {
+			bonded := k.GetModuleBondedAmount(ctx, valAddr)
+			pending := k.GetPendingRebalance(ctx, valAddr)
+			diff := target.Sub(bonded).Sub(sdk.NewDecFromInt(pending)).Abs()
+			if diff.GT(sdk.OneDec()) {
+				broken = true
+				msg += fmt.Sprintf("broken alliance rebalance target invariance: \n"+
+					"validator (%s) rebalance target: %s\n"+
+					"module delegation: %s, pending rebalance: %s\n", valAddr.String(), target, bonded, pending)
 			}
 			return false
 		})
+		return sdk.FormatInvariant(types.ModuleName, "rebalance target", msg), broken
+	}
+}
 
-		if hasNegativeShares {
-			msg += "negative delegation shares found\n"
-			broken = true
-			return sdk.FormatInvariant(types.ModuleName, "validator shares", msg), broken
+// RewardForwardingEscrowInvariant checks that the reward forwarding module account holds the escrowed rewards of all
+// delegators
+func RewardForwardingEscrowInvariant(k keeper.Keeper) sdk.Invariant {
+	return func(ctx sdk.Context) (string, bool) {
+		var (
+			msg    string
+			broken bool
+		)
+		balance := k.GetRewardForwardingBalance(ctx)
+		for _, coin := range k.TotalRewardForwardingEscrow(ctx) {
+			if balance.AmountOf(coin.Denom).LT(coin.Amount) {
+				broken = true
+				msg += fmt.Sprintf("broken alliance reward forwarding escrow invariance: \n"+
+					"reward forwarding balance(%s): %s\n"+
+					"sum of escrowed rewards: %s\n", coin.Denom, balance.AmountOf(coin.Denom), coin.Amount)
+			}
 		}
+		return sdk.FormatInvariant(types.ModuleName, "reward forwarding escrow", msg), broken
+	}
+}
 
-		for val, assets := range delegatorShares {
-			valAddr, err := sdk.ValAddressFromBech32(val)
-			if err != nil {
-				msg = "alliance validator address invalid\n"
+// RewardIncentivesInvariant checks that the reward incentives module account holds the unspent deposits of all
+// incentives
+func RewardIncentivesInvariant(k keeper.Keeper) sdk.Invariant {
+	return func(ctx sdk.Context) (string, bool) {
+		var (
+			msg    string
+			broken bool
+		)
+		balance := k.GetRewardIncentivesBalance(ctx)
+		for _, coin := range k.TotalUnspentRewardIncentives(ctx) {
+			if balance.AmountOf(coin.Denom).LT(coin.Amount) {
 				broken = true
-				break
+				msg += fmt.Sprintf("broken alliance reward incentives invariance: \n"+
+					"reward incentives balance(%s): %s\n"+
+					"sum of unspent deposits: %s\n", coin.Denom, balance.AmountOf(coin.Denom), coin.Amount)
 			}
-			info, found := k.GetAllianceValidatorInfo(ctx, valAddr)
-			if !found {
-				msg = fmt.Sprintf("alliance validator info for %s not found\n", val)
+		}
+		return sdk.FormatInvariant(types.ModuleName, "reward incentives", msg), broken
+	}
+}
+
+// DelegationIndexInvariant checks that the index of delegations by validator has exactly one entry for every delegation
+func DelegationIndexInvariant(k keeper.Keeper) sdk.Invariant {
+	return func(ctx sdk.Context) (string, bool) {
+		var (
+			msg    string
+			broken bool
+		)
+		// Only keys are compared so that the invariant is cheap enough to be asserted after every block
+		delegations := k.CountDelegations(ctx)
+		entries := 0
+		k.IterateDelegationByValidatorIndex(ctx, func(valAddr sdk.ValAddress, denom string, delAddr sdk.AccAddress) bool {
+			entries++
+			if !k.HasDelegation(ctx, delAddr, valAddr, denom) {
 				broken = true
-				break
-			}
-			shares := sdk.NewDecCoins(info.TotalDelegatorShares...)
-			for denom, amount := range assets {
-				if !shares.AmountOf(denom).Equal(amount) {
-					msg += fmt.Sprintf("broken alliance delegation share invariance: \n"+
-						"validator (%s) TotalDelegatorShares(%s): %s\n"+
-						"sum of delegator shares: %s\n", valAddr.String(), denom, shares, amount)
-					broken = true
-				}
+				msg += fmt.Sprintf("broken alliance delegation index invariance: \n"+
+					"index entry without delegation: %s %s %s\n", valAddr, denom, delAddr)
 			}
+			return false
+		})
+		if entries != delegations {
+			broken = true
+			msg += fmt.Sprintf("broken alliance delegation index invariance: \n"+
+				"delegations: %d\n"+
+				"index entries: %d\n", delegations, entries)
 		}
-		return sdk.FormatInvariant(types.ModuleName, "delegations shares", msg), broken
+		return sdk.FormatInvariant(types.ModuleName, "delegation index", msg), broken
 	}
 }
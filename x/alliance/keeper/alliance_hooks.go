@@ -0,0 +1,68 @@
+package keeper
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/terra-money/alliance/x/alliance/types"
+)
+
+// SetHooks sets the alliance hooks. The keeper is passed around by value, so the hooks have to be set before the
+// keeper is handed to other modules.
+func (k *Keeper) SetHooks(ah types.AllianceHooks) *Keeper {
+	if k.hooks != nil {
+		panic("cannot set alliance hooks twice")
+	}
+	k.hooks = ah
+	return k
+}
+
+func (k Keeper) afterAllianceDelegationModified(ctx sdk.Context, delAddr sdk.AccAddress, valAddr sdk.ValAddress, denom string) error {
+	if k.hooks == nil {
+		return nil
+	}
+	return k.hooks.AfterAllianceDelegationModified(ctx, delAddr, valAddr, denom)
+}
+
+func (k Keeper) beforeAllianceDelegationRemoved(ctx sdk.Context, delAddr sdk.AccAddress, valAddr sdk.ValAddress, denom string) error {
+	if k.hooks == nil {
+		return nil
+	}
+	return k.hooks.BeforeAllianceDelegationRemoved(ctx, delAddr, valAddr, denom)
+}
+
+func (k Keeper) afterAllianceRewardsClaimed(ctx sdk.Context, delAddr sdk.AccAddress, valAddr sdk.ValAddress, denom string, rewards sdk.Coins) error {
+	if k.hooks == nil {
+		return nil
+	}
+	return k.hooks.AfterAllianceRewardsClaimed(ctx, delAddr, valAddr, denom, rewards)
+}
+
+func (k Keeper) afterAllianceAssetCreated(ctx sdk.Context, denom string) error {
+	if k.hooks == nil {
+		return nil
+	}
+	return k.hooks.AfterAllianceAssetCreated(ctx, denom)
+}
+
+func (k Keeper) afterAllianceAssetUpdated(ctx sdk.Context, denom string) error {
+	if k.hooks == nil {
+		return nil
+	}
+	return k.hooks.AfterAllianceAssetUpdated(ctx, denom)
+}
+
+func (k Keeper) afterAllianceSlash(ctx sdk.Context, valAddr sdk.ValAddress, fraction sdk.Dec) error {
+	if k.hooks == nil {
+		return nil
+	}
+	return k.hooks.AfterAllianceSlash(ctx, valAddr, fraction)
+}
+
+// afterAllianceDelegationChanged calls the modified hook if the delegation still exists after a change of its shares.
+// Removed delegations already called the removal hook.
+func (k Keeper) afterAllianceDelegationChanged(ctx sdk.Context, delAddr sdk.AccAddress, valAddr sdk.ValAddress, denom string) error {
+	if _, found := k.GetDelegation(ctx, delAddr, valAddr, denom); !found {
+		return nil
+	}
+	return k.afterAllianceDelegationModified(ctx, delAddr, valAddr, denom)
+}
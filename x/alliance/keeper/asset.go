@@ -27,6 +27,8 @@ func (k Keeper) InitializeAllianceAssets(ctx sdk.Context, assets []*types.Allian
 			return false
 		})
 		k.SetAsset(ctx, *asset)
+		// The asset now counts towards the voting power of every validator
+		k.QueueAssetRebalanceEvent(ctx)
 	}
 }
 
@@ -83,32 +85,61 @@ func (k Keeper) UpdateAllianceAsset(ctx sdk.Context, newAsset types.AllianceAsse
 	asset.RewardChangeRate = newAsset.RewardChangeRate
 	asset.RewardChangeInterval = newAsset.RewardChangeInterval
 	asset.LastRewardChangeTime = newAsset.LastRewardChangeTime
+	asset.RewardWeightCurve = newAsset.RewardWeightCurve
 	k.SetAsset(ctx, asset)
 
 	return nil
 }
 
+// RebalanceHook rebalances every validator when a global input (reward weights, bond status, ...) changed during the
+// block. Otherwise only the validators whose alliance delegations changed are rebalanced.
+// A validator that fails to rebalance is rolled back and queued again without failing the others.
 func (k Keeper) RebalanceHook(ctx sdk.Context, assets []*types.AllianceAsset) error {
+	valAddrs := k.ConsumeValidatorRebalanceEvents(ctx)
 	if k.ConsumeAssetRebalanceEvent(ctx) {
-		return k.RebalanceBondTokenWeights(ctx, assets)
+		return k.rebalanceBondTokenWeights(ctx, assets, k.applyValidatorRebalance)
 	}
-	return nil
+	if len(valAddrs) == 0 {
+		return nil
+	}
+	return k.rebalanceValidatorsBondTokenWeights(ctx, assets, valAddrs, k.applyValidatorRebalance)
 }
 
-// RebalanceBondTokenWeights uses asset reward weights to calculate the expected amount of staking token that has to be
-// minted / burned to maintain the right ratio
-// It iterates all validators and calculates the expected staked amount based on delegations and delegates/undelegates
-// the difference.
-func (k Keeper) RebalanceBondTokenWeights(ctx sdk.Context, assets []*types.AllianceAsset) (err error) {
-	moduleAddr := k.accountKeeper.GetModuleAddress(types.ModuleName)
-	allianceBondAmount := k.GetAllianceBondedAmount(ctx, moduleAddr)
+// rebalanceInputs are the chain wide values that the expected bond amount of every validator is derived from
+type rebalanceInputs struct {
+	moduleAddr       sdk.AccAddress
+	bondDenom        string
+	bondedValidators []types.AllianceValidator
+	// nativeBondAmount is the amount of bond tokens staked by everyone except the alliance module
+	nativeBondAmount sdk.Int
+	// unbondedValidatorShares are the validator shares of each asset held by unbonded validators which are excluded
+	unbondedValidatorShares sdk.DecCoins
+	// expectedBondAmounts is the amount of bond token to distribute over the bonded validators of each started asset
+	expectedBondAmounts map[string]sdk.Dec
+	// bondedValidatorShares is the total amount of validator shares of each asset held by bonded validators including
+	// the boosted shares of locked delegations
+	bondedValidatorShares map[string]sdk.Dec
+}
 
-	nativeBondAmount := k.stakingKeeper.TotalBondedTokens(ctx).Sub(allianceBondAmount)
-	bondDenom := k.stakingKeeper.BondDenom(ctx)
+// bondAmountPerShare is the expected bond amount per validator share of an asset. A change in any global input of
+// the rebalance is reflected in it.
+func (i rebalanceInputs) bondAmountPerShare(denom string) sdk.Dec {
+	bondedValidatorShares, found := i.bondedValidatorShares[denom]
+	if !found || !bondedValidatorShares.IsPositive() {
+		return sdk.ZeroDec()
+	}
+	return i.expectedBondAmounts[denom].Quo(bondedValidatorShares)
+}
 
-	unbondedValidatorShares := sdk.NewDecCoins()
-	var bondedValidators []types.AllianceValidator
+func (k Keeper) getRebalanceInputs(ctx sdk.Context, assets []*types.AllianceAsset) (inputs rebalanceInputs, err error) {
+	inputs.moduleAddr = k.accountKeeper.GetModuleAddress(types.ModuleName)
+	inputs.bondDenom = k.stakingKeeper.BondDenom(ctx)
+	allianceBondAmount := k.GetAllianceBondedAmount(ctx, inputs.moduleAddr)
+	inputs.nativeBondAmount = k.stakingKeeper.TotalBondedTokens(ctx).Sub(allianceBondAmount)
+	rewardWeightScale := k.GetRewardWeightScale(ctx)
 
+	inputs.unbondedValidatorShares = sdk.NewDecCoins()
+	boostedValidatorShares := sdk.NewDecCoins()
 	// Iterate through all alliance validators to remove those that are unbonded.
 	// Unbonded validators will be ignored when rebalancing.
 	k.IterateAllianceValidatorInfo(ctx, func(valAddr sdk.ValAddress, info types.AllianceValidatorInfo) bool {
@@ -118,89 +149,354 @@ func (k Keeper) RebalanceBondTokenWeights(ctx sdk.Context, assets []*types.Allia
 			return true
 		}
 		if validator.IsBonded() {
-			bondedValidators = append(bondedValidators, validator)
+			inputs.bondedValidators = append(inputs.bondedValidators, validator)
+			for _, boost := range validator.BoostedDelegatorShares {
+				boostedValidatorShares = boostedValidatorShares.Add(sdk.NewDecCoinFromDec(boost.Denom, validator.BoostedValidatorSharesWithDenom(boost.Denom)))
+			}
 		} else {
-			unbondedValidatorShares = unbondedValidatorShares.Add(validator.ValidatorShares...)
+			inputs.unbondedValidatorShares = inputs.unbondedValidatorShares.Add(validator.ValidatorShares...)
 		}
 		return false
 	})
+	if err != nil {
+		return inputs, err
+	}
+
+	inputs.expectedBondAmounts = make(map[string]sdk.Dec, len(assets))
+	inputs.bondedValidatorShares = make(map[string]sdk.Dec, len(assets))
+	for _, asset := range assets {
+		// Ignores assets that were recently added to prevent a small set of stakers from owning too much of the
+		// voting power at the start. Uses the asset.RewardStartTime to determine when an asset is activated.
+		// A full rebalance is queued by InitializeAllianceAssets once the asset rewards start.
+		if !asset.RewardsStarted(ctx.BlockTime()) {
+			continue
+		}
+		inputs.expectedBondAmounts[asset.Denom] = asset.RewardWeight.Mul(rewardWeightScale).MulInt(inputs.nativeBondAmount)
+		inputs.bondedValidatorShares[asset.Denom] = asset.TotalValidatorShares.Sub(inputs.unbondedValidatorShares.AmountOf(asset.Denom)).
+			Add(boostedValidatorShares.AmountOf(asset.Denom))
+	}
+	return inputs, nil
+}
+
+// RebalanceBondTokenWeights uses asset reward weights to calculate the expected amount of staking token that has to be
+// minted / burned to maintain the right ratio
+// It iterates all validators and calculates the expected staked amount based on delegations and delegates/undelegates
+// the difference.
+func (k Keeper) RebalanceBondTokenWeights(ctx sdk.Context, assets []*types.AllianceAsset) (err error) {
+	return k.rebalanceBondTokenWeights(ctx, assets, k.rebalanceValidator)
+}
+
+// validatorRebalancer rebalances a single validator with the given inputs
+type validatorRebalancer func(ctx sdk.Context, assets []*types.AllianceAsset, inputs rebalanceInputs, validator types.AllianceValidator) error
+
+func (k Keeper) rebalanceBondTokenWeights(ctx sdk.Context, assets []*types.AllianceAsset, rebalance validatorRebalancer) error {
+	inputs, err := k.getRebalanceInputs(ctx, assets)
 	if err != nil {
 		return err
 	}
+	return k.rebalanceAllValidators(ctx, assets, inputs, rebalance)
+}
 
-	for _, validator := range bondedValidators {
-		currentBondedAmount := sdk.NewDec(0)
-		delegation, found := k.stakingKeeper.GetDelegation(ctx, moduleAddr, validator.GetOperator())
-		if found {
-			currentBondedAmount = validator.TokensFromShares(delegation.GetShares())
+// RebalanceValidatorsBondTokenWeights only rebalances the given validators. It falls back to rebalancing all validators
+// when the expected bond amount per validator share of any asset moved by more than the FullRebalanceThreshold param
+// since the last full rebalance, since the bond amount of every other validator is then off by the same ratio.
+func (k Keeper) RebalanceValidatorsBondTokenWeights(ctx sdk.Context, assets []*types.AllianceAsset, valAddrs []sdk.ValAddress) (err error) {
+	return k.rebalanceValidatorsBondTokenWeights(ctx, assets, valAddrs, k.rebalanceValidator)
+}
+
+func (k Keeper) rebalanceValidatorsBondTokenWeights(ctx sdk.Context, assets []*types.AllianceAsset, valAddrs []sdk.ValAddress, rebalance validatorRebalancer) error {
+	inputs, err := k.getRebalanceInputs(ctx, assets)
+	if err != nil {
+		return err
+	}
+	if k.exceedsFullRebalanceThreshold(ctx, assets, inputs) {
+		return k.rebalanceAllValidators(ctx, assets, inputs, rebalance)
+	}
+
+	changedValidators := make(map[string]bool, len(valAddrs))
+	for _, valAddr := range valAddrs {
+		changedValidators[valAddr.String()] = true
+	}
+	for _, validator := range inputs.bondedValidators {
+		if !changedValidators[validator.GetOperator().String()] {
+			continue
 		}
+		if err := rebalance(ctx, assets, inputs, validator); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-		expectedBondAmount := sdk.ZeroDec()
-		for _, asset := range assets {
-			// Ignores assets that were recently added to prevent a small set of stakers from owning too much of the
-			// voting power at the start. Uses the asset.RewardStartTime to determine when an asset is activated
-			if !asset.RewardsStarted(ctx.BlockTime()) {
-				// Queue a rebalancing event so that we keep checking if the asset rewards has started in the next block
-				k.QueueAssetRebalanceEvent(ctx)
-				continue
-			}
-			valShares := validator.ValidatorSharesWithDenom(asset.Denom)
-			expectedBondAmountForAsset := asset.RewardWeight.MulInt(nativeBondAmount)
+func (k Keeper) rebalanceAllValidators(ctx sdk.Context, assets []*types.AllianceAsset, inputs rebalanceInputs, rebalance validatorRebalancer) error {
+	for _, validator := range inputs.bondedValidators {
+		if err := rebalance(ctx, assets, inputs, validator); err != nil {
+			return err
+		}
+	}
+	k.clearLastRebalanceRates(ctx)
+	for _, asset := range assets {
+		k.setLastRebalanceRate(ctx, asset.Denom, inputs.bondAmountPerShare(asset.Denom))
+	}
+	return nil
+}
 
-			bondedValidatorShares := asset.TotalValidatorShares.Sub(unbondedValidatorShares.AmountOf(asset.Denom))
-			if valShares.IsPositive() && bondedValidatorShares.IsPositive() {
-				expectedBondAmount = expectedBondAmount.Add(valShares.Quo(bondedValidatorShares).Mul(expectedBondAmountForAsset))
-			}
+// applyValidatorRebalance rebalances a single validator in isolation. A validator that fails to rebalance is rolled
+// back and queued again so that it is retried in the next block. It is only used by the end blocker.
+func (k Keeper) applyValidatorRebalance(ctx sdk.Context, assets []*types.AllianceAsset, inputs rebalanceInputs, validator types.AllianceValidator) error {
+	ok := k.applyEndBlockerUnit(ctx, types.EndBlockerStepRebalance, "", validator.GetOperator(), func(ctx sdk.Context) error {
+		return k.rebalanceValidator(ctx, assets, inputs, validator)
+	})
+	if !ok {
+		k.QueueValidatorRebalanceEvent(ctx, validator.GetOperator())
+	}
+	return nil
+}
+
+// rebalanceValidator delegates or undelegates the difference between the expected and the current bond amount of the
+// module to a single validator
+func (k Keeper) rebalanceValidator(ctx sdk.Context, assets []*types.AllianceAsset, inputs rebalanceInputs, validator types.AllianceValidator) error {
+	moduleAddr := inputs.moduleAddr
+	bondDenom := inputs.bondDenom
+	currentBondedAmount := k.currentBondedAmount(ctx, inputs, validator)
+	expectedBondAmount, _ := expectedBondAmount(assets, inputs, validator)
+	delta, pending := k.rebalanceDelta(ctx, validator, expectedBondAmount, currentBondedAmount)
+
+	// The remainder of a limited rebalance is stored as pending and the validator is queued to be rebalanced again in
+	// the next block until it converges.
+	k.setRebalanceTarget(ctx, validator.GetOperator(), expectedBondAmount)
+	if pending.IsZero() {
+		k.deletePendingRebalance(ctx, validator.GetOperator())
+	} else {
+		k.setPendingRebalance(ctx, validator.GetOperator(), pending)
+		k.QueueValidatorRebalanceEvent(ctx, validator.GetOperator())
+	}
+
+	if delta.IsPositive() {
+		// delegate more tokens to increase the weight
+		bondAmount := delta
+		err := k.bankKeeper.MintCoins(ctx, types.ModuleName, sdk.NewCoins(sdk.NewCoin(bondDenom, bondAmount)))
+		if err != nil {
+			return err
 		}
-		if expectedBondAmount.GT(currentBondedAmount) {
-			// delegate more tokens to increase the weight
-			bondAmount := expectedBondAmount.Sub(currentBondedAmount).TruncateInt()
-			// If bond amount is zero after truncation, then skip delegation
-			// Small delegations to alliance will not change the voting power by a lot. We can accumulate all the small
-			// changes until it is larger than 1 utoken before we update voting power
-			if bondAmount.IsZero() {
-				continue
-			}
-			err = k.bankKeeper.MintCoins(ctx, types.ModuleName, sdk.NewCoins(sdk.NewCoin(bondDenom, bondAmount)))
-			if err != nil {
-				return err
-			}
-			_, err = k.ClaimValidatorRewards(ctx, validator)
-			if err != nil {
-				return err
-			}
-			_, err = k.stakingKeeper.Delegate(ctx, moduleAddr, bondAmount, stakingtypes.Unbonded, *validator.Validator, true)
-			if err != nil {
-				return err
-			}
-		} else if expectedBondAmount.LT(currentBondedAmount) {
-			// undelegate more tokens to reduce the weight
-			unbondAmount := currentBondedAmount.Sub(expectedBondAmount).TruncateInt()
-			// When unbondAmount is < 1 utoken, we ignore the change in voting power since it rounds down to zero.
-			if unbondAmount.IsZero() {
-				continue
-			}
-			sharesToUnbond, err := k.stakingKeeper.ValidateUnbondAmount(ctx, moduleAddr, validator.GetOperator(), unbondAmount)
-			if err != nil {
-				return err
-			}
-			_, err = k.ClaimValidatorRewards(ctx, validator)
-			if err != nil {
-				return err
-			}
-			tokensToBurn, err := k.stakingKeeper.Unbond(ctx, moduleAddr, validator.GetOperator(), sharesToUnbond)
-			if err != nil {
-				return err
-			}
-			err = k.bankKeeper.BurnCoins(ctx, stakingtypes.BondedPoolName, sdk.NewCoins(sdk.NewCoin(bondDenom, tokensToBurn)))
-			if err != nil {
-				return err
-			}
+		_, err = k.ClaimValidatorRewards(ctx, validator)
+		if err != nil {
+			return err
+		}
+		_, err = k.stakingKeeper.Delegate(ctx, moduleAddr, bondAmount, stakingtypes.Unbonded, *validator.Validator, true)
+		if err != nil {
+			return err
+		}
+	} else if delta.IsNegative() {
+		// undelegate more tokens to reduce the weight
+		unbondAmount := delta.Neg()
+		sharesToUnbond, err := k.stakingKeeper.ValidateUnbondAmount(ctx, moduleAddr, validator.GetOperator(), unbondAmount)
+		if err != nil {
+			return err
+		}
+		_, err = k.ClaimValidatorRewards(ctx, validator)
+		if err != nil {
+			return err
+		}
+		tokensToBurn, err := k.stakingKeeper.Unbond(ctx, moduleAddr, validator.GetOperator(), sharesToUnbond)
+		if err != nil {
+			return err
+		}
+		err = k.bankKeeper.BurnCoins(ctx, stakingtypes.BondedPoolName, sdk.NewCoins(sdk.NewCoin(bondDenom, tokensToBurn)))
+		if err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// GetModuleBondedAmount returns the amount of bond tokens the module has delegated to a validator
+func (k Keeper) GetModuleBondedAmount(ctx sdk.Context, valAddr sdk.ValAddress) sdk.Dec {
+	validator, found := k.stakingKeeper.GetValidator(ctx, valAddr)
+	if !found {
+		return sdk.ZeroDec()
+	}
+	delegation, found := k.stakingKeeper.GetDelegation(ctx, k.accountKeeper.GetModuleAddress(types.ModuleName), valAddr)
+	if !found {
+		return sdk.ZeroDec()
+	}
+	return validator.TokensFromShares(delegation.GetShares())
+}
+
+// currentBondedAmount is the amount of bond tokens the module has delegated to a validator
+func (k Keeper) currentBondedAmount(ctx sdk.Context, inputs rebalanceInputs, validator types.AllianceValidator) sdk.Dec {
+	delegation, found := k.stakingKeeper.GetDelegation(ctx, inputs.moduleAddr, validator.GetOperator())
+	if !found {
+		return sdk.ZeroDec()
+	}
+	return validator.TokensFromShares(delegation.GetShares())
+}
+
+// expectedBondAmount is the amount of bond tokens the module should delegate to a validator, also broken down per
+// started asset
+func expectedBondAmount(assets []*types.AllianceAsset, inputs rebalanceInputs, validator types.AllianceValidator) (sdk.Dec, []sdk.DecCoin) {
+	expectedBondAmount := sdk.ZeroDec()
+	var expectedBondAmounts []sdk.DecCoin
+	for _, asset := range assets {
+		expectedBondAmountForAsset, found := inputs.expectedBondAmounts[asset.Denom]
+		if !found {
+			continue
+		}
+		// Locked delegations get voting power for their boost shares as well
+		valShares := validator.ValidatorSharesWithDenom(asset.Denom).Add(validator.BoostedValidatorSharesWithDenom(asset.Denom))
+		bondedValidatorShares := inputs.bondedValidatorShares[asset.Denom]
+		expectedBondAmountForValidator := sdk.ZeroDec()
+		if valShares.IsPositive() && bondedValidatorShares.IsPositive() {
+			expectedBondAmountForValidator = valShares.Quo(bondedValidatorShares).Mul(expectedBondAmountForAsset)
+		}
+		expectedBondAmount = expectedBondAmount.Add(expectedBondAmountForValidator)
+		expectedBondAmounts = append(expectedBondAmounts, sdk.NewDecCoinFromDec(asset.Denom, expectedBondAmountForValidator))
+	}
+	return expectedBondAmount, expectedBondAmounts
+}
+
+// rebalanceDelta returns the bond tokens to delegate (positive) or undelegate (negative) in this block and the
+// remainder that is left pending because of the rebalance limits.
+// Changes smaller than 1 utoken round down to zero. Small delegations to alliance will not change the voting power by
+// a lot, so they are accumulated until they are larger than 1 utoken before the voting power is updated.
+func (k Keeper) rebalanceDelta(ctx sdk.Context, validator types.AllianceValidator, expectedBondAmount sdk.Dec, currentBondedAmount sdk.Dec) (delta sdk.Int, pending sdk.Int) {
+	delta = expectedBondAmount.Sub(currentBondedAmount).Abs().TruncateInt()
+	pending = sdk.ZeroInt()
+	if maxAmount, limited := k.maxRebalanceAmount(ctx, validator); limited && delta.GT(maxAmount) {
+		pending = delta.Sub(maxAmount)
+		delta = maxAmount
+	}
+	if expectedBondAmount.LT(currentBondedAmount) {
+		return delta.Neg(), pending.Neg()
+	}
+	return delta, pending
+}
+
+// maxRebalanceAmount is the smaller of the MaxRebalanceAmount param and the MaxRebalanceFraction of the validator
+// tokens. At least one token is allowed so that a limited rebalance always converges.
+func (k Keeper) maxRebalanceAmount(ctx sdk.Context, validator types.AllianceValidator) (maxAmount sdk.Int, limited bool) {
+	maxAmount = k.MaxRebalanceAmount(ctx)
+	limited = maxAmount.IsPositive()
+	maxFraction := k.MaxRebalanceFraction(ctx)
+	if maxFraction.IsPositive() && validator.Tokens.IsPositive() {
+		maxAmountFromFraction := sdk.MaxInt(maxFraction.MulInt(validator.Tokens).TruncateInt(), sdk.OneInt())
+		if !limited || maxAmountFromFraction.LT(maxAmount) {
+			maxAmount = maxAmountFromFraction
+			limited = true
+		}
+	}
+	return maxAmount, limited
+}
+
+// GetPendingRebalance returns the bond tokens that are still to be delegated (positive) or undelegated (negative) to
+// a validator because of the rebalance limits
+func (k Keeper) GetPendingRebalance(ctx sdk.Context, valAddr sdk.ValAddress) sdk.Int {
+	store := ctx.KVStore(k.storeKey)
+	b := store.Get(types.GetPendingRebalanceKey(valAddr))
+	if b == nil {
+		return sdk.ZeroInt()
+	}
+	var amount sdk.IntProto
+	k.cdc.MustUnmarshal(b, &amount)
+	return amount.Int
+}
+
+func (k Keeper) setPendingRebalance(ctx sdk.Context, valAddr sdk.ValAddress, amount sdk.Int) {
+	store := ctx.KVStore(k.storeKey)
+	b := k.cdc.MustMarshal(&sdk.IntProto{Int: amount})
+	store.Set(types.GetPendingRebalanceKey(valAddr), b)
+}
+
+func (k Keeper) deletePendingRebalance(ctx sdk.Context, valAddr sdk.ValAddress) {
+	store := ctx.KVStore(k.storeKey)
+	store.Delete(types.GetPendingRebalanceKey(valAddr))
+}
+
+// GetRebalanceTarget returns the bond amount that the last rebalance of the validator aimed for. Together with the
+// pending rebalance it accounts for the current delegation of the module to the validator.
+func (k Keeper) GetRebalanceTarget(ctx sdk.Context, valAddr sdk.ValAddress) (sdk.Dec, bool) {
+	b := ctx.KVStore(k.storeKey).Get(types.GetRebalanceTargetKey(valAddr))
+	if b == nil {
+		return sdk.ZeroDec(), false
+	}
+	var target sdk.DecProto
+	k.cdc.MustUnmarshal(b, &target)
+	return target.Dec, true
+}
+
+func (k Keeper) IterateRebalanceTargets(ctx sdk.Context, cb func(valAddr sdk.ValAddress, target sdk.Dec) (stop bool)) {
+	store := ctx.KVStore(k.storeKey)
+	iter := sdk.KVStorePrefixIterator(store, types.RebalanceTargetKey)
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		var target sdk.DecProto
+		k.cdc.MustUnmarshal(iter.Value(), &target)
+		if cb(types.ParseRebalanceTargetKey(iter.Key()), target.Dec) {
+			return
+		}
+	}
+}
+
+func (k Keeper) setRebalanceTarget(ctx sdk.Context, valAddr sdk.ValAddress, target sdk.Dec) {
+	b := k.cdc.MustMarshal(&sdk.DecProto{Dec: target})
+	ctx.KVStore(k.storeKey).Set(types.GetRebalanceTargetKey(valAddr), b)
+}
+
+// deleteRebalanceTarget is called when the module delegation to a validator changes outside of a rebalance
+func (k Keeper) deleteRebalanceTarget(ctx sdk.Context, valAddr sdk.ValAddress) {
+	ctx.KVStore(k.storeKey).Delete(types.GetRebalanceTargetKey(valAddr))
+}
+
+// exceedsFullRebalanceThreshold compares the expected bond amount per validator share of each asset with the one used
+// in the last full rebalance
+func (k Keeper) exceedsFullRebalanceThreshold(ctx sdk.Context, assets []*types.AllianceAsset, inputs rebalanceInputs) bool {
+	threshold := k.FullRebalanceThreshold(ctx)
+	for _, asset := range assets {
+		rate := inputs.bondAmountPerShare(asset.Denom)
+		lastRate, found := k.getLastRebalanceRate(ctx, asset.Denom)
+		if !found || !lastRate.IsPositive() {
+			if rate.IsPositive() {
+				return true
+			}
+			continue
+		}
+		if rate.Sub(lastRate).Abs().Quo(lastRate).GT(threshold) {
+			return true
+		}
+	}
+	return false
+}
+
+func (k Keeper) getLastRebalanceRate(ctx sdk.Context, denom string) (sdk.Dec, bool) {
+	store := ctx.KVStore(k.storeKey)
+	b := store.Get(types.GetLastRebalanceRateKey(denom))
+	if b == nil {
+		return sdk.Dec{}, false
+	}
+	var rate sdk.DecProto
+	k.cdc.MustUnmarshal(b, &rate)
+	return rate.Dec, true
+}
+
+func (k Keeper) setLastRebalanceRate(ctx sdk.Context, denom string, rate sdk.Dec) {
+	store := ctx.KVStore(k.storeKey)
+	b := k.cdc.MustMarshal(&sdk.DecProto{Dec: rate})
+	store.Set(types.GetLastRebalanceRateKey(denom), b)
+}
+
+func (k Keeper) clearLastRebalanceRates(ctx sdk.Context) {
+	store := ctx.KVStore(k.storeKey)
+	iter := sdk.KVStorePrefixIterator(store, types.LastRebalanceRateKey)
+	var keys [][]byte
+	for ; iter.Valid(); iter.Next() {
+		keys = append(keys, iter.Key())
+	}
+	iter.Close()
+	for _, key := range keys {
+		store.Delete(key)
+	}
+}
+
 // SetAsset Does not check if the asset already exists and overwrites it
 func (k Keeper) SetAsset(ctx sdk.Context, asset types.AllianceAsset) {
 	store := ctx.KVStore(k.storeKey)
@@ -267,6 +563,29 @@ func (k Keeper) ConsumeAssetRebalanceEvent(ctx sdk.Context) bool {
 	return true
 }
 
+// QueueValidatorRebalanceEvent marks a validator to be rebalanced at the end of the block
+func (k Keeper) QueueValidatorRebalanceEvent(ctx sdk.Context, valAddr sdk.ValAddress) {
+	store := ctx.KVStore(k.storeKey)
+	key := types.GetValidatorRebalanceQueueKey(valAddr)
+	store.Set(key, []byte{0x00})
+}
+
+// ConsumeValidatorRebalanceEvents returns and removes all validators that were marked to be rebalanced
+func (k Keeper) ConsumeValidatorRebalanceEvents(ctx sdk.Context) (valAddrs []sdk.ValAddress) {
+	store := ctx.KVStore(k.storeKey)
+	iter := sdk.KVStorePrefixIterator(store, types.ValidatorRebalanceQueueKey)
+	var keys [][]byte
+	for ; iter.Valid(); iter.Next() {
+		keys = append(keys, iter.Key())
+		valAddrs = append(valAddrs, types.ParseValidatorRebalanceQueueKey(iter.Key()))
+	}
+	iter.Close()
+	for _, key := range keys {
+		store.Delete(key)
+	}
+	return valAddrs
+}
+
 // DeductAssetsHook is called periodically to deduct from an alliance asset (calculated by take_rate).
 // The interval in which assets are deducted is set in module params
 func (k Keeper) DeductAssetsHook(ctx sdk.Context, assets []*types.AllianceAsset) (sdk.Coins, error) {
@@ -297,20 +616,32 @@ func (k Keeper) DeductAssetsWithTakeRate(ctx sdk.Context, lastClaim time.Time, a
 	assetsWithPositiveTakeRate := 0
 
 	for _, asset := range assets {
+		if asset.IsQuarantined {
+			continue
+		}
 		if asset.TotalTokens.IsPositive() && asset.TakeRate.IsPositive() && asset.RewardsStarted(ctx.BlockTime()) {
 			assetsWithPositiveTakeRate++
 			// take rate must be < 1 so multiple is also < 1
 			multiplier := sdk.OneDec().Sub(asset.TakeRate).Power(intervalsSinceLastClaim)
-			oldAmount := asset.TotalTokens
 			newAmount := multiplier.MulInt(asset.TotalTokens)
 			if newAmount.LTE(sdk.OneDec()) {
 				// If the next update reduces the amount of tokens to less than or equal to 1, stop reducing
 				continue
 			}
-			asset.TotalTokens = newAmount.TruncateInt()
-			deductedAmount := oldAmount.Sub(asset.TotalTokens)
-			coins = coins.Add(sdk.NewCoin(asset.Denom, deductedAmount))
-			k.SetAsset(ctx, *asset)
+			updated := *asset
+			updated.TotalTokens = newAmount.TruncateInt()
+			deducted := sdk.NewCoins(sdk.NewCoin(asset.Denom, asset.TotalTokens.Sub(updated.TotalTokens)))
+			// Each asset is sent separately so that an asset that cannot be transferred does not block the others
+			ok := k.applyEndBlockerUnit(ctx, types.EndBlockerStepDeductTakeRate, asset.Denom, nil, func(ctx sdk.Context) error {
+				k.SetAsset(ctx, updated)
+				return k.bankKeeper.SendCoinsFromModuleToModule(ctx, types.ModuleName, authtypes.FeeCollectorName, deducted)
+			})
+			if !ok {
+				asset.IsQuarantined = true
+				continue
+			}
+			*asset = updated
+			coins = coins.Add(deducted...)
 		}
 	}
 
@@ -321,18 +652,21 @@ func (k Keeper) DeductAssetsWithTakeRate(ctx sdk.Context, lastClaim time.Time, a
 	}
 
 	if !coins.Empty() && !coins.IsZero() {
-		err := k.bankKeeper.SendCoinsFromModuleToModule(ctx, types.ModuleName, authtypes.FeeCollectorName, coins)
-		if err != nil {
-			return nil, err
-		}
 		// Only update if there was a token transfer to prevent < 1 amounts to be ignored
 		k.SetLastRewardClaimTime(ctx, lastClaim.Add(rewardClaimInterval*time.Duration(intervalsSinceLastClaim)))
 	}
 	return coins, nil
 }
 
+// SetRewardWeightChangeSnapshot records the reward index weight that was used up to the current block.
+// If a snapshot was already taken in this block, it is kept since it holds the weight that was in effect before
+// any of the changes made during the block
 func (k Keeper) SetRewardWeightChangeSnapshot(ctx sdk.Context, asset types.AllianceAsset, val types.AllianceValidator) {
-	snapshot := types.NewRewardWeightChangeSnapshot(asset, val)
+	key := types.GetRewardWeightChangeSnapshotKey(asset.Denom, val.GetOperator(), uint64(ctx.BlockHeight()))
+	if ctx.KVStore(k.storeKey).Has(key) {
+		return
+	}
+	snapshot := types.NewRewardWeightChangeSnapshot(k.RewardIndexWeight(ctx, asset), val, asset)
 	k.setRewardWeightChangeSnapshot(ctx, asset.Denom, val.GetOperator(), uint64(ctx.BlockHeight()), snapshot)
 }
 
@@ -358,6 +692,18 @@ func (k Keeper) IterateWeightChangeSnapshot(ctx sdk.Context, denom string, valAd
 	return store.Iterator(key, end)
 }
 
+// getWeightChangeSnapshots returns the snapshots of an asset on a validator from a height on, ordered by height
+func (k Keeper) getWeightChangeSnapshots(ctx sdk.Context, denom string, valAddr sdk.ValAddress, lastClaimHeight uint64) (snapshots []types.RewardWeightChangeSnapshot) {
+	iter := k.IterateWeightChangeSnapshot(ctx, denom, valAddr, lastClaimHeight)
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		var snapshot types.RewardWeightChangeSnapshot
+		k.cdc.MustUnmarshal(iter.Value(), &snapshot)
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots
+}
+
 func (k Keeper) IterateAllWeightChangeSnapshot(ctx sdk.Context, cb func(denom string, valAddr sdk.ValAddress, lastClaimHeight uint64, snapshot types.RewardWeightChangeSnapshot) (stop bool)) {
 	store := ctx.KVStore(k.storeKey)
 	iter := sdk.KVStorePrefixIterator(store, types.RewardWeightChangeSnapshotKey)
@@ -372,34 +718,190 @@ func (k Keeper) IterateAllWeightChangeSnapshot(ctx sdk.Context, cb func(denom st
 	}
 }
 
+// RewardWeightChangeHook applies the scheduled reward weight changes and the reward weight curves of the assets,
+// bounded by their reward_weight_range
 func (k Keeper) RewardWeightChangeHook(ctx sdk.Context, assets []*types.AllianceAsset) error {
+	maxTotalRewardWeight := k.MaxTotalRewardWeight(ctx)
+	rejectAboveCap := maxTotalRewardWeight.IsPositive() && k.RewardWeightCapMode(ctx) == types.RewardWeightCapModeReject
 	for _, asset := range assets {
-		// If no reward changes are required, skip
-		if asset.RewardChangeInterval == 0 || asset.RewardChangeRate.Equal(sdk.OneDec()) {
+		if asset.IsQuarantined {
 			continue
 		}
-		// If it is not scheduled for change, skip
-		if asset.LastRewardChangeTime.Add(asset.RewardChangeInterval).After(ctx.BlockTime()) {
+		// The reward weight of group members is set by the group
+		if _, found := k.GetAssetGroupByDenom(ctx, asset.Denom); found {
 			continue
 		}
-		durationSinceLastClaim := ctx.BlockTime().Sub(asset.LastRewardChangeTime)
-		intervalsSinceLastClaim := uint64(durationSinceLastClaim / asset.RewardChangeInterval)
+		prevAsset := *asset
+		prevRewardWeight := asset.RewardWeight
+		otherRewardWeights := totalRewardWeight(assets).Sub(prevRewardWeight)
 
-		// Compound the weight changes
-		multiplier := asset.RewardChangeRate.Power(intervalsSinceLastClaim)
-		asset.RewardWeight = asset.RewardWeight.Mul(multiplier)
+		if asset.RewardWeightCurve != nil {
+			asset.RewardWeight = asset.RewardWeightCurve.RewardWeight(asset.TotalTokens)
+		} else {
+			// If no reward changes are required, skip
+			if asset.RewardChangeInterval == 0 || asset.RewardChangeRate.Equal(sdk.OneDec()) {
+				continue
+			}
+			// If it is not scheduled for change, skip
+			if asset.LastRewardChangeTime.Add(asset.RewardChangeInterval).After(ctx.BlockTime()) {
+				continue
+			}
+			durationSinceLastClaim := ctx.BlockTime().Sub(asset.LastRewardChangeTime)
+			intervalsSinceLastClaim := uint64(durationSinceLastClaim / asset.RewardChangeInterval)
+
+			// Compound the weight changes
+			asset.RewardWeight = compoundRewardWeight(asset.RewardWeight, asset.RewardChangeRate, asset.RewardWeightRange.Max, intervalsSinceLastClaim)
+			asset.LastRewardChangeTime = asset.LastRewardChangeTime.Add(asset.RewardChangeInterval * time.Duration(intervalsSinceLastClaim))
+		}
 		if asset.RewardWeight.LT(asset.RewardWeightRange.Min) {
 			asset.RewardWeight = asset.RewardWeightRange.Min
 		}
 		if asset.RewardWeight.GT(asset.RewardWeightRange.Max) {
 			asset.RewardWeight = asset.RewardWeightRange.Max
 		}
-		asset.LastRewardChangeTime = asset.LastRewardChangeTime.Add(asset.RewardChangeInterval * time.Duration(intervalsSinceLastClaim))
-		k.QueueAssetRebalanceEvent(ctx)
-		err := k.UpdateAllianceAsset(ctx, *asset)
+		// When the cap rejects changes, increases in reward weight stop at max_total_reward_weight
+		if rejectAboveCap && asset.RewardWeight.GT(prevRewardWeight) {
+			headroom := maxTotalRewardWeight.Sub(otherRewardWeights)
+			if asset.RewardWeight.GT(headroom) {
+				asset.RewardWeight = sdk.MaxDec(headroom, prevRewardWeight)
+			}
+		}
+		// Small moves along the curve are ignored to avoid a snapshot for every validator in every block
+		if asset.RewardWeightCurve != nil && asset.RewardWeight.Sub(prevRewardWeight).Abs().LTE(asset.RewardWeightCurve.UpdateThreshold) {
+			*asset = prevAsset
+			continue
+		}
+		updated := *asset
+		ok := k.applyEndBlockerUnit(ctx, types.EndBlockerStepRewardWeightChange, asset.Denom, nil, func(ctx sdk.Context) error {
+			k.QueueAssetRebalanceEvent(ctx)
+			return k.UpdateAllianceAsset(ctx, updated)
+		})
+		if !ok {
+			// Restore the weight in memory so that the other assets and the reward weight scale see the stored state
+			*asset = prevAsset
+			asset.IsQuarantined = true
+		}
+	}
+	if err := k.AssetGroupsHook(ctx, assets); err != nil {
+		return err
+	}
+	return k.UpdateRewardWeightScale(ctx, assets)
+}
+
+// EffectiveRewardWeight returns the reward weight of an asset after the reward weight scale is applied
+func (k Keeper) EffectiveRewardWeight(ctx sdk.Context, asset types.AllianceAsset) sdk.Dec {
+	return asset.RewardWeight.Mul(k.GetRewardWeightScale(ctx))
+}
+
+// RewardIndexWeight returns the weight that the delegated tokens of an asset are multiplied with to split and claim
+// rewards. The effective reward weight is divided by the conversion rate of the migrations of the asset, so that the
+// share of rewards of an asset does not depend on the unit of its tokens.
+func (k Keeper) RewardIndexWeight(ctx sdk.Context, asset types.AllianceAsset) sdk.Dec {
+	return k.EffectiveRewardWeight(ctx, asset).Quo(asset.GetConversionRate())
+}
+
+// GetRewardWeightScale returns the factor applied to all reward weights to keep their sum within
+// max_total_reward_weight. Defaults to 1 when no scaling has been applied
+func (k Keeper) GetRewardWeightScale(ctx sdk.Context) sdk.Dec {
+	b := ctx.KVStore(k.storeKey).Get(types.RewardWeightScaleKey)
+	if b == nil {
+		return sdk.OneDec()
+	}
+	var scale sdk.DecProto
+	k.cdc.MustUnmarshal(b, &scale)
+	return scale.Dec
+}
+
+func (k Keeper) setRewardWeightScale(ctx sdk.Context, scale sdk.Dec) {
+	b := k.cdc.MustMarshal(&sdk.DecProto{Dec: scale})
+	ctx.KVStore(k.storeKey).Set(types.RewardWeightScaleKey, b)
+}
+
+// ValidateTotalRewardWeight checks the sum of all reward weights against max_total_reward_weight when the cap is
+// configured to reject changes. In scaling mode any total is accepted since weights are scaled down instead
+func (k Keeper) ValidateTotalRewardWeight(ctx sdk.Context, total sdk.Dec) error {
+	maxTotalRewardWeight := k.MaxTotalRewardWeight(ctx)
+	if !maxTotalRewardWeight.IsPositive() || k.RewardWeightCapMode(ctx) != types.RewardWeightCapModeReject {
+		return nil
+	}
+	if total.GT(maxTotalRewardWeight) {
+		return types.ErrRewardWeightCapExceeded.Wrapf("total reward weight %s is more than %s", total, maxTotalRewardWeight)
+	}
+	return nil
+}
+
+// UpdateRewardWeightScale recalculates the factor applied to all reward weights.
+// When the factor changes, a snapshot is saved for every asset and validator so that rewards accrued before the change
+// are still calculated with the previous reward index weights
+func (k Keeper) UpdateRewardWeightScale(ctx sdk.Context, assets []*types.AllianceAsset) (err error) {
+	scale := k.calculateRewardWeightScale(ctx, assets)
+	if scale.Equal(k.GetRewardWeightScale(ctx)) {
+		return nil
+	}
+	k.IterateAllianceValidatorInfo(ctx, func(valAddr sdk.ValAddress, info types.AllianceValidatorInfo) bool {
+		var validator types.AllianceValidator
+		validator, err = k.GetAllianceValidator(ctx, valAddr)
 		if err != nil {
-			return err
+			return true
 		}
+		_, err = k.ClaimValidatorRewards(ctx, validator)
+		if err != nil {
+			return true
+		}
+		for _, asset := range assets {
+			k.SetRewardWeightChangeSnapshot(ctx, *asset, validator)
+		}
+		return false
+	})
+	if err != nil {
+		return err
 	}
+	k.setRewardWeightScale(ctx, scale)
+	k.QueueAssetRebalanceEvent(ctx)
 	return nil
 }
+
+func (k Keeper) calculateRewardWeightScale(ctx sdk.Context, assets []*types.AllianceAsset) sdk.Dec {
+	maxTotalRewardWeight := k.MaxTotalRewardWeight(ctx)
+	if !maxTotalRewardWeight.IsPositive() || k.RewardWeightCapMode(ctx) != types.RewardWeightCapModeScale {
+		return sdk.OneDec()
+	}
+	total := totalRewardWeight(assets)
+	if total.LTE(maxTotalRewardWeight) {
+		return sdk.OneDec()
+	}
+	return maxTotalRewardWeight.Quo(total)
+}
+
+// compoundRewardWeight returns weight * rate^intervals. A growing weight is clamped to max anyway, so max is returned
+// as soon as it is certain to be passed. This keeps the power from overflowing after many missed intervals.
+func compoundRewardWeight(weight sdk.Dec, rate sdk.Dec, max sdk.Dec, intervals uint64) sdk.Dec {
+	if !weight.IsPositive() {
+		return weight
+	}
+	if rate.GT(sdk.OneDec()) {
+		limit := max.Quo(weight)
+		multiplier := sdk.OneDec()
+		for base, n := rate, intervals; n > 0; n >>= 1 {
+			if base.GT(limit) {
+				return max
+			}
+			if n&1 == 1 {
+				multiplier = multiplier.Mul(base)
+				if multiplier.GT(limit) {
+					return max
+				}
+			}
+			base = base.Mul(base)
+		}
+	}
+	return weight.Mul(rate.Power(intervals))
+}
+
+func totalRewardWeight(assets []*types.AllianceAsset) sdk.Dec {
+	total := sdk.ZeroDec()
+	for _, asset := range assets {
+		total = total.Add(asset.RewardWeight)
+	}
+	return total
+}
@@ -0,0 +1,190 @@
+package keeper
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/terra-money/alliance/x/alliance/types"
+)
+
+// SetAssetGroup stores the group and indexes it by the denoms of its members. Members that were removed from the
+// group are removed from the index
+func (k Keeper) SetAssetGroup(ctx sdk.Context, group types.AssetGroup) {
+	store := ctx.KVStore(k.storeKey)
+	if prev, found := k.GetAssetGroup(ctx, group.Name); found {
+		for _, member := range prev.Members {
+			store.Delete(types.GetAssetGroupByDenomIndexKey(member.Denom))
+		}
+	}
+	store.Set(types.GetAssetGroupKey(group.Name), k.cdc.MustMarshal(&group))
+	for _, member := range group.Members {
+		store.Set(types.GetAssetGroupByDenomIndexKey(member.Denom), []byte(group.Name))
+	}
+}
+
+// deleteAssetGroup removes the group and the index of its members
+func (k Keeper) deleteAssetGroup(ctx sdk.Context, name string) {
+	group, found := k.GetAssetGroup(ctx, name)
+	if !found {
+		return
+	}
+	store := ctx.KVStore(k.storeKey)
+	for _, member := range group.Members {
+		store.Delete(types.GetAssetGroupByDenomIndexKey(member.Denom))
+	}
+	store.Delete(types.GetAssetGroupKey(name))
+}
+
+func (k Keeper) GetAssetGroup(ctx sdk.Context, name string) (group types.AssetGroup, found bool) {
+	b := ctx.KVStore(k.storeKey).Get(types.GetAssetGroupKey(name))
+	if b == nil {
+		return group, false
+	}
+	k.cdc.MustUnmarshal(b, &group)
+	return group, true
+}
+
+// GetAssetGroupByDenom returns the group that an alliance asset is a member of
+func (k Keeper) GetAssetGroupByDenom(ctx sdk.Context, denom string) (group types.AssetGroup, found bool) {
+	name := ctx.KVStore(k.storeKey).Get(types.GetAssetGroupByDenomIndexKey(denom))
+	if name == nil {
+		return group, false
+	}
+	return k.GetAssetGroup(ctx, string(name))
+}
+
+func (k Keeper) GetAllAssetGroups(ctx sdk.Context) (groups []types.AssetGroup) {
+	store := ctx.KVStore(k.storeKey)
+	iter := sdk.KVStorePrefixIterator(store, types.AssetGroupKey)
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		var group types.AssetGroup
+		k.cdc.MustUnmarshal(iter.Value(), &group)
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// checkAssetNotInGroup rejects changes to assets whose reward weight is managed by an asset group
+func (k Keeper) checkAssetNotInGroup(ctx sdk.Context, denom string) error {
+	if group, found := k.GetAssetGroupByDenom(ctx, denom); found {
+		return types.ErrAssetInGroup.Wrapf("%s is a member of %s", denom, group.Name)
+	}
+	return nil
+}
+
+// validateAssetGroupMembers checks that the members of a group are alliance assets without a reward weight curve
+// that do not belong to another group and whose reward weight ranges can hold the group reward weight
+func (k Keeper) validateAssetGroupMembers(ctx sdk.Context, group types.AssetGroup) error {
+	assets := make(map[string]types.AllianceAsset, len(group.Members))
+	for _, member := range group.Members {
+		asset, found := k.GetAssetByDenom(ctx, member.Denom)
+		if !found {
+			return types.ErrUnknownAsset.Wrapf("member %s of %s", member.Denom, group.Name)
+		}
+		assets[member.Denom] = asset
+		if asset.RewardWeightCurve != nil {
+			return types.ErrInvalidAssetGroup.Wrapf("the reward weight of member %s follows a curve", member.Denom)
+		}
+		if other, found := k.GetAssetGroupByDenom(ctx, member.Denom); found && other.Name != group.Name {
+			return types.ErrAssetInGroup.Wrapf("%s is a member of %s", member.Denom, other.Name)
+		}
+		if err := k.checkAssetNotMigrating(ctx, member.Denom); err != nil {
+			return err
+		}
+	}
+	return group.ValidateMemberRanges(assets)
+}
+
+// AssetGroupsHook splits the reward weight of every group whose update interval elapsed among its members. A group
+// that fails to update is rolled back and its members are quarantined without failing the other groups. Groups with
+// quarantined members are skipped until governance updates the group.
+func (k Keeper) AssetGroupsHook(ctx sdk.Context, assets []*types.AllianceAsset) error {
+	for _, group := range k.GetAllAssetGroups(ctx) {
+		if group.LastUpdateTime.Add(group.UpdateInterval).After(ctx.BlockTime()) {
+			continue
+		}
+		denoms := make(map[string]bool, len(group.Members))
+		for _, member := range group.Members {
+			denoms[member.Denom] = true
+		}
+		members := make([]*types.AllianceAsset, 0, len(group.Members))
+		prevMembers := make([]types.AllianceAsset, 0, len(group.Members))
+		quarantined := false
+		for _, asset := range assets {
+			if !denoms[asset.Denom] {
+				continue
+			}
+			members = append(members, asset)
+			prevMembers = append(prevMembers, *asset)
+			quarantined = quarantined || asset.IsQuarantined
+		}
+		if quarantined {
+			continue
+		}
+		ok := k.applyEndBlockerUnit(ctx, types.EndBlockerStepAssetGroup, "", nil, func(ctx sdk.Context) error {
+			if err := k.updateAssetGroupRewardWeights(ctx, group, assets); err != nil {
+				return fmt.Errorf("asset group %s: %w", group.Name, err)
+			}
+			return nil
+		})
+		if !ok {
+			// Restore the weights in memory so that the other groups and the reward weight scale see the stored state
+			for i, member := range members {
+				*member = prevMembers[i]
+				member.IsQuarantined = true
+				k.QuarantineAsset(ctx, member.Denom)
+			}
+		}
+	}
+	return nil
+}
+
+// updateAssetGroupRewardWeights sets the reward weight of each member to its share of the group reward weight within
+// the reward_weight_range of the member. The assets are updated in memory as well.
+func (k Keeper) updateAssetGroupRewardWeights(ctx sdk.Context, group types.AssetGroup, assets []*types.AllianceAsset) error {
+	group.LastUpdateTime = ctx.BlockTime()
+	k.SetAssetGroup(ctx, group)
+
+	members := make(map[string]*types.AllianceAsset, len(group.Members))
+	values := make(map[string]types.AllianceAsset, len(group.Members))
+	for _, asset := range assets {
+		members[asset.Denom] = asset
+		values[asset.Denom] = *asset
+	}
+	weights := group.MemberRewardWeights(values, ctx.BlockTime())
+	for _, member := range group.Members {
+		asset, found := members[member.Denom]
+		if !found {
+			continue
+		}
+		weight := weights[member.Denom]
+		if weight.Equal(asset.RewardWeight) {
+			continue
+		}
+		updated := *asset
+		updated.RewardWeight = weight
+		if err := k.UpdateAllianceAsset(ctx, updated); err != nil {
+			return err
+		}
+		asset.RewardWeight = weight
+	}
+	return nil
+}
+
+// assetGroupTotalRewardWeight is the total reward weight of all assets when the members of the group share the group
+// reward weight
+func assetGroupTotalRewardWeight(assets []*types.AllianceAsset, group types.AssetGroup) sdk.Dec {
+	members := make(map[string]bool, len(group.Members))
+	for _, member := range group.Members {
+		members[member.Denom] = true
+	}
+	total := group.RewardWeight
+	for _, asset := range assets {
+		if !members[asset.Denom] {
+			total = total.Add(asset.RewardWeight)
+		}
+	}
+	return total
+}
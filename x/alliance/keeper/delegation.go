@@ -18,11 +18,20 @@ import (
 // at the end of the block. This improves performance since rebalancing only needs to happen once regardless of how many
 // delegations are made in a single block
 func (k Keeper) Delegate(ctx sdk.Context, delAddr sdk.AccAddress, validator types.AllianceValidator, coin sdk.Coin) (*sdk.Dec, error) {
+	if err := k.checkAssetNotMigrating(ctx, coin.Denom); err != nil {
+		return nil, err
+	}
 	// Check if asset is whitelisted as an alliance asset
 	asset, found := k.GetAssetByDenom(ctx, coin.Denom)
 	if !found {
 		return nil, status.Errorf(codes.NotFound, "asset with denom: %s does not exist in alliance whitelist", coin.Denom)
 	}
+	if validator.InvalidExRateWithAsset(asset) {
+		return nil, types.ErrInvalidExchangeRate.Wrapf("validator %s with %s", validator.GetOperator(), coin.Denom)
+	}
+	if types.ValidatorSharesOverflow(asset, coin.Amount) {
+		return nil, types.ErrAssetDrained.Wrapf("%s cannot be converted to validator shares", coin)
+	}
 
 	// for the AllianceDenomTwo.
 	// Check and send delegated tokens into the alliance module address
@@ -53,7 +62,10 @@ func (k Keeper) Delegate(ctx sdk.Context, delAddr sdk.AccAddress, validator type
 		sdk.NewDecCoins(sdk.NewDecCoinFromDec(coin.Denom, newValidatorShares)),
 		true,
 	)
-	k.QueueAssetRebalanceEvent(ctx)
+	k.QueueValidatorRebalanceEvent(ctx, validator.GetOperator())
+	if err := k.afterAllianceDelegationModified(ctx, delAddr, validator.GetOperator(), coin.Denom); err != nil {
+		return nil, err
+	}
 
 	_ = ctx.EventManager().EmitTypedEvent(
 		&types.DelegateAllianceEvent{
@@ -72,11 +84,17 @@ func (k Keeper) Redelegate(ctx sdk.Context, delAddr sdk.AccAddress, srcVal types
 	if srcVal.Validator.Equal(dstVal.Validator) {
 		return nil, status.Errorf(codes.InvalidArgument, "Cannot redelegate to the same validator")
 	}
+	if err := k.checkAssetNotMigrating(ctx, coin.Denom); err != nil {
+		return nil, err
+	}
 
 	asset, found := k.GetAssetByDenom(ctx, coin.Denom)
 	if !found {
 		return nil, status.Errorf(codes.NotFound, "Asset with denom: %s does not exist", coin.Denom)
 	}
+	if dstVal.InvalidExRateWithAsset(asset) {
+		return nil, types.ErrInvalidExchangeRate.Wrapf("validator %s with %s", dstVal.GetOperator(), coin.Denom)
+	}
 
 	_, found = k.GetDelegation(ctx, delAddr, srcVal.GetOperator(), coin.Denom)
 	if !found {
@@ -101,6 +119,9 @@ func (k Keeper) Redelegate(ctx sdk.Context, delAddr sdk.AccAddress, srcVal types
 	if err != nil {
 		return nil, err
 	}
+	if err := k.checkDelegationUnlocked(ctx, delAddr, srcVal.GetOperator(), srcDelegation, delegationSharesToRemove); err != nil {
+		return nil, err
+	}
 
 	// Now we have shares we want to re-delegate, we re-calculate how many tokens to actually re-delegate
 	// Directly using the input amount can result in un-delegating more tokens than expected due to rounding issues
@@ -120,7 +141,9 @@ func (k Keeper) Redelegate(ctx sdk.Context, delAddr sdk.AccAddress, srcVal types
 	changedValidatorShares := types.GetValidatorShares(asset, coin.Amount)
 
 	// Remove tokens and shares from src validator
-	k.reduceDelegationShares(ctx, delAddr, srcVal, coin, delegationSharesToRemove, srcDelegation)
+	if err := k.reduceDelegationShares(ctx, delAddr, srcVal, coin, delegationSharesToRemove, srcDelegation); err != nil {
+		return nil, err
+	}
 	k.updateValidatorShares(
 		ctx,
 		srcVal,
@@ -129,7 +152,9 @@ func (k Keeper) Redelegate(ctx sdk.Context, delAddr sdk.AccAddress, srcVal types
 		false,
 	)
 
-	k.ClearDustDelegation(ctx, delAddr, srcVal, asset)
+	if err := k.ClearDustDelegation(ctx, delAddr, srcVal, asset); err != nil {
+		return nil, err
+	}
 
 	// Add tokens and shares to dst validator
 	_, newDelegationShares := k.upsertDelegationWithNewTokens(ctx, delAddr, dstVal, coin, asset)
@@ -143,7 +168,14 @@ func (k Keeper) Redelegate(ctx sdk.Context, delAddr sdk.AccAddress, srcVal types
 
 	k.addRedelegation(ctx, delAddr, srcVal.GetOperator(), dstVal.GetOperator(), coin, completionTime)
 
-	k.QueueAssetRebalanceEvent(ctx)
+	k.QueueValidatorRebalanceEvent(ctx, srcVal.GetOperator())
+	k.QueueValidatorRebalanceEvent(ctx, dstVal.GetOperator())
+	if err := k.afterAllianceDelegationChanged(ctx, delAddr, srcVal.GetOperator(), coin.Denom); err != nil {
+		return nil, err
+	}
+	if err := k.afterAllianceDelegationModified(ctx, delAddr, dstVal.GetOperator(), coin.Denom); err != nil {
+		return nil, err
+	}
 
 	_ = ctx.EventManager().EmitTypedEvent(
 		&types.RedelegateAllianceEvent{
@@ -161,6 +193,9 @@ func (k Keeper) Redelegate(ctx sdk.Context, delAddr sdk.AccAddress, srcVal types
 // Undelegate from a validator
 // Staked tokens are only distributed to the delegator after the unbonding period
 func (k Keeper) Undelegate(ctx sdk.Context, delAddr sdk.AccAddress, validator types.AllianceValidator, coin sdk.Coin) (*time.Time, error) {
+	if err := k.checkAssetNotMigrating(ctx, coin.Denom); err != nil {
+		return nil, err
+	}
 	asset, found := k.GetAssetByDenom(ctx, coin.Denom)
 	if !found {
 		return nil, status.Errorf(codes.NotFound, "Asset with denom: %s does not exist", coin.Denom)
@@ -184,6 +219,9 @@ func (k Keeper) Undelegate(ctx sdk.Context, delAddr sdk.AccAddress, validator ty
 	if err != nil {
 		return nil, err
 	}
+	if err := k.checkDelegationUnlocked(ctx, delAddr, validator.GetOperator(), delegation, delegationSharesToUndelegate); err != nil {
+		return nil, err
+	}
 
 	// Now we have shares we want to un-delegate, we re-calculate how many tokens to actually un-delegate
 	// Directly using the input amount can result in un-delegating more tokens than expected due to rounding issues
@@ -199,7 +237,9 @@ func (k Keeper) Undelegate(ctx sdk.Context, delAddr sdk.AccAddress, validator ty
 	k.SetAsset(ctx, asset)
 
 	// Remove shares from the delegation
-	k.reduceDelegationShares(ctx, delAddr, validator, coin, delegationSharesToUndelegate, delegation)
+	if err := k.reduceDelegationShares(ctx, delAddr, validator, coin, delegationSharesToUndelegate, delegation); err != nil {
+		return nil, err
+	}
 
 	// Remove tokens and shares from src validator
 	k.updateValidatorShares(
@@ -210,11 +250,16 @@ func (k Keeper) Undelegate(ctx sdk.Context, delAddr sdk.AccAddress, validator ty
 		false,
 	)
 
-	k.ClearDustDelegation(ctx, delAddr, validator, asset)
+	if err := k.ClearDustDelegation(ctx, delAddr, validator, asset); err != nil {
+		return nil, err
+	}
 
 	// Queue undelegation messages to distribute tokens after undelegation completes in the future
 	completionTime := k.queueUndelegation(ctx, delAddr, validator.GetOperator(), coin)
-	k.QueueAssetRebalanceEvent(ctx)
+	k.QueueValidatorRebalanceEvent(ctx, validator.GetOperator())
+	if err := k.afterAllianceDelegationChanged(ctx, delAddr, validator.GetOperator(), coin.Denom); err != nil {
+		return nil, err
+	}
 
 	_ = ctx.EventManager().EmitTypedEvent(
 		&types.UndelegateAllianceEvent{
@@ -247,35 +292,44 @@ func (k Keeper) CompleteRedelegations(ctx sdk.Context) int {
 	return deleted
 }
 
-// CompleteUndelegations Go through all queued undelegations and send the tokens to the delegators
+// CompleteUndelegations Go through all queued undelegations and send the tokens to the delegators.
+// Each undelegation is completed in isolation so that a failing one stays queued and quarantines its asset
+// without blocking the others.
 func (k Keeper) CompleteUndelegations(ctx sdk.Context) error {
 	store := ctx.KVStore(k.storeKey)
 	iter := k.IterateUndelegationsByCompletionTime(ctx, ctx.BlockTime())
-	defer iter.Close()
+	var keys [][]byte
+	var queues []types.QueuedUndelegation
 	for ; iter.Valid(); iter.Next() {
 		var queued types.QueuedUndelegation
-		completionTime, err := types.ParseUndelegationQueueKeyForCompletionTime(iter.Key())
+		k.cdc.MustUnmarshal(iter.Value(), &queued)
+		keys = append(keys, iter.Key())
+		queues = append(queues, queued)
+	}
+	iter.Close()
+
+	for i, key := range keys {
+		completionTime, err := types.ParseUndelegationQueueKeyForCompletionTime(key)
 		if err != nil {
 			return err
 		}
-		k.cdc.MustUnmarshal(iter.Value(), &queued)
-		for _, undel := range queued.Entries {
-			delAddr, err := sdk.AccAddressFromBech32(undel.DelegatorAddress)
-			if err != nil {
-				return err
+		var failed []*types.Undelegation
+		for _, undel := range queues[i].Entries {
+			undel := undel
+			ok := k.applyEndBlockerUnit(ctx, types.EndBlockerStepCompleteUndelegations, undel.Balance.Denom, nil, func(ctx sdk.Context) error {
+				return k.completeUndelegation(ctx, completionTime, *undel)
+			})
+			if !ok {
+				failed = append(failed, undel)
 			}
-			err = k.bankKeeper.SendCoinsFromModuleToAccount(ctx, types.ModuleName, delAddr, sdk.NewCoins(undel.Balance))
-			if err != nil {
-				return err
-			}
-			valAddr, err := sdk.ValAddressFromBech32(undel.ValidatorAddress)
-			if err != nil {
+		}
+		if len(failed) == 0 {
+			if err := k.deleteQueuedUndelegations(ctx, key); err != nil {
 				return err
 			}
-			indexKey := types.GetUnbondingIndexKey(valAddr, completionTime, undel.Balance.Denom, delAddr)
-			store.Delete(indexKey)
+		} else {
+			store.Set(key, k.cdc.MustMarshal(&types.QueuedUndelegation{Entries: failed}))
 		}
-		store.Delete(iter.Key())
 	}
 
 	// Burn all "virtual" staking tokens in the module account
@@ -290,6 +344,30 @@ func (k Keeper) CompleteUndelegations(ctx sdk.Context) error {
 	return nil
 }
 
+// completeUndelegation sends the undelegated tokens back to the delegator and removes the unbonding index
+func (k Keeper) completeUndelegation(ctx sdk.Context, completionTime time.Time, undel types.Undelegation) error {
+	delAddr, err := sdk.AccAddressFromBech32(undel.DelegatorAddress)
+	if err != nil {
+		return err
+	}
+	err = k.bankKeeper.SendCoinsFromModuleToAccount(ctx, types.ModuleName, delAddr, sdk.NewCoins(undel.Balance))
+	if err != nil {
+		return err
+	}
+	valAddr, err := sdk.ValAddressFromBech32(undel.ValidatorAddress)
+	if err != nil {
+		return err
+	}
+	indexKey := types.GetUnbondingIndexKey(valAddr, completionTime, undel.Balance.Denom, delAddr)
+	ctx.KVStore(k.storeKey).Delete(indexKey)
+	return nil
+}
+
+// GetModuleBalance returns the balance of the module account which holds the delegated and unbonding alliance assets
+func (k Keeper) GetModuleBalance(ctx sdk.Context) sdk.Coins {
+	return k.bankKeeper.GetAllBalances(ctx, k.accountKeeper.GetModuleAddress(types.ModuleName))
+}
+
 func (k Keeper) GetDelegation(ctx sdk.Context, delAddr sdk.AccAddress, valAddr sdk.ValAddress, denom string) (d types.Delegation, found bool) {
 	key := types.GetDelegationKey(delAddr, valAddr, denom)
 	b := ctx.KVStore(k.storeKey).Get(key)
@@ -300,10 +378,25 @@ func (k Keeper) GetDelegation(ctx sdk.Context, delAddr sdk.AccAddress, valAddr s
 	return d, true
 }
 
+// HasDelegation checks whether a delegation exists without decoding it
+func (k Keeper) HasDelegation(ctx sdk.Context, delAddr sdk.AccAddress, valAddr sdk.ValAddress, denom string) bool {
+	return ctx.KVStore(k.storeKey).Has(types.GetDelegationKey(delAddr, valAddr, denom))
+}
+
+// SetDelegation stores a delegation together with its entry in the index of delegations by validator
 func (k Keeper) SetDelegation(ctx sdk.Context, delAddr sdk.AccAddress, valAddr sdk.ValAddress, denom string, del types.Delegation) {
+	store := ctx.KVStore(k.storeKey)
 	key := types.GetDelegationKey(delAddr, valAddr, denom)
 	b := k.cdc.MustMarshal(&del)
-	ctx.KVStore(k.storeKey).Set(key, b)
+	store.Set(key, b)
+	store.Set(types.GetDelegationByValidatorIndexKey(valAddr, denom, delAddr), []byte{})
+}
+
+// DeleteDelegation removes a delegation together with its entry in the index of delegations by validator
+func (k Keeper) DeleteDelegation(ctx sdk.Context, delAddr sdk.AccAddress, valAddr sdk.ValAddress, denom string) {
+	store := ctx.KVStore(k.storeKey)
+	store.Delete(types.GetDelegationKey(delAddr, valAddr, denom))
+	store.Delete(types.GetDelegationByValidatorIndexKey(valAddr, denom, delAddr))
 }
 
 func (k Keeper) DeleteRedelegation(ctx sdk.Context, redel types.Redelegation, completion time.Time) {
@@ -339,6 +432,56 @@ func (k Keeper) IterateDelegations(ctx sdk.Context, cb func(d types.Delegation)
 	}
 }
 
+// CountDelegations returns the number of delegations without decoding them
+func (k Keeper) CountDelegations(ctx sdk.Context) (count int) {
+	iter := sdk.KVStorePrefixIterator(ctx.KVStore(k.storeKey), types.DelegationKey)
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		count++
+	}
+	return count
+}
+
+// IterateValidatorDelegations iterates over the delegations to a validator through the index of delegations by
+// validator
+func (k Keeper) IterateValidatorDelegations(ctx sdk.Context, valAddr sdk.ValAddress, cb func(d types.Delegation) (stop bool)) {
+	k.iterateIndexedDelegations(ctx, valAddr, types.GetDelegationsByValidatorIndexKey(valAddr), cb)
+}
+
+// IterateValidatorDelegationsWithDenom iterates over the delegations of an asset to a validator through the index of
+// delegations by validator
+func (k Keeper) IterateValidatorDelegationsWithDenom(ctx sdk.Context, valAddr sdk.ValAddress, denom string, cb func(d types.Delegation) (stop bool)) {
+	k.iterateIndexedDelegations(ctx, valAddr, types.GetDelegationsByValidatorAndDenomIndexKey(valAddr, denom), cb)
+}
+
+func (k Keeper) iterateIndexedDelegations(ctx sdk.Context, valAddr sdk.ValAddress, prefix []byte, cb func(d types.Delegation) (stop bool)) {
+	store := ctx.KVStore(k.storeKey)
+	iter := sdk.KVStorePrefixIterator(store, prefix)
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		_, denom, delAddr := types.ParseDelegationByValidatorIndexKey(iter.Key())
+		delegation, found := k.GetDelegation(ctx, delAddr, valAddr, denom)
+		if !found {
+			continue
+		}
+		if cb(delegation) {
+			break
+		}
+	}
+}
+
+// IterateDelegationByValidatorIndex iterates over all the entries of the index of delegations by validator
+func (k Keeper) IterateDelegationByValidatorIndex(ctx sdk.Context, cb func(valAddr sdk.ValAddress, denom string, delAddr sdk.AccAddress) (stop bool)) {
+	store := ctx.KVStore(k.storeKey)
+	iter := sdk.KVStorePrefixIterator(store, types.DelegationByValidatorIndexKey)
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		if cb(types.ParseDelegationByValidatorIndexKey(iter.Key())) {
+			break
+		}
+	}
+}
+
 func (k Keeper) HasRedelegation(ctx sdk.Context, delAddr sdk.AccAddress, dstVal sdk.ValAddress, denom string) bool {
 	store := ctx.KVStore(k.storeKey)
 	key := types.GetRedelegationsKey(delAddr, denom, dstVal)
@@ -380,6 +523,13 @@ func (k Keeper) IterateUndelegationsBySrcValidator(ctx sdk.Context, valAddr sdk.
 	return sdk.KVStorePrefixIterator(store, prefix)
 }
 
+// IterateUndelegationsByDelegator iterates the index of the queued undelegations of a delegator, whose keys are
+// parsed with types.ParseUndelegationByDelegatorIndexKey
+func (k Keeper) IterateUndelegationsByDelegator(ctx sdk.Context, delAddr sdk.AccAddress) sdk.Iterator {
+	store := ctx.KVStore(k.storeKey)
+	return sdk.KVStorePrefixIterator(store, types.GetUndelegationsByDelegatorIndexKey(delAddr))
+}
+
 func (k Keeper) IterateUndelegationsByCompletionTime(ctx sdk.Context, completionTime time.Time) sdk.Iterator {
 	store := ctx.KVStore(k.storeKey)
 	return store.Iterator(types.UndelegationQueueKey, types.GetUndelegationQueueKeyByTime(completionTime))
@@ -417,6 +567,9 @@ func (k Keeper) SetValidatorInfo(ctx sdk.Context, valAddr sdk.ValAddress, val ty
 // ValidateDelegatedAmount returns the amount of shares for a given coin that is staked
 // Returns the number of shares that represents the amount of staked tokens that was requested
 func (k Keeper) ValidateDelegatedAmount(delegation types.Delegation, coin sdk.Coin, val types.AllianceValidator, asset types.AllianceAsset) (shares sdk.Dec, err error) {
+	if val.InvalidExRateWithAsset(asset) {
+		return sdk.Dec{}, types.ErrInvalidExchangeRate.Wrapf("validator %s with %s", val.GetOperator(), asset.Denom)
+	}
 	delegationSharesToUpdate := types.GetDelegationSharesFromTokens(val, asset, coin.Amount)
 	// Account for rounding in which shares for a full withdraw is slightly more or less than the number of shares recorded
 	// Withdraw all in that case
@@ -524,16 +677,31 @@ func (k Keeper) queueUndelegation(ctx sdk.Context, delAddr sdk.AccAddress, val s
 			Balance:          coin,
 		})
 	}
-	k.setQueuedUndelegations(ctx, completionTime, delAddr, queue)
+	k.SetQueuedUndelegations(ctx, completionTime, delAddr, queue)
 	k.setUnbondingIndexByVal(ctx, val, completionTime, delAddr, coin.Denom)
 	return completionTime
 }
 
-func (k Keeper) setQueuedUndelegations(ctx sdk.Context, completionTime time.Time, delAddr sdk.AccAddress, queuedDelegations types.QueuedUndelegation) {
+// SetQueuedUndelegations stores the undelegations of a delegator that complete at the same time and indexes them by
+// delegator
+func (k Keeper) SetQueuedUndelegations(ctx sdk.Context, completionTime time.Time, delAddr sdk.AccAddress, queuedDelegations types.QueuedUndelegation) {
 	store := ctx.KVStore(k.storeKey)
 	queueKey := types.GetUndelegationQueueKey(completionTime, delAddr)
 	b := k.cdc.MustMarshal(&queuedDelegations)
 	store.Set(queueKey, b)
+	store.Set(types.GetUndelegationByDelegatorIndexKey(delAddr, completionTime), []byte{})
+}
+
+// deleteQueuedUndelegations removes the undelegations stored at a queue key together with their delegator index
+func (k Keeper) deleteQueuedUndelegations(ctx sdk.Context, queueKey []byte) error {
+	completionTime, delAddr, err := types.ParseUndelegationQueueKey(queueKey)
+	if err != nil {
+		return err
+	}
+	store := ctx.KVStore(k.storeKey)
+	store.Delete(queueKey)
+	store.Delete(types.GetUndelegationByDelegatorIndexKey(delAddr, completionTime))
+	return nil
 }
 
 func (k Keeper) setUnbondingIndexByVal(ctx sdk.Context, valAddr sdk.ValAddress, completionTime time.Time, delAddr sdk.AccAddress, denom string) {
@@ -556,15 +724,18 @@ func (k Keeper) upsertDelegationWithNewTokens(ctx sdk.Context, delAddr sdk.AccAd
 
 // reduceDelegationShares
 // If shares after reduction = 0, delegation will be deleted
-func (k Keeper) reduceDelegationShares(ctx sdk.Context, delAddr sdk.AccAddress, validator types.AllianceValidator, coin sdk.Coin, shares sdk.Dec, delegation types.Delegation) {
+func (k Keeper) reduceDelegationShares(ctx sdk.Context, delAddr sdk.AccAddress, validator types.AllianceValidator, coin sdk.Coin, shares sdk.Dec, delegation types.Delegation) error {
 	delegation.Shares = delegation.Shares.Sub(shares)
 	if delegation.Shares.IsZero() {
-		store := ctx.KVStore(k.storeKey)
-		key := types.GetDelegationKey(delAddr, validator.GetOperator(), coin.Denom)
-		store.Delete(key)
+		if err := k.beforeAllianceDelegationRemoved(ctx, delAddr, validator.GetOperator(), coin.Denom); err != nil {
+			return err
+		}
+		k.DeleteDelegation(ctx, delAddr, validator.GetOperator(), coin.Denom)
+		k.DeleteRewardForwarding(ctx, delAddr, validator.GetOperator(), coin.Denom)
 	} else {
 		k.SetDelegation(ctx, delAddr, validator.GetOperator(), coin.Denom, delegation)
 	}
+	return nil
 }
 
 func (k Keeper) updateValidatorShares(ctx sdk.Context, validator types.AllianceValidator, delegationShares sdk.DecCoins, validatorShares sdk.DecCoins, isAdd bool) {
@@ -620,7 +791,9 @@ func (k Keeper) ResetAssetAndValidators(ctx sdk.Context, asset types.AllianceAss
 	k.SetAsset(ctx, asset)
 }
 
-func (k Keeper) ClearDustDelegation(ctx sdk.Context, delAddr sdk.AccAddress, validator types.AllianceValidator, asset types.AllianceAsset) {
+// ClearDustDelegation deletes a delegation whose shares are no longer worth a whole token. The remainder stays in the
+// total tokens of the asset and goes to the other delegations of the asset, so it is not tracked as reward dust.
+func (k Keeper) ClearDustDelegation(ctx sdk.Context, delAddr sdk.AccAddress, validator types.AllianceValidator, asset types.AllianceAsset) error {
 	delegatorSharesToRemove := sdk.NewDecCoinFromDec(asset.Denom, sdk.ZeroDec())
 	validatorSharesToRemove := sdk.NewDecCoinFromDec(asset.Denom, sdk.ZeroDec())
 
@@ -630,10 +803,12 @@ func (k Keeper) ClearDustDelegation(ctx sdk.Context, delAddr sdk.AccAddress, val
 		tokensLeft := types.GetDelegationTokensWithShares(delegation.Shares, validator, asset)
 		// If there are no tokens that can be claimed by the delegation, delete the delegation
 		if tokensLeft.IsZero() {
-			store := ctx.KVStore(k.storeKey)
+			if err := k.beforeAllianceDelegationRemoved(ctx, delAddr, validator.GetOperator(), asset.Denom); err != nil {
+				return err
+			}
 			delAddr := sdk.MustAccAddressFromBech32(delegation.DelegatorAddress) // acc address should always be valid here
-			key := types.GetDelegationKey(delAddr, validator.GetOperator(), asset.Denom)
-			store.Delete(key)
+			k.DeleteDelegation(ctx, delAddr, validator.GetOperator(), asset.Denom)
+			k.DeleteRewardForwarding(ctx, delAddr, validator.GetOperator(), asset.Denom)
 
 			delegatorSharesToRemove = sdk.NewDecCoinFromDec(asset.Denom, delegation.Shares)
 		}
@@ -649,4 +824,5 @@ func (k Keeper) ClearDustDelegation(ctx sdk.Context, delAddr sdk.AccAddress, val
 	k.SetValidator(ctx, validator)
 
 	k.ResetAssetAndValidators(ctx, asset)
+	return nil
 }
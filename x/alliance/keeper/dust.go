@@ -0,0 +1,151 @@
+package keeper
+
+import (
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/terra-money/alliance/x/alliance/types"
+)
+
+// SweepRewardDustHook sweeps the reward dust once every reward_dust_sweep_interval. A zero interval disables sweeping.
+func (k Keeper) SweepRewardDustHook(ctx sdk.Context) error {
+	interval := k.RewardDustSweepInterval(ctx)
+	if interval <= 0 {
+		return nil
+	}
+	last := k.LastRewardDustSweepTime(ctx)
+	// If the last sweep time has not been set, set it and do nothing for this block
+	if last.Equal(time.Time{}) {
+		k.SetLastRewardDustSweepTime(ctx, ctx.BlockTime())
+		return nil
+	}
+	if !ctx.BlockTime().After(last.Add(interval)) {
+		return nil
+	}
+	k.SetLastRewardDustSweepTime(ctx, ctx.BlockTime())
+	_, err := k.SweepRewardDust(ctx)
+	return err
+}
+
+// SweepRewardDust takes the whole tokens out of the reward dust and either sends them from the rewards pool to the
+// community pool or sets them aside to be added to the next rewards of a validator, depending on
+// reward_dust_sweep_mode. The fractions stay in the dust until they add up to whole tokens.
+func (k Keeper) SweepRewardDust(ctx sdk.Context) (sdk.Coins, error) {
+	whole, _ := k.GetAllRewardDust(ctx).TruncateDecimal()
+	// The reward indices are rounded, so the dust is never swept beyond the rewards pool balance that is not set
+	// aside for recycling already
+	available := k.GetRewardsPoolBalance(ctx)
+	recycled := k.GetAllRecycledRewardDust(ctx)
+	swept := sdk.NewCoins()
+	for _, coin := range whole {
+		amount := sdk.MinInt(coin.Amount, available.AmountOf(coin.Denom).Sub(recycled.AmountOf(coin.Denom)))
+		if amount.IsPositive() {
+			swept = swept.Add(sdk.NewCoin(coin.Denom, amount))
+		}
+	}
+	if swept.IsZero() {
+		return swept, nil
+	}
+
+	for _, coin := range swept {
+		dust := k.GetRewardDust(ctx, coin.Denom)
+		k.setRewardDust(ctx, dust.Sub(sdk.NewDecCoinFromCoin(coin)))
+	}
+	mode := k.RewardDustSweepMode(ctx)
+	switch mode {
+	case types.RewardDustSweepModeRecycle:
+		for _, coin := range swept {
+			k.setRecycledRewardDust(ctx, coin.Add(sdk.NewCoin(coin.Denom, recycled.AmountOf(coin.Denom))))
+		}
+	default:
+		rewardsPoolAddr := k.accountKeeper.GetModuleAddress(types.RewardsPoolName)
+		if err := k.distributionKeeper.FundCommunityPool(ctx, swept, rewardsPoolAddr); err != nil {
+			return nil, err
+		}
+	}
+
+	_ = ctx.EventManager().EmitTypedEvent(
+		&types.SweepRewardDustEvent{
+			Coins: swept,
+			Mode:  mode,
+		},
+	)
+	return swept, nil
+}
+
+// addRewardDust adds the fractions of reward tokens that were truncated when rewards were claimed.
+//
+// The rounding remainder of undelegations that ClearDustDelegation removes is not reward dust. Those shares are worth
+// less than a token of the asset, and the asset keeps the tokens in total_tokens, so the remainder is shared by the
+// other delegations of the asset through the share price. It never leaves the alliance module account, so there is
+// nothing to sweep.
+func (k Keeper) addRewardDust(ctx sdk.Context, dust sdk.DecCoins) {
+	for _, coin := range dust {
+		if !coin.IsPositive() {
+			continue
+		}
+		k.setRewardDust(ctx, k.GetRewardDust(ctx, coin.Denom).Add(coin))
+	}
+}
+
+// popRecycledRewardDust removes the dust that was set aside for recycling and returns it
+func (k Keeper) popRecycledRewardDust(ctx sdk.Context) sdk.Coins {
+	recycled := k.GetAllRecycledRewardDust(ctx)
+	for _, coin := range recycled {
+		ctx.KVStore(k.storeKey).Delete(types.GetRecycledRewardDustKey(coin.Denom))
+	}
+	return recycled
+}
+
+func (k Keeper) GetRewardDust(ctx sdk.Context, denom string) sdk.DecCoin {
+	b := ctx.KVStore(k.storeKey).Get(types.GetRewardDustKey(denom))
+	if b == nil {
+		return sdk.NewDecCoin(denom, sdk.ZeroInt())
+	}
+	var dust sdk.DecCoin
+	k.cdc.MustUnmarshal(b, &dust)
+	return dust
+}
+
+func (k Keeper) setRewardDust(ctx sdk.Context, dust sdk.DecCoin) {
+	store := ctx.KVStore(k.storeKey)
+	if dust.IsZero() {
+		store.Delete(types.GetRewardDustKey(dust.Denom))
+		return
+	}
+	store.Set(types.GetRewardDustKey(dust.Denom), k.cdc.MustMarshal(&dust))
+}
+
+func (k Keeper) GetAllRewardDust(ctx sdk.Context) sdk.DecCoins {
+	iter := sdk.KVStorePrefixIterator(ctx.KVStore(k.storeKey), types.RewardDustKey)
+	defer iter.Close()
+	dust := sdk.NewDecCoins()
+	for ; iter.Valid(); iter.Next() {
+		var coin sdk.DecCoin
+		k.cdc.MustUnmarshal(iter.Value(), &coin)
+		dust = dust.Add(coin)
+	}
+	return dust
+}
+
+func (k Keeper) setRecycledRewardDust(ctx sdk.Context, coin sdk.Coin) {
+	store := ctx.KVStore(k.storeKey)
+	if coin.IsZero() {
+		store.Delete(types.GetRecycledRewardDustKey(coin.Denom))
+		return
+	}
+	store.Set(types.GetRecycledRewardDustKey(coin.Denom), k.cdc.MustMarshal(&coin))
+}
+
+func (k Keeper) GetAllRecycledRewardDust(ctx sdk.Context) sdk.Coins {
+	iter := sdk.KVStorePrefixIterator(ctx.KVStore(k.storeKey), types.RecycledRewardDustKey)
+	defer iter.Close()
+	recycled := sdk.NewCoins()
+	for ; iter.Valid(); iter.Next() {
+		var coin sdk.Coin
+		k.cdc.MustUnmarshal(iter.Value(), &coin)
+		recycled = recycled.Add(coin)
+	}
+	return recycled
+}
@@ -0,0 +1,57 @@
+package keeper
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/terra-money/alliance/x/alliance/types"
+)
+
+// ApplyEndBlockerStep runs a step of the end blocker in a cached context so that a failing step is rolled back and
+// reported instead of halting the chain. Returns false if the step failed.
+func (k Keeper) ApplyEndBlockerStep(ctx sdk.Context, step string, fn func(ctx sdk.Context) error) bool {
+	return k.applyEndBlockerUnit(ctx, step, "", nil, fn)
+}
+
+// applyEndBlockerUnit runs the processing of a single asset or validator in a cached context. On failure the changes
+// are discarded, an EndBlockerErrorEvent is emitted and the asset, if any, is quarantined.
+func (k Keeper) applyEndBlockerUnit(ctx sdk.Context, step string, denom string, valAddr sdk.ValAddress, fn func(ctx sdk.Context) error) bool {
+	cacheCtx, writeCache := ctx.CacheContext()
+	err := fn(cacheCtx)
+	if err == nil {
+		writeCache()
+		return true
+	}
+
+	k.Logger(ctx).Error("alliance end blocker step failed", "step", step, "denom", denom, "validator", valAddr.String(), "error", err.Error())
+	if denom != "" {
+		k.QuarantineAsset(ctx, denom)
+	}
+	_ = ctx.EventManager().EmitTypedEvent(&types.EndBlockerErrorEvent{
+		Step:      step,
+		Denom:     denom,
+		Validator: valAddr.String(),
+		Error:     err.Error(),
+	})
+	return false
+}
+
+// QuarantineAsset flags an asset that failed to be processed by the end blocker. Quarantined assets are skipped when
+// deducting take rates and changing reward weights until the asset is updated through governance.
+func (k Keeper) QuarantineAsset(ctx sdk.Context, denom string) {
+	asset, found := k.GetAssetByDenom(ctx, denom)
+	if !found || asset.IsQuarantined {
+		return
+	}
+	asset.IsQuarantined = true
+	k.SetAsset(ctx, asset)
+}
+
+// ReleaseAssetFromQuarantine lets the end blocker process the asset again. It is called when governance updates the asset
+func (k Keeper) ReleaseAssetFromQuarantine(ctx sdk.Context, denom string) {
+	asset, found := k.GetAssetByDenom(ctx, denom)
+	if !found || !asset.IsQuarantined {
+		return
+	}
+	asset.IsQuarantined = false
+	k.SetAsset(ctx, asset)
+}
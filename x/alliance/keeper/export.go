@@ -0,0 +1,118 @@
+package keeper
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+
+	"github.com/terra-money/alliance/x/alliance/types"
+)
+
+// PrepForZeroHeightGenesis settles the alliance state before a zero height export. Reward indices, claim heights and
+// snapshot heights have no meaning once the chain restarts at height zero, so all rewards are paid out and the reward
+// bookkeeping is reset. The bond tokens minted for the module delegations are burned since the module delegations are
+// rebuilt by the full rebalance that InitGenesis queues.
+// It must run before the distribution state is reset so that the rewards of the module delegations still go through
+// the rewards pool.
+func (k Keeper) PrepForZeroHeightGenesis(ctx sdk.Context) error {
+	if err := k.MigrateAssetDelegations(ctx, 0); err != nil {
+		return err
+	}
+	moduleAddr := k.accountKeeper.GetModuleAddress(types.ModuleName)
+	var moduleDelegations []stakingtypes.Delegation
+	k.stakingKeeper.IterateDelegatorDelegations(ctx, moduleAddr, func(delegation stakingtypes.Delegation) (stop bool) {
+		moduleDelegations = append(moduleDelegations, delegation)
+		return false
+	})
+
+	// Claim all rewards so that no delegator loses rewards when the reward histories are reset
+	for _, delegation := range moduleDelegations {
+		val, err := k.GetAllianceValidator(ctx, delegation.GetValidatorAddr())
+		if err != nil {
+			return err
+		}
+		if _, err := k.ClaimValidatorRewards(ctx, val); err != nil {
+			return err
+		}
+	}
+	var delegations []types.Delegation
+	k.IterateDelegations(ctx, func(delegation types.Delegation) (stop bool) {
+		delegations = append(delegations, delegation)
+		return false
+	})
+	for _, delegation := range delegations {
+		delAddr := sdk.MustAccAddressFromBech32(delegation.DelegatorAddress)
+		valAddr, err := sdk.ValAddressFromBech32(delegation.ValidatorAddress)
+		if err != nil {
+			return err
+		}
+		val, err := k.GetAllianceValidator(ctx, valAddr)
+		if err != nil {
+			return err
+		}
+		// Rewards are paid out to the delegator account since no packets must be sent during an export
+		if _, err := k.claimDelegationRewards(ctx, delAddr, val, delegation.Denom, false, false); err != nil {
+			return err
+		}
+	}
+
+	// Reset the reward histories. The rounding remainders stay in the rewards pool.
+	k.IterateAllianceValidatorInfo(ctx, func(valAddr sdk.ValAddress, info types.AllianceValidatorInfo) (stop bool) {
+		info.GlobalRewardHistory = types.RewardHistories{}
+		k.SetValidatorInfo(ctx, valAddr, info)
+		return false
+	})
+	delegations = nil
+	k.IterateDelegations(ctx, func(delegation types.Delegation) (stop bool) {
+		delegations = append(delegations, delegation)
+		return false
+	})
+	for _, delegation := range delegations {
+		delAddr := sdk.MustAccAddressFromBech32(delegation.DelegatorAddress)
+		valAddr, err := sdk.ValAddressFromBech32(delegation.ValidatorAddress)
+		if err != nil {
+			return err
+		}
+		delegation.RewardHistory = types.RewardHistories{}
+		delegation.LastRewardClaimHeight = 0
+		k.SetDelegation(ctx, delAddr, valAddr, delegation.Denom, delegation)
+	}
+	k.deleteAllRewardWeightChangeSnapshots(ctx)
+
+	// Remove the module delegations and burn the bond tokens that were minted for them
+	for _, delegation := range moduleDelegations {
+		validator, found := k.stakingKeeper.GetValidator(ctx, delegation.GetValidatorAddr())
+		if !found {
+			continue
+		}
+		poolName := stakingtypes.NotBondedPoolName
+		if validator.IsBonded() {
+			poolName = stakingtypes.BondedPoolName
+		}
+		tokens, err := k.stakingKeeper.Unbond(ctx, moduleAddr, delegation.GetValidatorAddr(), delegation.GetShares())
+		if err != nil {
+			return err
+		}
+		if err := k.bankKeeper.BurnCoins(ctx, poolName, sdk.NewCoins(sdk.NewCoin(k.stakingKeeper.BondDenom(ctx), tokens))); err != nil {
+			return err
+		}
+		k.deletePendingRebalance(ctx, delegation.GetValidatorAddr())
+		k.deleteRebalanceTarget(ctx, delegation.GetValidatorAddr())
+	}
+	k.clearLastRebalanceRates(ctx)
+	k.ConsumeValidatorRebalanceEvents(ctx)
+	k.QueueAssetRebalanceEvent(ctx)
+	return nil
+}
+
+func (k Keeper) deleteAllRewardWeightChangeSnapshots(ctx sdk.Context) {
+	store := ctx.KVStore(k.storeKey)
+	iter := sdk.KVStorePrefixIterator(store, types.RewardWeightChangeSnapshotKey)
+	var keys [][]byte
+	for ; iter.Valid(); iter.Next() {
+		keys = append(keys, iter.Key())
+	}
+	iter.Close()
+	for _, key := range keys {
+		store.Delete(key)
+	}
+}
@@ -0,0 +1,248 @@
+package keeper
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	transfertypes "github.com/cosmos/ibc-go/v6/modules/apps/transfer/types"
+	clienttypes "github.com/cosmos/ibc-go/v6/modules/core/02-client/types"
+
+	"github.com/terra-money/alliance/x/alliance/types"
+)
+
+func (k Keeper) GetRewardForwarding(ctx sdk.Context, delAddr sdk.AccAddress, valAddr sdk.ValAddress, denom string) (f types.RewardForwarding, found bool) {
+	b := ctx.KVStore(k.storeKey).Get(types.GetRewardForwardingKey(delAddr, valAddr, denom))
+	if b == nil {
+		return f, false
+	}
+	k.cdc.MustUnmarshal(b, &f)
+	return f, true
+}
+
+func (k Keeper) SetRewardForwarding(ctx sdk.Context, delAddr sdk.AccAddress, valAddr sdk.ValAddress, denom string, f types.RewardForwarding) {
+	b := k.cdc.MustMarshal(&f)
+	ctx.KVStore(k.storeKey).Set(types.GetRewardForwardingKey(delAddr, valAddr, denom), b)
+}
+
+// DeleteRewardForwarding is called when a delegation is removed so that a later delegation pays out locally again
+func (k Keeper) DeleteRewardForwarding(ctx sdk.Context, delAddr sdk.AccAddress, valAddr sdk.ValAddress, denom string) {
+	ctx.KVStore(k.storeKey).Delete(types.GetRewardForwardingKey(delAddr, valAddr, denom))
+}
+
+func (k Keeper) IterateRewardForwardings(ctx sdk.Context, cb func(f types.RewardForwarding) (stop bool)) {
+	store := ctx.KVStore(k.storeKey)
+	iter := sdk.KVStorePrefixIterator(store, types.RewardForwardingKey)
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		var f types.RewardForwarding
+		k.cdc.MustUnmarshal(iter.Value(), &f)
+		if cb(f) {
+			break
+		}
+	}
+}
+
+func (k Keeper) getPendingRewardForward(ctx sdk.Context, channelID string, sequence uint64) (p types.PendingRewardForward, found bool) {
+	b := ctx.KVStore(k.storeKey).Get(types.GetPendingRewardForwardKey(channelID, sequence))
+	if b == nil {
+		return p, false
+	}
+	k.cdc.MustUnmarshal(b, &p)
+	return p, true
+}
+
+func (k Keeper) setPendingRewardForward(ctx sdk.Context, p types.PendingRewardForward) {
+	b := k.cdc.MustMarshal(&p)
+	ctx.KVStore(k.storeKey).Set(types.GetPendingRewardForwardKey(p.ChannelId, p.Sequence), b)
+}
+
+func (k Keeper) deletePendingRewardForward(ctx sdk.Context, channelID string, sequence uint64) {
+	ctx.KVStore(k.storeKey).Delete(types.GetPendingRewardForwardKey(channelID, sequence))
+}
+
+func (k Keeper) IteratePendingRewardForwards(ctx sdk.Context, cb func(p types.PendingRewardForward) (stop bool)) {
+	store := ctx.KVStore(k.storeKey)
+	iter := sdk.KVStorePrefixIterator(store, types.PendingRewardForwardKey)
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		var p types.PendingRewardForward
+		k.cdc.MustUnmarshal(iter.Value(), &p)
+		if cb(p) {
+			break
+		}
+	}
+}
+
+// GetRewardForwardingEscrow returns the rewards of a delegator that could not be forwarded. The escrowed coins are held
+// by the reward forwarding module account.
+func (k Keeper) GetRewardForwardingEscrow(ctx sdk.Context, delAddr sdk.AccAddress) sdk.Coins {
+	b := ctx.KVStore(k.storeKey).Get(types.GetRewardForwardingEscrowKey(delAddr))
+	if b == nil {
+		return sdk.NewCoins()
+	}
+	var escrow types.RewardForwardingEscrow
+	k.cdc.MustUnmarshal(b, &escrow)
+	return escrow.Coins
+}
+
+func (k Keeper) setRewardForwardingEscrow(ctx sdk.Context, delAddr sdk.AccAddress, coins sdk.Coins) {
+	store := ctx.KVStore(k.storeKey)
+	if coins.IsZero() {
+		store.Delete(types.GetRewardForwardingEscrowKey(delAddr))
+		return
+	}
+	b := k.cdc.MustMarshal(&types.RewardForwardingEscrow{
+		DelegatorAddress: delAddr.String(),
+		Coins:            coins,
+	})
+	store.Set(types.GetRewardForwardingEscrowKey(delAddr), b)
+}
+
+func (k Keeper) IterateRewardForwardingEscrows(ctx sdk.Context, cb func(escrow types.RewardForwardingEscrow) (stop bool)) {
+	store := ctx.KVStore(k.storeKey)
+	iter := sdk.KVStorePrefixIterator(store, types.RewardForwardingEscrowKey)
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		var escrow types.RewardForwardingEscrow
+		k.cdc.MustUnmarshal(iter.Value(), &escrow)
+		if cb(escrow) {
+			break
+		}
+	}
+}
+
+// SetDelegationRewardForwarding sets the forwarding destination of an existing delegation. An empty channel removes
+// the forwarding.
+func (k Keeper) SetDelegationRewardForwarding(ctx sdk.Context, f types.RewardForwarding) error {
+	delAddr, err := sdk.AccAddressFromBech32(f.DelegatorAddress)
+	if err != nil {
+		return err
+	}
+	valAddr, err := sdk.ValAddressFromBech32(f.ValidatorAddress)
+	if err != nil {
+		return err
+	}
+	if f.ChannelId == "" {
+		if _, found := k.GetRewardForwarding(ctx, delAddr, valAddr, f.Denom); !found {
+			return types.ErrRewardForwardingNotFound
+		}
+		k.DeleteRewardForwarding(ctx, delAddr, valAddr, f.Denom)
+		return nil
+	}
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	if _, found := k.GetDelegation(ctx, delAddr, valAddr, f.Denom); !found {
+		return stakingtypes.ErrNoDelegatorForAddress
+	}
+	k.SetRewardForwarding(ctx, delAddr, valAddr, f.Denom, f)
+	return nil
+}
+
+// forwardRewards sends the claimed rewards of a delegation to its forwarding destination. The reward forwarding module
+// account is the sender of the transfers so that a failed or timed-out transfer is refunded to it, where it is kept in
+// escrow for the delegator. Transfers that cannot be sent at all are escrowed right away so that a claim never fails
+// because of the forwarding.
+func (k Keeper) forwardRewards(ctx sdk.Context, f types.RewardForwarding, coins sdk.Coins) error {
+	if coins.IsZero() {
+		return nil
+	}
+	// creates the module account on chains that did not have it yet
+	moduleAddr := k.accountKeeper.GetModuleAccount(ctx, types.RewardForwardingName).GetAddress()
+	err := k.bankKeeper.SendCoinsFromModuleToModule(ctx, types.RewardsPoolName, types.RewardForwardingName, coins)
+	if err != nil {
+		return err
+	}
+
+	delAddr := sdk.MustAccAddressFromBech32(f.DelegatorAddress)
+	timeout := uint64(ctx.BlockTime().Add(f.Timeout).UnixNano())
+	forwarded := sdk.NewCoins()
+	for _, coin := range coins {
+		msg := transfertypes.NewMsgTransfer(transfertypes.PortID, f.ChannelId, coin, moduleAddr.String(), f.Receiver,
+			clienttypes.ZeroHeight(), timeout, "")
+		cacheCtx, writeCache := ctx.CacheContext()
+		res, err := k.transferKeeper.Transfer(sdk.WrapSDKContext(cacheCtx), msg)
+		if err != nil {
+			k.escrowRewards(ctx, delAddr, sdk.NewCoins(coin), err.Error())
+			continue
+		}
+		writeCache()
+		k.setPendingRewardForward(ctx, types.PendingRewardForward{
+			ChannelId:        f.ChannelId,
+			Sequence:         res.Sequence,
+			DelegatorAddress: f.DelegatorAddress,
+			Amount:           coin,
+		})
+		forwarded = forwarded.Add(coin)
+	}
+
+	if !forwarded.IsZero() {
+		_ = ctx.EventManager().EmitTypedEvent(
+			&types.ForwardAllianceRewardsEvent{
+				AllianceSender: f.DelegatorAddress,
+				Validator:      f.ValidatorAddress,
+				ChannelId:      f.ChannelId,
+				Receiver:       f.Receiver,
+				Coins:          forwarded,
+			},
+		)
+	}
+	return nil
+}
+
+// OnRewardForwardAcknowledged removes the pending forward of a packet that was received on the counterparty chain.
+// Packets that are not reward forwards are ignored.
+func (k Keeper) OnRewardForwardAcknowledged(ctx sdk.Context, channelID string, sequence uint64) {
+	k.deletePendingRewardForward(ctx, channelID, sequence)
+}
+
+// OnRewardForwardFailed moves the rewards of a failed or timed-out forward into the escrow of the delegator. The
+// transfer module already refunded the tokens to the reward forwarding module account. Packets that are not reward forwards
+// are ignored.
+func (k Keeper) OnRewardForwardFailed(ctx sdk.Context, channelID string, sequence uint64, reason string) {
+	pending, found := k.getPendingRewardForward(ctx, channelID, sequence)
+	if !found {
+		return
+	}
+	k.deletePendingRewardForward(ctx, channelID, sequence)
+	delAddr := sdk.MustAccAddressFromBech32(pending.DelegatorAddress)
+	k.escrowRewards(ctx, delAddr, sdk.NewCoins(pending.Amount), reason)
+}
+
+func (k Keeper) escrowRewards(ctx sdk.Context, delAddr sdk.AccAddress, coins sdk.Coins, reason string) {
+	k.setRewardForwardingEscrow(ctx, delAddr, k.GetRewardForwardingEscrow(ctx, delAddr).Add(coins...))
+	_ = ctx.EventManager().EmitTypedEvent(
+		&types.EscrowAllianceRewardsEvent{
+			AllianceSender: delAddr.String(),
+			Coins:          coins,
+			Reason:         reason,
+		},
+	)
+}
+
+// ClaimRewardForwardingEscrow pays out the escrowed rewards to the delegator account on this chain
+func (k Keeper) ClaimRewardForwardingEscrow(ctx sdk.Context, delAddr sdk.AccAddress) (sdk.Coins, error) {
+	coins := k.GetRewardForwardingEscrow(ctx, delAddr)
+	if coins.IsZero() {
+		return nil, types.ErrEmptyRewardForwardingEscrow
+	}
+	k.setRewardForwardingEscrow(ctx, delAddr, sdk.NewCoins())
+	if err := k.bankKeeper.SendCoinsFromModuleToAccount(ctx, types.RewardForwardingName, delAddr, coins); err != nil {
+		return nil, err
+	}
+	return coins, nil
+}
+
+// GetRewardForwardingBalance returns the balance of the reward forwarding module account
+func (k Keeper) GetRewardForwardingBalance(ctx sdk.Context) sdk.Coins {
+	return k.bankKeeper.GetAllBalances(ctx, k.accountKeeper.GetModuleAddress(types.RewardForwardingName))
+}
+
+// TotalRewardForwardingEscrow sums up the escrowed rewards of all delegators
+func (k Keeper) TotalRewardForwardingEscrow(ctx sdk.Context) sdk.Coins {
+	total := sdk.NewCoins()
+	k.IterateRewardForwardingEscrows(ctx, func(escrow types.RewardForwardingEscrow) bool {
+		total = total.Add(escrow.Coins...)
+		return false
+	})
+	return total
+}
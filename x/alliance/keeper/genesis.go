@@ -17,6 +17,11 @@ func (k Keeper) InitGenesis(ctx sdk.Context, g *types.GenesisState) []abci.Valid
 		}
 		k.SetAsset(ctx, asset)
 	}
+	k.setRewardWeightScale(ctx, k.calculateRewardWeightScale(ctx, k.GetAllAssets(ctx)))
+
+	for _, group := range g.AssetGroups {
+		k.SetAssetGroup(ctx, group)
+	}
 
 	for _, val := range g.ValidatorInfos {
 		valAddr, _ := sdk.ValAddressFromBech32(val.ValidatorAddress)
@@ -43,7 +48,7 @@ func (k Keeper) InitGenesis(ctx sdk.Context, g *types.GenesisState) []abci.Valid
 			continue
 		}
 		delAddr, _ := sdk.AccAddressFromBech32(undelegationState.Undelegation.Entries[0].DelegatorAddress)
-		k.setQueuedUndelegations(ctx, undelegationState.CompletionTime, delAddr, undelegationState.Undelegation)
+		k.SetQueuedUndelegations(ctx, undelegationState.CompletionTime, delAddr, undelegationState.Undelegation)
 		for _, undelegation := range undelegationState.Undelegation.Entries {
 			valAddr, _ := sdk.ValAddressFromBech32(undelegation.ValidatorAddress)
 			k.setUnbondingIndexByVal(ctx, valAddr, undelegationState.CompletionTime, delAddr, undelegation.Balance.Denom)
@@ -55,15 +60,67 @@ func (k Keeper) InitGenesis(ctx sdk.Context, g *types.GenesisState) []abci.Valid
 		k.setRewardWeightChangeSnapshot(ctx, rewardWeightSnapshot.Denom, valAddr, rewardWeightSnapshot.Height, rewardWeightSnapshot.Snapshot)
 	}
 
+	for _, forwarding := range g.RewardForwardings {
+		delAddr, _ := sdk.AccAddressFromBech32(forwarding.DelegatorAddress)
+		valAddr, _ := sdk.ValAddressFromBech32(forwarding.ValidatorAddress)
+		k.SetRewardForwarding(ctx, delAddr, valAddr, forwarding.Denom, forwarding)
+	}
+
+	for _, pending := range g.PendingRewardForwards {
+		k.setPendingRewardForward(ctx, pending)
+	}
+
+	for _, escrow := range g.RewardForwardingEscrows {
+		delAddr, _ := sdk.AccAddressFromBech32(escrow.DelegatorAddress)
+		k.setRewardForwardingEscrow(ctx, delAddr, escrow.Coins)
+	}
+
+	for _, lock := range g.DelegationLocks {
+		delAddr, _ := sdk.AccAddressFromBech32(lock.DelegatorAddress)
+		valAddr, _ := sdk.ValAddressFromBech32(lock.ValidatorAddress)
+		k.SetDelegationLock(ctx, delAddr, valAddr, lock)
+	}
+
+	for _, incentive := range g.RewardIncentives {
+		k.SetRewardIncentive(ctx, incentive)
+	}
+	if g.NextRewardIncentiveId > 0 {
+		k.SetNextRewardIncentiveID(ctx, g.NextRewardIncentiveId)
+	}
+
+	k.addRewardDust(ctx, g.RewardDust)
+	for _, coin := range g.RecycledRewardDust {
+		k.setRecycledRewardDust(ctx, coin)
+	}
+
+	for _, checkpoint := range g.RewardRateCheckpoints {
+		k.setRewardRateCheckpoint(ctx, checkpoint)
+	}
+	for _, checkpoint := range g.RewardWeightsCheckpoints {
+		k.setRewardWeightsCheckpoint(ctx, checkpoint)
+	}
+
+	// Pending rebalances are not exported and the module delegations are removed by a zero height export, so a full
+	// rebalance rebuilds them at the end of the first block
+	k.QueueAssetRebalanceEvent(ctx)
+
 	return []abci.ValidatorUpdate{}
 }
 
 func (k Keeper) ExportGenesis(ctx sdk.Context) *types.GenesisState {
+	// Delegations of an asset migration in progress are exported with the new denom
+	if _, found := k.GetAssetMigration(ctx); found {
+		ctx, _ = ctx.CacheContext()
+		if err := k.MigrateAssetDelegations(ctx, 0); err != nil {
+			panic(err)
+		}
+	}
 	state := types.GenesisState{}
 	assets := k.GetAllAssets(ctx)
 	for _, asset := range assets {
 		state.Assets = append(state.Assets, *asset)
 	}
+	state.AssetGroups = k.GetAllAssetGroups(ctx)
 
 	k.IterateAllianceValidatorInfo(ctx, func(valAddr sdk.ValAddress, info types.AllianceValidatorInfo) (stop bool) {
 		state.ValidatorInfos = append(state.ValidatorInfos, types.ValidatorInfoState{
@@ -104,10 +161,58 @@ func (k Keeper) ExportGenesis(ctx sdk.Context) *types.GenesisState {
 		return false
 	})
 
+	k.IterateRewardForwardings(ctx, func(f types.RewardForwarding) (stop bool) {
+		state.RewardForwardings = append(state.RewardForwardings, f)
+		return false
+	})
+
+	k.IteratePendingRewardForwards(ctx, func(p types.PendingRewardForward) (stop bool) {
+		state.PendingRewardForwards = append(state.PendingRewardForwards, p)
+		return false
+	})
+
+	k.IterateRewardForwardingEscrows(ctx, func(escrow types.RewardForwardingEscrow) (stop bool) {
+		state.RewardForwardingEscrows = append(state.RewardForwardingEscrows, escrow)
+		return false
+	})
+
+	k.IterateDelegationLocks(ctx, func(lock types.DelegationLock) (stop bool) {
+		state.DelegationLocks = append(state.DelegationLocks, lock)
+		return false
+	})
+
+	k.IterateRewardIncentives(ctx, func(incentive types.RewardIncentive) (stop bool) {
+		state.RewardIncentives = append(state.RewardIncentives, incentive)
+		return false
+	})
+	state.NextRewardIncentiveId = k.GetNextRewardIncentiveID(ctx)
+	state.RewardDust = k.GetAllRewardDust(ctx)
+	state.RecycledRewardDust = k.GetAllRecycledRewardDust(ctx)
+
+	k.IterateRewardRateCheckpoints(ctx, func(checkpoint types.RewardRateCheckpoint) (stop bool) {
+		state.RewardRateCheckpoints = append(state.RewardRateCheckpoints, checkpoint)
+		return false
+	})
+	k.IterateRewardWeightsCheckpoints(ctx, func(checkpoint types.RewardWeightsCheckpoint) (stop bool) {
+		state.RewardWeightsCheckpoints = append(state.RewardWeightsCheckpoints, checkpoint)
+		return false
+	})
+
 	state.Params = types.Params{
-		RewardDelayTime:       k.RewardDelayTime(ctx),
-		TakeRateClaimInterval: k.RewardClaimInterval(ctx),
-		LastTakeRateClaimTime: k.LastRewardClaimTime(ctx),
+		RewardDelayTime:              k.RewardDelayTime(ctx),
+		TakeRateClaimInterval:        k.RewardClaimInterval(ctx),
+		LastTakeRateClaimTime:        k.LastRewardClaimTime(ctx),
+		MaxTotalRewardWeight:         k.MaxTotalRewardWeight(ctx),
+		RewardWeightCapMode:          k.RewardWeightCapMode(ctx),
+		FullRebalanceThreshold:       k.FullRebalanceThreshold(ctx),
+		MaxRebalanceAmount:           k.MaxRebalanceAmount(ctx),
+		MaxRebalanceFraction:         k.MaxRebalanceFraction(ctx),
+		LockDurations:                k.LockDurations(ctx),
+		RewardDustSweepInterval:      k.RewardDustSweepInterval(ctx),
+		LastRewardDustSweepTime:      k.LastRewardDustSweepTime(ctx),
+		RewardDustSweepMode:          k.RewardDustSweepMode(ctx),
+		RewardRateCheckpointInterval: k.RewardRateCheckpointInterval(ctx),
+		RewardRateRetention:          k.RewardRateRetention(ctx),
 	}
 
 	return &state
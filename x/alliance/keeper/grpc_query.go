@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"sort"
+	"time"
 
 	"github.com/terra-money/alliance/x/alliance/types"
 
@@ -38,7 +40,7 @@ func (k QueryServer) AllAlliancesDelegations(c context.Context, req *types.Query
 		var delegation types.Delegation
 		k.cdc.MustUnmarshal(value, &delegation)
 
-		asset, found := k.GetAssetByDenom(ctx, delegation.Denom)
+		asset, found := k.GetDelegationAsset(ctx, delegation.Denom)
 		if !found {
 			return types.ErrUnknownAsset
 		}
@@ -69,11 +71,9 @@ func (k QueryServer) AllianceValidator(c context.Context, req *types.QueryAllian
 		return nil, status.Error(codes.InvalidArgument, "invalid request")
 	}
 	ctx := sdk.UnwrapSDKContext(c)
-	res := types.QueryAllianceValidatorResponse{
-		ValidatorAddr:         req.ValidatorAddr,
-		TotalDelegationShares: nil,
-		ValidatorShares:       nil,
-		TotalStaked:           nil,
+	decodedDenom, err := url.QueryUnescape(req.Denom)
+	if err == nil {
+		req.Denom = decodedDenom
 	}
 	valAddr, err := sdk.ValAddressFromBech32(req.ValidatorAddr)
 	if err != nil {
@@ -83,16 +83,12 @@ func (k QueryServer) AllianceValidator(c context.Context, req *types.QueryAllian
 	if err != nil {
 		return nil, status.Error(codes.NotFound, fmt.Sprintf("validator with address %s not found", req.ValidatorAddr))
 	}
-	res.ValidatorShares = val.ValidatorShares
-	res.TotalDelegationShares = val.TotalDelegatorShares
-
-	for _, share := range val.ValidatorShares {
-		asset, found := k.GetAssetByDenom(ctx, share.Denom)
-		if !found {
-			continue
-		}
-		res.TotalStaked = append(res.TotalStaked, sdk.NewDecCoinFromDec(share.Denom, val.TotalTokensWithAsset(asset)))
+	assets := k.GetAllAssets(ctx)
+	inputs, err := k.getRebalanceInputs(ctx, assets)
+	if err != nil {
+		return nil, err
 	}
+	res := k.allianceValidatorResponse(ctx, assets, inputs, val, req.Denom)
 	return &res, nil
 }
 
@@ -101,37 +97,39 @@ func (k QueryServer) AllAllianceValidators(c context.Context, req *types.QueryAl
 		return nil, status.Error(codes.InvalidArgument, "invalid request")
 	}
 	ctx := sdk.UnwrapSDKContext(c)
+	decodedDenom, err := url.QueryUnescape(req.Denom)
+	if err == nil {
+		req.Denom = decodedDenom
+	}
 	res := &types.QueryAllianceValidatorsResponse{
 		Validators: nil,
 		Pagination: nil,
 	}
+	assets := k.GetAllAssets(ctx)
+	inputs, err := k.getRebalanceInputs(ctx, assets)
+	if err != nil {
+		return nil, err
+	}
+	if req.SortByStaked {
+		return k.sortedAllianceValidators(ctx, req, assets, inputs)
+	}
 
 	store := ctx.KVStore(k.storeKey)
 	valStore := prefix.NewStore(store, types.ValidatorInfoKey)
 
-	pageRes, err := query.Paginate(valStore, req.Pagination, func(key []byte, value []byte) error {
+	pageRes, err := query.FilteredPaginate(valStore, req.Pagination, func(key []byte, value []byte, accumulate bool) (bool, error) {
 		valAddr := sdk.ValAddress(key[1:]) // Due to length prefix when encoding the key
 		val, err := k.GetAllianceValidator(ctx, valAddr)
 		if err != nil {
-			return err
+			return false, err
 		}
-
-		totalStaked := sdk.NewDecCoins()
-		for _, share := range val.ValidatorShares {
-			asset, found := k.GetAssetByDenom(ctx, share.Denom)
-			if !found {
-				continue
-			}
-			totalStaked = append(totalStaked, sdk.NewDecCoinFromDec(share.Denom, val.TotalTokensWithAsset(asset)))
+		if req.Denom != "" && !val.TotalDelegationSharesWithDenom(req.Denom).IsPositive() {
+			return false, nil
 		}
-
-		res.Validators = append(res.Validators, types.QueryAllianceValidatorResponse{
-			ValidatorAddr:         valAddr.String(),
-			TotalDelegationShares: val.TotalDelegatorShares,
-			ValidatorShares:       val.ValidatorShares,
-			TotalStaked:           totalStaked,
-		})
-		return nil
+		if accumulate {
+			res.Validators = append(res.Validators, k.allianceValidatorResponse(ctx, assets, inputs, val, req.Denom))
+		}
+		return true, nil
 	})
 	if err != nil {
 		return nil, err
@@ -140,6 +138,110 @@ func (k QueryServer) AllAllianceValidators(c context.Context, req *types.QueryAl
 	return res, nil
 }
 
+// sortedAllianceValidators loads all the validators to sort them, so only offset pagination is supported
+func (k QueryServer) sortedAllianceValidators(ctx sdk.Context, req *types.QueryAllAllianceValidatorsRequest, assets []*types.AllianceAsset, inputs rebalanceInputs) (*types.QueryAllianceValidatorsResponse, error) {
+	offset, limit := uint64(0), uint64(query.DefaultLimit)
+	countTotal := false
+	if req.Pagination != nil {
+		if len(req.Pagination.Key) > 0 {
+			return nil, status.Error(codes.InvalidArgument, "only offset pagination is supported when sorting")
+		}
+		offset = req.Pagination.Offset
+		if req.Pagination.Limit > 0 {
+			limit = req.Pagination.Limit
+		}
+		countTotal = req.Pagination.CountTotal
+	}
+
+	var valAddrs []sdk.ValAddress
+	k.IterateAllianceValidatorInfo(ctx, func(valAddr sdk.ValAddress, info types.AllianceValidatorInfo) bool {
+		if req.Denom == "" || sdk.DecCoins(info.TotalDelegatorShares).AmountOf(req.Denom).IsPositive() {
+			valAddrs = append(valAddrs, valAddr)
+		}
+		return false
+	})
+	validators := make([]types.QueryAllianceValidatorResponse, 0, len(valAddrs))
+	for _, valAddr := range valAddrs {
+		val, err := k.GetAllianceValidator(ctx, valAddr)
+		if err != nil {
+			return nil, err
+		}
+		validators = append(validators, k.allianceValidatorResponse(ctx, assets, inputs, val, req.Denom))
+	}
+	sortKey := func(res types.QueryAllianceValidatorResponse) sdk.Dec {
+		if req.Denom != "" {
+			return sdk.DecCoins(res.TotalStaked).AmountOf(req.Denom)
+		}
+		return res.BondedAmount
+	}
+	sort.SliceStable(validators, func(i, j int) bool {
+		return sortKey(validators[i]).GT(sortKey(validators[j]))
+	})
+
+	res := &types.QueryAllianceValidatorsResponse{
+		Pagination: &query.PageResponse{},
+	}
+	if countTotal {
+		res.Pagination.Total = uint64(len(validators))
+	}
+	if offset < uint64(len(validators)) {
+		end := offset + limit
+		if end > uint64(len(validators)) {
+			end = uint64(len(validators))
+		}
+		res.Validators = validators[offset:end]
+	}
+	return res, nil
+}
+
+// allianceValidatorResponse attributes the bond tokens the module delegated to a validator to its assets and limits
+// the response to a single asset if a denom is given
+func (k QueryServer) allianceValidatorResponse(ctx sdk.Context, assets []*types.AllianceAsset, inputs rebalanceInputs, val types.AllianceValidator, denom string) types.QueryAllianceValidatorResponse {
+	bondedAmount, bondedAmountPerAsset := k.bondedAmountPerAsset(ctx, assets, inputs, val)
+	res := types.QueryAllianceValidatorResponse{
+		ValidatorAddr:         val.OperatorAddress,
+		TotalDelegationShares: val.TotalDelegatorShares,
+		ValidatorShares:       val.ValidatorShares,
+		TotalStaked:           nil,
+		BondedAmount:          bondedAmount,
+		BondedAmountPerAsset:  bondedAmountPerAsset,
+		GlobalRewardHistory:   val.GlobalRewardHistory,
+	}
+	for _, share := range val.ValidatorShares {
+		asset, found := k.GetAssetByDenom(ctx, share.Denom)
+		if !found {
+			continue
+		}
+		res.TotalStaked = append(res.TotalStaked, sdk.NewDecCoinFromDec(share.Denom, val.TotalTokensWithAsset(asset)))
+	}
+	if denom == "" {
+		return res
+	}
+
+	filter := func(coins []sdk.DecCoin) []sdk.DecCoin {
+		var filtered []sdk.DecCoin
+		for _, coin := range coins {
+			if coin.Denom == denom {
+				filtered = append(filtered, coin)
+			}
+		}
+		return filtered
+	}
+	res.TotalDelegationShares = filter(res.TotalDelegationShares)
+	res.ValidatorShares = filter(res.ValidatorShares)
+	res.TotalStaked = filter(res.TotalStaked)
+	res.BondedAmountPerAsset = filter(res.BondedAmountPerAsset)
+	// The rewards shared by all assets are kept together with the rewards of the asset itself
+	var histories []types.RewardHistory
+	for _, history := range res.GlobalRewardHistory {
+		if history.Alliance == "" || history.Alliance == denom {
+			histories = append(histories, history)
+		}
+	}
+	res.GlobalRewardHistory = histories
+	return res
+}
+
 var _ types.QueryServer = QueryServer{}
 
 func (k QueryServer) Params(c context.Context, _ *types.QueryParamsRequest) (*types.QueryParamsResponse, error) {
@@ -303,7 +405,7 @@ func (k QueryServer) AlliancesDelegation(c context.Context, req *types.QueryAlli
 			return err
 		}
 
-		asset, found := k.GetAssetByDenom(ctx, delegation.Denom)
+		asset, found := k.GetDelegationAsset(ctx, delegation.Denom)
 		if !found {
 			return types.ErrUnknownAsset
 		}
@@ -369,7 +471,7 @@ func (k QueryServer) AlliancesDelegationByValidator(c context.Context, req *type
 			return err
 		}
 
-		asset, found := k.GetAssetByDenom(ctx, delegation.Denom)
+		asset, found := k.GetDelegationAsset(ctx, delegation.Denom)
 		if !found {
 			return types.ErrUnknownAsset
 		}
@@ -429,6 +531,10 @@ func (k QueryServer) AllianceDelegation(c context.Context, req *types.QueryAllia
 	}
 
 	delegation, found := k.GetDelegation(ctx, delAddr, validator.GetOperator(), req.Denom)
+	// Delegations that were not moved yet by an asset migration still use the old denom
+	if m, migrating := k.GetAssetMigration(ctx); !found && migrating && m.ToDenom == req.Denom {
+		delegation, found = k.GetDelegation(ctx, delAddr, validator.GetOperator(), m.FromDenom)
+	}
 	if !found {
 		return &types.QueryAllianceDelegationResponse{
 			Delegation: types.DelegationResponse{
@@ -457,6 +563,387 @@ func (k QueryServer) IBCAllianceDelegation(c context.Context, request *types.Que
 	return k.AllianceDelegation(c, &req)
 }
 
+func (k QueryServer) PendingRebalance(c context.Context, req *types.QueryPendingRebalanceRequest) (*types.QueryPendingRebalanceResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	ctx := sdk.UnwrapSDKContext(c)
+	valAddr, err := sdk.ValAddressFromBech32(req.ValidatorAddr)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("validator address %s invalid", req.ValidatorAddr))
+	}
+	return &types.QueryPendingRebalanceResponse{
+		ValidatorAddr: valAddr.String(),
+		Amount:        k.GetPendingRebalance(ctx, valAddr),
+	}, nil
+}
+
+func (k QueryServer) AllPendingRebalances(c context.Context, req *types.QueryAllPendingRebalancesRequest) (*types.QueryPendingRebalancesResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	ctx := sdk.UnwrapSDKContext(c)
+	res := &types.QueryPendingRebalancesResponse{
+		PendingRebalances: nil,
+		Pagination:        nil,
+	}
+
+	store := ctx.KVStore(k.storeKey)
+	pendingStore := prefix.NewStore(store, types.PendingRebalanceKey)
+
+	pageRes, err := query.Paginate(pendingStore, req.Pagination, func(key []byte, value []byte) error {
+		valAddr := sdk.ValAddress(key[1:]) // Due to length prefix when encoding the key
+		var amount sdk.IntProto
+		k.cdc.MustUnmarshal(value, &amount)
+		res.PendingRebalances = append(res.PendingRebalances, types.QueryPendingRebalanceResponse{
+			ValidatorAddr: valAddr.String(),
+			Amount:        amount.Int,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	res.Pagination = pageRes
+	return res, nil
+}
+
+func (k QueryServer) RebalancePreview(c context.Context, req *types.QueryRebalancePreviewRequest) (*types.QueryRebalancePreviewResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	ctx := sdk.UnwrapSDKContext(c)
+	assets := k.GetAllAssets(ctx)
+	inputs, err := k.getRebalanceInputs(ctx, assets)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &types.QueryRebalancePreviewResponse{
+		NativeBondAmount:        inputs.nativeBondAmount,
+		UnbondedValidatorShares: inputs.unbondedValidatorShares,
+		Validators:              nil,
+	}
+	for _, validator := range inputs.bondedValidators {
+		currentBondedAmount := k.currentBondedAmount(ctx, inputs, validator)
+		expectedBondAmount, expectedBondAmountPerAsset := expectedBondAmount(assets, inputs, validator)
+		delta, pending := k.rebalanceDelta(ctx, validator, expectedBondAmount, currentBondedAmount)
+		res.Validators = append(res.Validators, types.RebalancePreviewValidator{
+			ValidatorAddr:              validator.GetOperator().String(),
+			CurrentBondedAmount:        currentBondedAmount,
+			ExpectedBondAmount:         expectedBondAmount,
+			ExpectedBondAmountPerAsset: expectedBondAmountPerAsset,
+			Delta:                      delta,
+			PendingDelta:               pending,
+		})
+	}
+	return res, nil
+}
+
+func (k QueryServer) RewardForwarding(c context.Context, req *types.QueryRewardForwardingRequest) (*types.QueryRewardForwardingResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	ctx := sdk.UnwrapSDKContext(c)
+	decodedDenom, err := url.QueryUnescape(req.Denom)
+	if err == nil {
+		req.Denom = decodedDenom
+	}
+
+	delAddr, err := sdk.AccAddressFromBech32(req.DelegatorAddr)
+	if err != nil {
+		return nil, err
+	}
+	valAddr, err := sdk.ValAddressFromBech32(req.ValidatorAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	forwarding, found := k.GetRewardForwarding(ctx, delAddr, valAddr, req.Denom)
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "RewardForwarding not found for delegator %s, validator %s and denom %s", req.DelegatorAddr, req.ValidatorAddr, req.Denom)
+	}
+	return &types.QueryRewardForwardingResponse{
+		Forwarding: forwarding,
+	}, nil
+}
+
+func (k QueryServer) RewardForwardingEscrow(c context.Context, req *types.QueryRewardForwardingEscrowRequest) (*types.QueryRewardForwardingEscrowResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	ctx := sdk.UnwrapSDKContext(c)
+	delAddr, err := sdk.AccAddressFromBech32(req.DelegatorAddr)
+	if err != nil {
+		return nil, err
+	}
+	return &types.QueryRewardForwardingEscrowResponse{
+		Coins: k.GetRewardForwardingEscrow(ctx, delAddr),
+	}, nil
+}
+
+func (k QueryServer) AssetMigration(c context.Context, _ *types.QueryAssetMigrationRequest) (*types.QueryAssetMigrationResponse, error) {
+	ctx := sdk.UnwrapSDKContext(c)
+	migration, found := k.GetAssetMigration(ctx)
+	if !found {
+		return &types.QueryAssetMigrationResponse{}, nil
+	}
+	return &types.QueryAssetMigrationResponse{
+		Migration: &migration,
+	}, nil
+}
+
+func (k QueryServer) AssetGroups(c context.Context, req *types.QueryAssetGroupsRequest) (*types.QueryAssetGroupsResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	ctx := sdk.UnwrapSDKContext(c)
+	groupsStore := prefix.NewStore(ctx.KVStore(k.storeKey), types.AssetGroupKey)
+
+	var groups []types.AssetGroup
+	pageRes, err := query.Paginate(groupsStore, req.Pagination, func(key []byte, value []byte) error {
+		var group types.AssetGroup
+		if err := k.cdc.Unmarshal(value, &group); err != nil {
+			return err
+		}
+		groups = append(groups, group)
+		return nil
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &types.QueryAssetGroupsResponse{
+		Groups:     groups,
+		Pagination: pageRes,
+	}, nil
+}
+
+func (k QueryServer) AssetGroup(c context.Context, req *types.QueryAssetGroupRequest) (*types.QueryAssetGroupResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	ctx := sdk.UnwrapSDKContext(c)
+	group, found := k.GetAssetGroup(ctx, req.Name)
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "Asset group not found by name %s", req.Name)
+	}
+	return &types.QueryAssetGroupResponse{
+		Group: group,
+	}, nil
+}
+
+func (k QueryServer) AllianceDelegationLocks(c context.Context, req *types.QueryAllianceDelegationLocksRequest) (*types.QueryAllianceDelegationLocksResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	ctx := sdk.UnwrapSDKContext(c)
+	delAddr, err := sdk.AccAddressFromBech32(req.DelegatorAddr)
+	if err != nil {
+		return nil, err
+	}
+	locksStore := prefix.NewStore(ctx.KVStore(k.storeKey), types.GetDelegationLocksKey(delAddr))
+
+	var locks []types.DelegationLock
+	pageRes, err := query.Paginate(locksStore, req.Pagination, func(key []byte, value []byte) error {
+		var lock types.DelegationLock
+		if err := k.cdc.Unmarshal(value, &lock); err != nil {
+			return err
+		}
+		locks = append(locks, lock)
+		return nil
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &types.QueryAllianceDelegationLocksResponse{
+		Locks:      locks,
+		Pagination: pageRes,
+	}, nil
+}
+
+func (k QueryServer) RewardIncentives(c context.Context, req *types.QueryRewardIncentivesRequest) (*types.QueryRewardIncentivesResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	ctx := sdk.UnwrapSDKContext(c)
+	incentivesStore := prefix.NewStore(ctx.KVStore(k.storeKey), types.RewardIncentiveKey)
+
+	var incentives []types.RewardIncentive
+	pageRes, err := query.Paginate(incentivesStore, req.Pagination, func(key []byte, value []byte) error {
+		var incentive types.RewardIncentive
+		if err := k.cdc.Unmarshal(value, &incentive); err != nil {
+			return err
+		}
+		incentives = append(incentives, incentive)
+		return nil
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &types.QueryRewardIncentivesResponse{
+		Incentives: incentives,
+		Pagination: pageRes,
+	}, nil
+}
+
+func (k QueryServer) RewardIncentive(c context.Context, req *types.QueryRewardIncentiveRequest) (*types.QueryRewardIncentiveResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	ctx := sdk.UnwrapSDKContext(c)
+	incentive, found := k.GetRewardIncentive(ctx, req.Id)
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "Reward incentive not found by id %d", req.Id)
+	}
+	return &types.QueryRewardIncentiveResponse{
+		Incentive: incentive,
+		Unspent:   incentive.Unspent(),
+	}, nil
+}
+
+func (k QueryServer) RewardDust(c context.Context, req *types.QueryRewardDustRequest) (*types.QueryRewardDustResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	ctx := sdk.UnwrapSDKContext(c)
+	return &types.QueryRewardDustResponse{
+		Dust:     k.GetAllRewardDust(ctx),
+		Recycled: k.GetAllRecycledRewardDust(ctx),
+	}, nil
+}
+
+func (k QueryServer) AllianceRewardRates(c context.Context, req *types.QueryAllianceRewardRatesRequest) (*types.QueryAllianceRewardRatesResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	ctx := sdk.UnwrapSDKContext(c)
+	decodedDenom, err := url.QueryUnescape(req.Denom)
+	if err == nil {
+		req.Denom = decodedDenom
+	}
+	if _, found := k.GetAssetByDenom(ctx, req.Denom); !found {
+		return nil, status.Errorf(codes.NotFound, "Asset with denom: %s does not exist", req.Denom)
+	}
+
+	var valAddr sdk.ValAddress
+	if req.ValidatorAddr != "" {
+		valAddr, err = sdk.ValAddressFromBech32(req.ValidatorAddr)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("validator address %s invalid", req.ValidatorAddr))
+		}
+	}
+	var window time.Duration
+	if req.Window != "" {
+		window, err = time.ParseDuration(req.Window)
+		if err != nil || window < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid window %s", req.Window)
+		}
+	}
+
+	rates, validatorRates, startTime, endTime := k.GetAllianceRewardRates(ctx, req.Denom, valAddr, window)
+	return &types.QueryAllianceRewardRatesResponse{
+		Denom:       req.Denom,
+		StartTime:   startTime,
+		EndTime:     endTime,
+		AnnualRates: rates,
+		Validators:  validatorRates,
+	}, nil
+}
+
+func (k QueryServer) SimulateAllianceAction(c context.Context, req *types.QuerySimulateAllianceActionRequest) (*types.QuerySimulateAllianceActionResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	ctx := sdk.UnwrapSDKContext(c)
+	delAddr, err := sdk.AccAddressFromBech32(req.DelegatorAddr)
+	if err != nil {
+		return nil, err
+	}
+	valAddr, err := sdk.ValAddressFromBech32(req.ValidatorAddr)
+	if err != nil {
+		return nil, err
+	}
+	var dstValAddr sdk.ValAddress
+	if req.Action == types.AllianceActionRedelegate {
+		dstValAddr, err = sdk.ValAddressFromBech32(req.DstValidatorAddr)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := req.Coin.Validate(); err != nil || !req.Coin.IsPositive() {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid coin %s", req.Coin)
+	}
+
+	return k.Keeper.SimulateAllianceAction(ctx, req.Action, delAddr, valAddr, dstValAddr, req.Coin)
+}
+
+func (k QueryServer) DelegatorPortfolio(c context.Context, req *types.QueryDelegatorPortfolioRequest) (*types.QueryDelegatorPortfolioResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	ctx := sdk.UnwrapSDKContext(c)
+	delAddr, err := sdk.AccAddressFromBech32(req.DelegatorAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	return k.Keeper.GetDelegatorPortfolio(ctx, delAddr)
+}
+
+func (k QueryServer) ValidatorAllianceDelegations(c context.Context, req *types.QueryValidatorAllianceDelegationsRequest) (*types.QueryAlliancesDelegationsResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	ctx := sdk.UnwrapSDKContext(c)
+	decodedDenom, err := url.QueryUnescape(req.Denom)
+	if err == nil {
+		req.Denom = decodedDenom
+	}
+	valAddr, err := sdk.ValAddressFromBech32(req.ValidatorAddr)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("validator address %s invalid", req.ValidatorAddr))
+	}
+	validator, err := k.GetAllianceValidator(ctx, valAddr)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "Validator not found by address %s", req.ValidatorAddr)
+	}
+
+	indexKey := types.GetDelegationsByValidatorIndexKey(valAddr)
+	if req.Denom != "" {
+		indexKey = types.GetDelegationsByValidatorAndDenomIndexKey(valAddr, req.Denom)
+	}
+	indexStore := prefix.NewStore(ctx.KVStore(k.storeKey), indexKey)
+
+	var delegationsRes []types.DelegationResponse
+	pageRes, err := query.Paginate(indexStore, req.Pagination, func(key []byte, value []byte) error {
+		_, denom, delAddr := types.ParseDelegationByValidatorIndexKey(append(append([]byte{}, indexKey...), key...))
+		delegation, found := k.GetDelegation(ctx, delAddr, valAddr, denom)
+		if !found {
+			return status.Errorf(codes.Internal, "delegation of %s with %s not found", delAddr, denom)
+		}
+		asset, found := k.GetDelegationAsset(ctx, delegation.Denom)
+		if !found {
+			return types.ErrUnknownAsset
+		}
+		delegationsRes = append(delegationsRes, types.DelegationResponse{
+			Delegation: delegation,
+			Balance:    types.GetDelegationTokens(delegation, validator, asset),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.QueryAlliancesDelegationsResponse{
+		Delegations: delegationsRes,
+		Pagination:  pageRes,
+	}, nil
+}
+
 func NewQueryServerImpl(keeper Keeper) types.QueryServer {
 	return &QueryServer{Keeper: keeper}
 }
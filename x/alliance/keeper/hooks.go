@@ -3,6 +3,8 @@ package keeper
 import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+
+	"github.com/terra-money/alliance/x/alliance/types"
 )
 
 type Hooks struct {
@@ -19,7 +21,9 @@ func (h Hooks) BeforeValidatorModified(_ sdk.Context, _ sdk.ValAddress) error {
 	return nil
 }
 
-func (h Hooks) AfterValidatorRemoved(ctx sdk.Context, _ sdk.ConsAddress, _ sdk.ValAddress) error {
+func (h Hooks) AfterValidatorRemoved(ctx sdk.Context, _ sdk.ConsAddress, valAddr sdk.ValAddress) error {
+	h.k.deletePendingRebalance(ctx, valAddr)
+	h.k.deleteRebalanceTarget(ctx, valAddr)
 	h.k.QueueAssetRebalanceEvent(ctx)
 	return nil
 }
@@ -29,7 +33,10 @@ func (h Hooks) AfterValidatorBonded(ctx sdk.Context, _ sdk.ConsAddress, _ sdk.Va
 	return nil
 }
 
-func (h Hooks) AfterValidatorBeginUnbonding(ctx sdk.Context, _ sdk.ConsAddress, _ sdk.ValAddress) error {
+// AfterValidatorBeginUnbonding drops the pending rebalance of the validator since unbonded validators are not rebalanced
+func (h Hooks) AfterValidatorBeginUnbonding(ctx sdk.Context, _ sdk.ConsAddress, valAddr sdk.ValAddress) error {
+	h.k.deletePendingRebalance(ctx, valAddr)
+	h.k.deleteRebalanceTarget(ctx, valAddr)
 	h.k.QueueAssetRebalanceEvent(ctx)
 	return nil
 }
@@ -42,20 +49,35 @@ func (h Hooks) BeforeDelegationSharesModified(_ sdk.Context, _ sdk.AccAddress, _
 	return nil
 }
 
-func (h Hooks) BeforeDelegationRemoved(_ sdk.Context, _ sdk.AccAddress, _ sdk.ValAddress) error {
+func (h Hooks) BeforeDelegationRemoved(ctx sdk.Context, delAddr sdk.AccAddress, valAddr sdk.ValAddress) error {
+	h.queueNativeDelegationChange(ctx, delAddr, valAddr)
 	return nil
 }
 
-func (h Hooks) AfterDelegationModified(ctx sdk.Context, _ sdk.AccAddress, _ sdk.ValAddress) error {
-	h.k.QueueAssetRebalanceEvent(ctx)
+func (h Hooks) AfterDelegationModified(ctx sdk.Context, delAddr sdk.AccAddress, valAddr sdk.ValAddress) error {
+	h.queueNativeDelegationChange(ctx, delAddr, valAddr)
 	return nil
 }
 
+// queueNativeDelegationChange rebalances the validator so that the change in native bonded amount is compared against
+// the full rebalance threshold. Delegations made by the module itself during a rebalance are ignored.
+func (h Hooks) queueNativeDelegationChange(ctx sdk.Context, delAddr sdk.AccAddress, valAddr sdk.ValAddress) {
+	if delAddr.Equals(h.k.accountKeeper.GetModuleAddress(types.ModuleName)) {
+		return
+	}
+	h.k.QueueValidatorRebalanceEvent(ctx, valAddr)
+}
+
 func (h Hooks) BeforeValidatorSlashed(ctx sdk.Context, valAddr sdk.ValAddress, fraction sdk.Dec) error {
-	err := h.k.SlashValidator(ctx, valAddr, fraction)
+	// Slashing burns part of the module delegation, which no longer matches the target until the next rebalance
+	h.k.deleteRebalanceTarget(ctx, valAddr)
+	// Staking ignores the errors of the hook, so the slash is only written if it is applied completely
+	cacheCtx, writeCache := ctx.CacheContext()
+	err := h.k.SlashValidator(cacheCtx, valAddr, fraction)
 	if err != nil {
 		return err
 	}
+	writeCache()
 	h.k.QueueAssetRebalanceEvent(ctx)
 	return nil
 }
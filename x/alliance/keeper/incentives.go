@@ -0,0 +1,266 @@
+package keeper
+
+import (
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+
+	"github.com/terra-money/alliance/x/alliance/types"
+)
+
+// FundAllianceRewards deposits incentives for the delegators of an alliance asset, or only for the ones of a single
+// validator. The deposit is held by the reward incentives module account and streamed into the reward indices of the
+// asset by DistributeRewardIncentives from the start time until the end time. A zero start time starts right away.
+func (k Keeper) FundAllianceRewards(ctx sdk.Context, funder sdk.AccAddress, denom string, valAddr sdk.ValAddress, amount sdk.Coins, startTime time.Time, endTime time.Time) (types.RewardIncentive, error) {
+	if _, found := k.GetAssetByDenom(ctx, denom); !found {
+		return types.RewardIncentive{}, types.ErrUnknownAsset.Wrap(denom)
+	}
+	if valAddr != nil {
+		if _, found := k.stakingKeeper.GetValidator(ctx, valAddr); !found {
+			return types.RewardIncentive{}, types.ErrValidatorNotFound.Wrap(valAddr.String())
+		}
+	}
+	if startTime.IsZero() {
+		startTime = ctx.BlockTime()
+	}
+	if startTime.Before(ctx.BlockTime()) {
+		return types.RewardIncentive{}, types.ErrInvalidRewardIncentive.Wrapf("start time %s is before the block time %s", startTime, ctx.BlockTime())
+	}
+	if !endTime.After(startTime) {
+		return types.RewardIncentive{}, types.ErrInvalidRewardIncentive.Wrapf("end time %s must be after start time %s", endTime, startTime)
+	}
+	if err := k.bankKeeper.SendCoinsFromAccountToModule(ctx, funder, types.RewardIncentivesName, amount); err != nil {
+		return types.RewardIncentive{}, err
+	}
+
+	id := k.GetNextRewardIncentiveID(ctx)
+	k.SetNextRewardIncentiveID(ctx, id+1)
+	incentive := types.NewRewardIncentive(id, funder, denom, valAddr, amount, startTime, endTime)
+	k.SetRewardIncentive(ctx, incentive)
+
+	_ = ctx.EventManager().EmitTypedEvent(
+		&types.FundAllianceRewardsEvent{
+			Id:        incentive.Id,
+			Funder:    incentive.Funder,
+			Denom:     incentive.Denom,
+			Validator: incentive.ValidatorAddress,
+			Amount:    incentive.Amount,
+			StartTime: incentive.StartTime,
+			EndTime:   incentive.EndTime,
+		},
+	)
+	return incentive, nil
+}
+
+// RefundAllianceRewards stops the stream of an incentive and sends the unspent deposit back to the funder
+func (k Keeper) RefundAllianceRewards(ctx sdk.Context, funder sdk.AccAddress, id uint64) (sdk.Coins, error) {
+	incentive, found := k.GetRewardIncentive(ctx, id)
+	if !found {
+		return nil, types.ErrRewardIncentiveNotFound.Wrapf("%d", id)
+	}
+	if incentive.Funder != funder.String() {
+		return nil, sdkerrors.ErrUnauthorized.Wrapf("only %s can refund reward incentive %d", incentive.Funder, id)
+	}
+	unspent := incentive.Unspent()
+	k.DeleteRewardIncentive(ctx, id)
+	if !unspent.IsZero() {
+		if err := k.bankKeeper.SendCoinsFromModuleToAccount(ctx, types.RewardIncentivesName, funder, unspent); err != nil {
+			return nil, err
+		}
+	}
+
+	_ = ctx.EventManager().EmitTypedEvent(
+		&types.RefundAllianceRewardsEvent{
+			Id:     id,
+			Funder: incentive.Funder,
+			Coins:  unspent,
+		},
+	)
+	return unspent, nil
+}
+
+// DistributeRewardIncentives streams the part of each incentive deposit that is due since the last block into the
+// reward indices. Each incentive is distributed in isolation so that a failing one does not block the others.
+func (k Keeper) DistributeRewardIncentives(ctx sdk.Context) error {
+	var incentives []types.RewardIncentive
+	k.IterateRewardIncentives(ctx, func(incentive types.RewardIncentive) bool {
+		if !incentive.IsFinished() && ctx.BlockTime().After(incentive.LastDistributionTime) {
+			incentives = append(incentives, incentive)
+		}
+		return false
+	})
+
+	for _, incentive := range incentives {
+		valAddr, _ := sdk.ValAddressFromBech32(incentive.ValidatorAddress)
+		k.applyEndBlockerUnit(ctx, types.EndBlockerStepRewardIncentives, incentive.Denom, valAddr, func(ctx sdk.Context) error {
+			return k.distributeRewardIncentive(ctx, incentive)
+		})
+	}
+	return nil
+}
+
+func (k Keeper) distributeRewardIncentive(ctx sdk.Context, incentive types.RewardIncentive) error {
+	now := ctx.BlockTime()
+	if now.After(incentive.EndTime) {
+		now = incentive.EndTime
+	}
+	due := incentive.StreamedAt(now).Sub(incentive.StreamedAt(incentive.LastDistributionTime)...)
+	incentive.LastDistributionTime = now
+
+	distributed, err := k.distributeIncentiveCoins(ctx, incentive, due)
+	if err != nil {
+		return err
+	}
+	incentive.Distributed = incentive.Distributed.Add(distributed...)
+	if incentive.IsFinished() && incentive.Unspent().IsZero() {
+		k.DeleteRewardIncentive(ctx, incentive.Id)
+		return nil
+	}
+	k.SetRewardIncentive(ctx, incentive)
+	return nil
+}
+
+// distributeIncentiveCoins splits the coins between the targeted validators in proportion to the tokens of the asset
+// delegated to them and returns the coins that were distributed. Nothing is distributed while the asset has no
+// delegators or does not earn rewards yet, and the rounded off amounts are not distributed either.
+func (k Keeper) distributeIncentiveCoins(ctx sdk.Context, incentive types.RewardIncentive, coins sdk.Coins) (sdk.Coins, error) {
+	distributed := sdk.NewCoins()
+	asset, found := k.GetAssetByDenom(ctx, incentive.Denom)
+	if !found || !asset.RewardsStarted(ctx.BlockTime()) || coins.IsZero() {
+		return distributed, nil
+	}
+
+	var valAddrs []sdk.ValAddress
+	if incentive.ValidatorAddress != "" {
+		valAddr, err := sdk.ValAddressFromBech32(incentive.ValidatorAddress)
+		if err != nil {
+			return nil, err
+		}
+		valAddrs = append(valAddrs, valAddr)
+	} else {
+		k.IterateAllianceValidatorInfo(ctx, func(valAddr sdk.ValAddress, info types.AllianceValidatorInfo) bool {
+			if !sdk.DecCoins(info.TotalDelegatorShares).AmountOf(asset.Denom).IsZero() {
+				valAddrs = append(valAddrs, valAddr)
+			}
+			return false
+		})
+	}
+
+	var validators []types.AllianceValidator
+	var validatorTokens []sdk.Dec
+	totalTokens := sdk.ZeroDec()
+	for _, valAddr := range valAddrs {
+		// Validators that were removed from the staking module do not receive incentives anymore
+		if _, found := k.stakingKeeper.GetValidator(ctx, valAddr); !found {
+			continue
+		}
+		val, err := k.GetAllianceValidator(ctx, valAddr)
+		if err != nil {
+			return nil, err
+		}
+		tokens := val.TotalTokensWithAsset(asset).Add(val.BoostedTokensWithAsset(asset))
+		if !tokens.IsPositive() {
+			continue
+		}
+		validators = append(validators, val)
+		validatorTokens = append(validatorTokens, tokens)
+		totalTokens = totalTokens.Add(tokens)
+	}
+	if totalTokens.IsZero() {
+		return distributed, nil
+	}
+
+	for i, val := range validators {
+		share := sdk.NewCoins()
+		for _, coin := range coins {
+			amount := sdk.NewDecFromInt(coin.Amount).Mul(validatorTokens[i]).Quo(totalTokens).TruncateInt()
+			share = share.Add(sdk.NewCoin(coin.Denom, amount))
+		}
+		if share.IsZero() {
+			continue
+		}
+		k.addAllianceRewardsToPool(ctx, val, asset, share)
+		distributed = distributed.Add(share...)
+	}
+	if distributed.IsZero() {
+		return distributed, nil
+	}
+	if err := k.bankKeeper.SendCoinsFromModuleToModule(ctx, types.RewardIncentivesName, types.RewardsPoolName, distributed); err != nil {
+		return nil, err
+	}
+	return distributed, nil
+}
+
+func (k Keeper) GetRewardIncentive(ctx sdk.Context, id uint64) (incentive types.RewardIncentive, found bool) {
+	b := ctx.KVStore(k.storeKey).Get(types.GetRewardIncentiveKey(id))
+	if b == nil {
+		return incentive, false
+	}
+	k.cdc.MustUnmarshal(b, &incentive)
+	return incentive, true
+}
+
+func (k Keeper) SetRewardIncentive(ctx sdk.Context, incentive types.RewardIncentive) {
+	b := k.cdc.MustMarshal(&incentive)
+	ctx.KVStore(k.storeKey).Set(types.GetRewardIncentiveKey(incentive.Id), b)
+}
+
+func (k Keeper) DeleteRewardIncentive(ctx sdk.Context, id uint64) {
+	ctx.KVStore(k.storeKey).Delete(types.GetRewardIncentiveKey(id))
+}
+
+func (k Keeper) IterateRewardIncentives(ctx sdk.Context, cb func(incentive types.RewardIncentive) (stop bool)) {
+	iter := sdk.KVStorePrefixIterator(ctx.KVStore(k.storeKey), types.RewardIncentiveKey)
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		var incentive types.RewardIncentive
+		k.cdc.MustUnmarshal(iter.Value(), &incentive)
+		if cb(incentive) {
+			break
+		}
+	}
+}
+
+// GetNextRewardIncentiveID returns the id of the next incentive. Ids start at 1.
+func (k Keeper) GetNextRewardIncentiveID(ctx sdk.Context) uint64 {
+	b := ctx.KVStore(k.storeKey).Get(types.NextRewardIncentiveIDKey)
+	if b == nil {
+		return 1
+	}
+	return sdk.BigEndianToUint64(b)
+}
+
+func (k Keeper) SetNextRewardIncentiveID(ctx sdk.Context, id uint64) {
+	ctx.KVStore(k.storeKey).Set(types.NextRewardIncentiveIDKey, sdk.Uint64ToBigEndian(id))
+}
+
+// GetRewardIncentivesBalance returns the balance of the reward incentives module account
+func (k Keeper) GetRewardIncentivesBalance(ctx sdk.Context) sdk.Coins {
+	return k.bankKeeper.GetAllBalances(ctx, k.accountKeeper.GetModuleAddress(types.RewardIncentivesName))
+}
+
+// TotalUnspentRewardIncentives sums up the unspent deposits of all incentives
+func (k Keeper) TotalUnspentRewardIncentives(ctx sdk.Context) sdk.Coins {
+	total := sdk.NewCoins()
+	k.IterateRewardIncentives(ctx, func(incentive types.RewardIncentive) bool {
+		total = total.Add(incentive.Unspent()...)
+		return false
+	})
+	return total
+}
+
+// migrateRewardIncentives moves the incentives of a migrated asset to the new denom
+func (k Keeper) migrateRewardIncentives(ctx sdk.Context, fromDenom string, toDenom string) {
+	var incentives []types.RewardIncentive
+	k.IterateRewardIncentives(ctx, func(incentive types.RewardIncentive) bool {
+		if incentive.Denom == fromDenom {
+			incentives = append(incentives, incentive)
+		}
+		return false
+	})
+	for _, incentive := range incentives {
+		incentive.Denom = toDenom
+		k.SetRewardIncentive(ctx, incentive)
+	}
+}
@@ -0,0 +1,254 @@
+package keeper
+
+import (
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/terra-money/alliance/x/alliance/types"
+)
+
+// DelegateWithLock delegates and locks the new delegation shares until the lock duration has passed. The lock duration
+// has to be one of the durations set by governance and the locked shares earn rewards and voting power as if they
+// were reward_multiplier times larger. Locks of the same delegation that end at the same time are merged.
+func (k Keeper) DelegateWithLock(ctx sdk.Context, delAddr sdk.AccAddress, validator types.AllianceValidator, coin sdk.Coin, lockDuration time.Duration) (*types.DelegationLock, error) {
+	entry, found := types.FindLockDuration(k.LockDurations(ctx), lockDuration)
+	if !found {
+		return nil, types.ErrInvalidLockDuration.Wrapf("%s", lockDuration)
+	}
+
+	valAddr := validator.GetOperator()
+	sharesBefore := sdk.ZeroDec()
+	if delegation, found := k.GetDelegation(ctx, delAddr, valAddr, coin.Denom); found {
+		sharesBefore = delegation.Shares
+	}
+	// Delegate claims the rewards of an existing delegation, so the boost below does not change past rewards
+	if _, err := k.Delegate(ctx, delAddr, validator, coin); err != nil {
+		return nil, err
+	}
+	delegation, _ := k.GetDelegation(ctx, delAddr, valAddr, coin.Denom)
+	lockedShares := delegation.Shares.Sub(sharesBefore)
+	boostShares := lockedShares.Mul(entry.RewardMultiplier.Sub(sdk.OneDec()))
+	endTime := ctx.BlockTime().Add(lockDuration)
+
+	lock, found := k.GetDelegationLock(ctx, delAddr, valAddr, coin.Denom, endTime)
+	if found {
+		lock.Shares = lock.Shares.Add(lockedShares)
+		lock.BoostShares = lock.BoostShares.Add(boostShares)
+	} else {
+		lock = types.DelegationLock{
+			DelegatorAddress: delAddr.String(),
+			ValidatorAddress: valAddr.String(),
+			Denom:            coin.Denom,
+			Shares:           lockedShares,
+			BoostShares:      boostShares,
+			EndTime:          endTime,
+		}
+	}
+	k.SetDelegationLock(ctx, delAddr, valAddr, lock)
+
+	// The validator is queried again since it was updated by Delegate
+	validator, err := k.GetAllianceValidator(ctx, valAddr)
+	if err != nil {
+		return nil, err
+	}
+	validator.AddBoostedShares(sdk.NewDecCoins(sdk.NewDecCoinFromDec(coin.Denom, boostShares)))
+	k.SetValidator(ctx, validator)
+
+	_ = ctx.EventManager().EmitTypedEvent(
+		&types.LockAllianceDelegationEvent{
+			AllianceSender: delAddr.String(),
+			Validator:      valAddr.String(),
+			Denom:          coin.Denom,
+			Shares:         lockedShares,
+			BoostShares:    boostShares,
+			EndTime:        endTime,
+		},
+	)
+	return &lock, nil
+}
+
+// CompleteDelegationLocks removes the locks that ended and the boost they earned. Each lock is completed in isolation
+// so that a failing one stays in the queue and quarantines its asset without blocking the others.
+func (k Keeper) CompleteDelegationLocks(ctx sdk.Context) error {
+	store := ctx.KVStore(k.storeKey)
+	iter := store.Iterator(types.DelegationLockQueueKey, sdk.PrefixEndBytes(types.GetDelegationLockQueueKeyByTime(ctx.BlockTime())))
+	var keys [][]byte
+	for ; iter.Valid(); iter.Next() {
+		keys = append(keys, iter.Key())
+	}
+	iter.Close()
+
+	for _, key := range keys {
+		endTime, delAddr, valAddr, denom, err := types.ParseDelegationLockQueueKey(key)
+		if err != nil {
+			return err
+		}
+		k.applyEndBlockerUnit(ctx, types.EndBlockerStepDelegationLocks, denom, valAddr, func(ctx sdk.Context) error {
+			return k.completeDelegationLock(ctx, delAddr, valAddr, denom, endTime)
+		})
+	}
+	return nil
+}
+
+func (k Keeper) completeDelegationLock(ctx sdk.Context, delAddr sdk.AccAddress, valAddr sdk.ValAddress, denom string, endTime time.Time) error {
+	queueKey := types.GetDelegationLockQueueKey(endTime, delAddr, valAddr, denom)
+	// Locks of a migrated asset move to the new denom together with their delegation
+	if m, found := k.GetAssetMigration(ctx); found && m.FromDenom == denom {
+		k.moveMigratedDelegation(ctx, delAddr, valAddr, m.ToDenom)
+		denom = m.ToDenom
+	}
+	lock, found := k.GetDelegationLock(ctx, delAddr, valAddr, denom, endTime)
+	if !found {
+		ctx.KVStore(k.storeKey).Delete(queueKey)
+		return nil
+	}
+	validator, err := k.GetAllianceValidator(ctx, valAddr)
+	if err != nil {
+		return err
+	}
+
+	// The boost counts until the lock ends, so the rewards are claimed before it is removed
+	if _, found := k.GetDelegation(ctx, delAddr, valAddr, denom); found {
+		if _, err := k.ClaimDelegationRewards(ctx, delAddr, validator, denom); err != nil {
+			return err
+		}
+		// The validator is queried again since claiming rewards updates its reward history
+		validator, err = k.GetAllianceValidator(ctx, valAddr)
+		if err != nil {
+			return err
+		}
+	}
+
+	validator.ReduceBoostedShares(sdk.NewDecCoins(sdk.NewDecCoinFromDec(lock.Denom, lock.BoostShares)))
+	k.SetValidator(ctx, validator)
+	k.DeleteDelegationLock(ctx, delAddr, valAddr, lock)
+	k.QueueValidatorRebalanceEvent(ctx, valAddr)
+
+	_ = ctx.EventManager().EmitTypedEvent(
+		&types.UnlockAllianceDelegationEvent{
+			AllianceSender: lock.DelegatorAddress,
+			Validator:      lock.ValidatorAddress,
+			Denom:          lock.Denom,
+			Shares:         lock.Shares,
+		},
+	)
+	return nil
+}
+
+// checkDelegationUnlocked makes sure that removing shares from a delegation leaves at least its locked shares
+func (k Keeper) checkDelegationUnlocked(ctx sdk.Context, delAddr sdk.AccAddress, valAddr sdk.ValAddress, delegation types.Delegation, sharesToRemove sdk.Dec) error {
+	lockedShares, _ := k.GetLockedShares(ctx, delAddr, valAddr, delegation.Denom)
+	if lockedShares.IsZero() {
+		return nil
+	}
+	// A small margin is allowed for the rounding of the token to share conversion
+	if delegation.Shares.Sub(sharesToRemove).Add(sdk.NewDecWithPrec(1, 6)).LT(lockedShares) {
+		return types.ErrDelegationLocked.Wrapf("%s of %s shares are locked", lockedShares, delegation.Shares)
+	}
+	return nil
+}
+
+// slashDelegationLocks scales the locks of a delegation and their boost by the share of the delegation that is left
+// after slashing, so that a delegation is never locked or boosted for more shares than it holds. The rewards of the
+// delegation have to be claimed before.
+func (k Keeper) slashDelegationLocks(ctx sdk.Context, delAddr sdk.AccAddress, valAddr sdk.ValAddress, denom string, remaining sdk.Dec) error {
+	locks := k.GetDelegationLocks(ctx, delAddr, valAddr, denom)
+	if len(locks) == 0 {
+		return nil
+	}
+	slashedBoost := sdk.ZeroDec()
+	for _, lock := range locks {
+		boostShares := lock.BoostShares.Mul(remaining)
+		slashedBoost = slashedBoost.Add(lock.BoostShares.Sub(boostShares))
+		lock.Shares = lock.Shares.Mul(remaining)
+		lock.BoostShares = boostShares
+		k.SetDelegationLock(ctx, delAddr, valAddr, lock)
+	}
+	validator, err := k.GetAllianceValidator(ctx, valAddr)
+	if err != nil {
+		return err
+	}
+	validator.ReduceBoostedShares(sdk.NewDecCoins(sdk.NewDecCoinFromDec(denom, slashedBoost)))
+	k.SetValidator(ctx, validator)
+	k.QueueValidatorRebalanceEvent(ctx, valAddr)
+	return nil
+}
+
+// GetLockedShares sums up the locked shares and the boost shares of all locks of a delegation
+func (k Keeper) GetLockedShares(ctx sdk.Context, delAddr sdk.AccAddress, valAddr sdk.ValAddress, denom string) (shares sdk.Dec, boostShares sdk.Dec) {
+	shares, boostShares = sdk.ZeroDec(), sdk.ZeroDec()
+	for _, lock := range k.GetDelegationLocks(ctx, delAddr, valAddr, denom) {
+		shares = shares.Add(lock.Shares)
+		boostShares = boostShares.Add(lock.BoostShares)
+	}
+	return shares, boostShares
+}
+
+// GetDelegationBoostShares returns the extra shares that a delegation earns rewards with because of its locks
+func (k Keeper) GetDelegationBoostShares(ctx sdk.Context, delegation types.Delegation) sdk.Dec {
+	delAddr, err := sdk.AccAddressFromBech32(delegation.DelegatorAddress)
+	if err != nil {
+		return sdk.ZeroDec()
+	}
+	valAddr, err := sdk.ValAddressFromBech32(delegation.ValidatorAddress)
+	if err != nil {
+		return sdk.ZeroDec()
+	}
+	_, boostShares := k.GetLockedShares(ctx, delAddr, valAddr, delegation.Denom)
+	return boostShares
+}
+
+func (k Keeper) GetDelegationLock(ctx sdk.Context, delAddr sdk.AccAddress, valAddr sdk.ValAddress, denom string, endTime time.Time) (lock types.DelegationLock, found bool) {
+	b := ctx.KVStore(k.storeKey).Get(types.GetDelegationLockKey(delAddr, valAddr, denom, endTime))
+	if b == nil {
+		return lock, false
+	}
+	k.cdc.MustUnmarshal(b, &lock)
+	return lock, true
+}
+
+func (k Keeper) GetDelegationLocks(ctx sdk.Context, delAddr sdk.AccAddress, valAddr sdk.ValAddress, denom string) (locks []types.DelegationLock) {
+	iter := sdk.KVStorePrefixIterator(ctx.KVStore(k.storeKey), types.GetDelegationLocksKeyForDelegation(delAddr, valAddr, denom))
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		var lock types.DelegationLock
+		k.cdc.MustUnmarshal(iter.Value(), &lock)
+		locks = append(locks, lock)
+	}
+	return locks
+}
+
+// SetDelegationLock stores a lock and queues it to be completed at its end time
+func (k Keeper) SetDelegationLock(ctx sdk.Context, delAddr sdk.AccAddress, valAddr sdk.ValAddress, lock types.DelegationLock) {
+	store := ctx.KVStore(k.storeKey)
+	store.Set(types.GetDelegationLockKey(delAddr, valAddr, lock.Denom, lock.EndTime), k.cdc.MustMarshal(&lock))
+	store.Set(types.GetDelegationLockQueueKey(lock.EndTime, delAddr, valAddr, lock.Denom), []byte{})
+}
+
+func (k Keeper) DeleteDelegationLock(ctx sdk.Context, delAddr sdk.AccAddress, valAddr sdk.ValAddress, lock types.DelegationLock) {
+	store := ctx.KVStore(k.storeKey)
+	store.Delete(types.GetDelegationLockKey(delAddr, valAddr, lock.Denom, lock.EndTime))
+	store.Delete(types.GetDelegationLockQueueKey(lock.EndTime, delAddr, valAddr, lock.Denom))
+}
+
+func (k Keeper) IterateDelegationLocks(ctx sdk.Context, cb func(lock types.DelegationLock) (stop bool)) {
+	iter := sdk.KVStorePrefixIterator(ctx.KVStore(k.storeKey), types.DelegationLockKey)
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		var lock types.DelegationLock
+		k.cdc.MustUnmarshal(iter.Value(), &lock)
+		if cb(lock) {
+			break
+		}
+	}
+}
+
+// moveDelegationLocks renames the locks of a delegation whose asset is migrated to the new denom
+func (k Keeper) moveDelegationLocks(ctx sdk.Context, m types.AssetMigration, delAddr sdk.AccAddress, valAddr sdk.ValAddress) {
+	for _, lock := range k.GetDelegationLocks(ctx, delAddr, valAddr, m.FromDenom) {
+		k.DeleteDelegationLock(ctx, delAddr, valAddr, lock)
+		lock.Denom = m.ToDenom
+		k.SetDelegationLock(ctx, delAddr, valAddr, lock)
+	}
+}
@@ -0,0 +1,400 @@
+package keeper
+
+import (
+	"time"
+
+	"cosmossdk.io/math"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/terra-money/alliance/x/alliance/types"
+)
+
+// GetAssetMigration returns the asset migration whose delegations are still being moved to the new denom
+func (k Keeper) GetAssetMigration(ctx sdk.Context) (m types.AssetMigration, found bool) {
+	b := ctx.KVStore(k.storeKey).Get(types.AssetMigrationKey)
+	if b == nil {
+		return m, false
+	}
+	k.cdc.MustUnmarshal(b, &m)
+	return m, true
+}
+
+func (k Keeper) setAssetMigration(ctx sdk.Context, m types.AssetMigration) {
+	b := k.cdc.MustMarshal(&m)
+	ctx.KVStore(k.storeKey).Set(types.AssetMigrationKey, b)
+}
+
+// GetDelegationAsset returns the asset of a delegation denom. Delegations that were not moved yet by an asset
+// migration still use the old denom.
+func (k Keeper) GetDelegationAsset(ctx sdk.Context, denom string) (types.AllianceAsset, bool) {
+	if m, found := k.GetAssetMigration(ctx); found && m.FromDenom == denom {
+		denom = m.ToDenom
+	}
+	return k.GetAssetByDenom(ctx, denom)
+}
+
+// checkAssetNotMigrating rejects changes to the delegations of an asset while they are being moved to the new denom
+func (k Keeper) checkAssetNotMigrating(ctx sdk.Context, denom string) error {
+	m, found := k.GetAssetMigration(ctx)
+	if found && (m.FromDenom == denom || m.ToDenom == denom) {
+		return types.ErrAssetMigrationInProgress.Wrapf("%s is being migrated to %s", m.FromDenom, m.ToDenom)
+	}
+	return nil
+}
+
+// StartAssetMigration converts an alliance asset to a new denom at a fixed conversion rate without unbonding.
+// The asset, the validator shares, the reward weight snapshots, the reward incentives and the queued undelegations and
+// redelegations are converted right away and the tokens held by the alliance module are swapped through the migration escrow, which
+// has to be funded with the to_denom tokens beforehand. The swapped out tokens and the unused escrow tokens are sent
+// to the escrow recipient. Delegations are moved to the new denom over the next blocks by MigrateAssetDelegations.
+//
+// Delegation shares do not change, so every delegation is worth conversion_rate times more tokens of the new denom.
+// Rewards are split and claimed by the delegated tokens times the reward index weight, which is the reward weight
+// divided by the conversion rate of the asset. The conversion rate of the asset is multiplied by the rate of the
+// migration, so the rewards of the asset and of the other assets of a validator do not change. The reward weights of
+// the snapshots before the migration are divided by the rate as well. The reward indices that are only shared by the
+// delegators of the asset are rewards per token, so they are divided by the rate too.
+//
+// The reward weight and its range are kept, because they also set the share of the native stake that is bonded for the
+// asset and that share must not depend on the unit of the asset. The target tokens of a reward weight curve are
+// converted since they are an amount of the asset.
+func (k Keeper) StartAssetMigration(ctx sdk.Context, fromDenom string, toDenom string, rate sdk.Dec, escrowRecipient sdk.AccAddress) error {
+	if m, found := k.GetAssetMigration(ctx); found {
+		return types.ErrAssetMigrationInProgress.Wrapf("%s is being migrated to %s", m.FromDenom, m.ToDenom)
+	}
+	asset, found := k.GetAssetByDenom(ctx, fromDenom)
+	if !found {
+		return status.Errorf(codes.NotFound, "Asset with denom: %s does not exist", fromDenom)
+	}
+	if _, found := k.GetAssetByDenom(ctx, toDenom); found {
+		return status.Errorf(codes.AlreadyExists, "Asset with denom: %s already exists", toDenom)
+	}
+	if err := k.checkAssetNotInGroup(ctx, fromDenom); err != nil {
+		return err
+	}
+	convert := func(amount math.Int) math.Int {
+		return sdk.NewDecFromInt(amount).Mul(rate).TruncateInt()
+	}
+
+	unbondingFrom, unbondingTo := k.migrateUndelegations(ctx, fromDenom, toDenom, convert)
+	k.migrateRedelegations(ctx, fromDenom, toDenom, convert)
+	totalTokens := convert(asset.TotalTokens)
+	swappedOut := sdk.NewCoin(fromDenom, asset.TotalTokens.Add(unbondingFrom))
+	swappedIn := sdk.NewCoin(toDenom, totalTokens.Add(unbondingTo))
+	if err := k.swapMigratedTokens(ctx, swappedOut, swappedIn, escrowRecipient); err != nil {
+		return err
+	}
+
+	k.migrateRewardWeightChangeSnapshots(ctx, fromDenom, toDenom, rate)
+	conversionRate := asset.GetConversionRate().Mul(rate)
+	asset.ConversionRate = &conversionRate
+	asset.Denom = toDenom
+	asset.TotalTokens = totalTokens
+	if asset.RewardWeightCurve != nil {
+		curve := *asset.RewardWeightCurve
+		curve.TargetTokens = convert(curve.TargetTokens)
+		asset.RewardWeightCurve = &curve
+	}
+	k.IterateAllianceValidatorInfo(ctx, func(valAddr sdk.ValAddress, info types.AllianceValidatorInfo) bool {
+		if sdk.DecCoins(info.TotalDelegatorShares).AmountOf(fromDenom).IsZero() && sdk.DecCoins(info.ValidatorShares).AmountOf(fromDenom).IsZero() {
+			return false
+		}
+		info.TotalDelegatorShares = renameDecCoins(info.TotalDelegatorShares, fromDenom, toDenom)
+		info.ValidatorShares = renameDecCoins(info.ValidatorShares, fromDenom, toDenom)
+		info.BoostedDelegatorShares = renameDecCoins(info.BoostedDelegatorShares, fromDenom, toDenom)
+		info.GlobalRewardHistory = migrateRewardHistories(info.GlobalRewardHistory, fromDenom, toDenom, rate)
+		k.SetValidatorInfo(ctx, valAddr, info)
+		// Closes the period before the migration. Assets that are not initialized yet get a zero weight snapshot
+		// for that period when they are initialized.
+		if asset.IsInitialized && !ctx.KVStore(k.storeKey).Has(types.GetRewardWeightChangeSnapshotKey(toDenom, valAddr, uint64(ctx.BlockHeight()))) {
+			snapshot := types.NewRewardWeightChangeSnapshot(k.RewardIndexWeight(ctx, asset), types.AllianceValidator{AllianceValidatorInfo: &info}, asset)
+			k.setRewardWeightChangeSnapshot(ctx, toDenom, valAddr, uint64(ctx.BlockHeight()), snapshot)
+		}
+		return false
+	})
+
+	k.migrateRewardIncentives(ctx, fromDenom, toDenom)
+
+	if lastRate, found := k.getLastRebalanceRate(ctx, fromDenom); found {
+		ctx.KVStore(k.storeKey).Delete(types.GetLastRebalanceRateKey(fromDenom))
+		k.setLastRebalanceRate(ctx, toDenom, lastRate)
+	}
+	k.deleteAsset(ctx, fromDenom)
+	k.SetAsset(ctx, asset)
+
+	k.setAssetMigration(ctx, types.AssetMigration{
+		FromDenom:      fromDenom,
+		ToDenom:        toDenom,
+		ConversionRate: rate,
+	})
+	_ = ctx.EventManager().EmitTypedEvent(
+		&types.AssetMigrationStartedEvent{
+			FromDenom:      fromDenom,
+			ToDenom:        toDenom,
+			ConversionRate: rate,
+			SwappedOut:     swappedOut,
+			SwappedIn:      swappedIn,
+		},
+	)
+	return nil
+}
+
+// MigrateAssetDelegations moves the delegations and reward forwardings of a migrated asset to the new denom. At most
+// limit delegations are checked per call, a limit of zero moves all of them. The migration is completed once all
+// delegations were checked.
+func (k Keeper) MigrateAssetDelegations(ctx sdk.Context, limit int) error {
+	m, found := k.GetAssetMigration(ctx)
+	if !found {
+		return nil
+	}
+	store := ctx.KVStore(k.storeKey)
+	start := m.NextKey
+	if len(start) == 0 {
+		start = types.DelegationKey
+	}
+	iter := store.Iterator(start, sdk.PrefixEndBytes(types.DelegationKey))
+	var delegations []types.Delegation
+	checked := 0
+	m.NextKey = nil
+	for ; iter.Valid(); iter.Next() {
+		if limit > 0 && checked == limit {
+			m.NextKey = append([]byte{}, iter.Key()...)
+			break
+		}
+		checked++
+		var delegation types.Delegation
+		k.cdc.MustUnmarshal(iter.Value(), &delegation)
+		if delegation.Denom == m.FromDenom {
+			delegations = append(delegations, delegation)
+		}
+	}
+	iter.Close()
+
+	for _, delegation := range delegations {
+		delAddr, err := sdk.AccAddressFromBech32(delegation.DelegatorAddress)
+		if err != nil {
+			return err
+		}
+		valAddr, err := sdk.ValAddressFromBech32(delegation.ValidatorAddress)
+		if err != nil {
+			return err
+		}
+		k.moveDelegation(ctx, m, delAddr, valAddr, delegation)
+	}
+	m.MigratedDelegations += uint64(len(delegations))
+
+	if m.NextKey != nil {
+		k.setAssetMigration(ctx, m)
+		return nil
+	}
+	store.Delete(types.AssetMigrationKey)
+	_ = ctx.EventManager().EmitTypedEvent(
+		&types.AssetMigrationCompletedEvent{
+			FromDenom:           m.FromDenom,
+			ToDenom:             m.ToDenom,
+			MigratedDelegations: m.MigratedDelegations,
+		},
+	)
+	return nil
+}
+
+// moveMigratedDelegation moves a single delegation of a migrated asset to the new denom ahead of the batches. It is
+// used where the rewards of a delegation are claimed during the migration, like when slashing redelegations.
+func (k Keeper) moveMigratedDelegation(ctx sdk.Context, delAddr sdk.AccAddress, valAddr sdk.ValAddress, denom string) {
+	m, found := k.GetAssetMigration(ctx)
+	if !found || m.ToDenom != denom {
+		return
+	}
+	delegation, found := k.GetDelegation(ctx, delAddr, valAddr, m.FromDenom)
+	if !found {
+		return
+	}
+	k.moveDelegation(ctx, m, delAddr, valAddr, delegation)
+	m.MigratedDelegations++
+	k.setAssetMigration(ctx, m)
+}
+
+func (k Keeper) moveDelegation(ctx sdk.Context, m types.AssetMigration, delAddr sdk.AccAddress, valAddr sdk.ValAddress, delegation types.Delegation) {
+	k.DeleteDelegation(ctx, delAddr, valAddr, m.FromDenom)
+	delegation.Denom = m.ToDenom
+	delegation.RewardHistory = migrateRewardHistories(delegation.RewardHistory, m.FromDenom, m.ToDenom, m.ConversionRate)
+	k.SetDelegation(ctx, delAddr, valAddr, m.ToDenom, delegation)
+	if forwarding, found := k.GetRewardForwarding(ctx, delAddr, valAddr, m.FromDenom); found {
+		k.DeleteRewardForwarding(ctx, delAddr, valAddr, m.FromDenom)
+		forwarding.Denom = m.ToDenom
+		k.SetRewardForwarding(ctx, delAddr, valAddr, m.ToDenom, forwarding)
+	}
+	k.moveDelegationLocks(ctx, m, delAddr, valAddr)
+}
+
+// swapMigratedTokens swaps the tokens held by the alliance module for the migrated asset through the migration escrow
+func (k Keeper) swapMigratedTokens(ctx sdk.Context, swappedOut sdk.Coin, swappedIn sdk.Coin, escrowRecipient sdk.AccAddress) error {
+	// creates the module account on chains that did not have it yet
+	escrowAddr := k.accountKeeper.GetModuleAccount(ctx, types.AssetMigrationEscrowName).GetAddress()
+	escrowBalance := k.bankKeeper.GetBalance(ctx, escrowAddr, swappedIn.Denom)
+	if escrowBalance.IsLT(swappedIn) {
+		return types.ErrInsufficientMigrationEscrow.Wrapf("wanted %s but have %s", swappedIn, escrowBalance)
+	}
+	err := k.bankKeeper.SendCoinsFromModuleToModule(ctx, types.ModuleName, types.AssetMigrationEscrowName, sdk.NewCoins(swappedOut))
+	if err != nil {
+		return err
+	}
+	err = k.bankKeeper.SendCoinsFromModuleToModule(ctx, types.AssetMigrationEscrowName, types.ModuleName, sdk.NewCoins(swappedIn))
+	if err != nil {
+		return err
+	}
+	refund := sdk.NewCoins(swappedOut, escrowBalance.Sub(swappedIn))
+	if refund.IsZero() {
+		return nil
+	}
+	return k.bankKeeper.SendCoinsFromModuleToAccount(ctx, types.AssetMigrationEscrowName, escrowRecipient, refund)
+}
+
+// migrateUndelegations converts the queued undelegations of the migrated asset and returns the unbonding amounts
+// before and after the conversion
+func (k Keeper) migrateUndelegations(ctx sdk.Context, fromDenom string, toDenom string, convert func(math.Int) math.Int) (fromAmount math.Int, toAmount math.Int) {
+	fromAmount, toAmount = sdk.ZeroInt(), sdk.ZeroInt()
+	store := ctx.KVStore(k.storeKey)
+	iter := sdk.KVStorePrefixIterator(store, types.UndelegationQueueKey)
+	var keys [][]byte
+	var queues []types.QueuedUndelegation
+	for ; iter.Valid(); iter.Next() {
+		var queued types.QueuedUndelegation
+		k.cdc.MustUnmarshal(iter.Value(), &queued)
+		keys = append(keys, iter.Key())
+		queues = append(queues, queued)
+	}
+	iter.Close()
+
+	for i, key := range keys {
+		completionTime, err := types.ParseUndelegationQueueKeyForCompletionTime(key)
+		if err != nil {
+			continue
+		}
+		changed := false
+		for _, undel := range queues[i].Entries {
+			if undel.Balance.Denom != fromDenom {
+				continue
+			}
+			delAddr := sdk.MustAccAddressFromBech32(undel.DelegatorAddress)
+			valAddr, err := sdk.ValAddressFromBech32(undel.ValidatorAddress)
+			if err != nil {
+				continue
+			}
+			converted := convert(undel.Balance.Amount)
+			fromAmount = fromAmount.Add(undel.Balance.Amount)
+			toAmount = toAmount.Add(converted)
+			undel.Balance = sdk.NewCoin(toDenom, converted)
+			store.Delete(types.GetUnbondingIndexKey(valAddr, completionTime, fromDenom, delAddr))
+			k.setUnbondingIndexByVal(ctx, valAddr, completionTime, delAddr, toDenom)
+			changed = true
+		}
+		if changed {
+			store.Set(key, k.cdc.MustMarshal(&queues[i]))
+		}
+	}
+	return fromAmount, toAmount
+}
+
+// migrateRedelegations converts the redelegations of the migrated asset, which are kept to slash redelegated tokens
+// and to prevent transitive redelegations
+func (k Keeper) migrateRedelegations(ctx sdk.Context, fromDenom string, toDenom string, convert func(math.Int) math.Int) {
+	store := ctx.KVStore(k.storeKey)
+	var redelegations []types.Redelegation
+	var completionTimes []time.Time
+	k.IterateRedelegations(ctx, func(redelegation types.Redelegation, completionTime time.Time) bool {
+		if redelegation.Balance.Denom == fromDenom {
+			redelegations = append(redelegations, redelegation)
+			completionTimes = append(completionTimes, completionTime)
+		}
+		return false
+	})
+	for i, redel := range redelegations {
+		k.DeleteRedelegation(ctx, redel, completionTimes[i])
+		delAddr := sdk.MustAccAddressFromBech32(redel.DelegatorAddress)
+		srcValAddr, _ := sdk.ValAddressFromBech32(redel.SrcValidatorAddress)
+		dstValAddr, _ := sdk.ValAddressFromBech32(redel.DstValidatorAddress)
+		redel.Balance = sdk.NewCoin(toDenom, convert(redel.Balance.Amount))
+		store.Set(types.GetRedelegationKey(delAddr, toDenom, dstValAddr, completionTimes[i]), k.cdc.MustMarshal(&redel))
+		store.Set(types.GetRedelegationIndexKey(srcValAddr, completionTimes[i], toDenom, dstValAddr, delAddr), []byte{})
+	}
+
+	iter := sdk.KVStorePrefixIterator(store, types.RedelegationQueueKey)
+	var keys [][]byte
+	var queues []types.QueuedRedelegation
+	for ; iter.Valid(); iter.Next() {
+		var queued types.QueuedRedelegation
+		k.cdc.MustUnmarshal(iter.Value(), &queued)
+		keys = append(keys, iter.Key())
+		queues = append(queues, queued)
+	}
+	iter.Close()
+	for i, key := range keys {
+		changed := false
+		for _, redel := range queues[i].Entries {
+			if redel.Balance.Denom == fromDenom {
+				redel.Balance = sdk.NewCoin(toDenom, convert(redel.Balance.Amount))
+				changed = true
+			}
+		}
+		if changed {
+			store.Set(key, k.cdc.MustMarshal(&queues[i]))
+		}
+	}
+}
+
+// migrateRewardWeightChangeSnapshots moves the snapshots of the migrated asset to the new denom. Their reward weights
+// are divided by the conversion rate because the rewards are calculated with the converted delegation tokens.
+func (k Keeper) migrateRewardWeightChangeSnapshots(ctx sdk.Context, fromDenom string, toDenom string, rate sdk.Dec) {
+	type snapshotEntry struct {
+		valAddr  sdk.ValAddress
+		height   uint64
+		snapshot types.RewardWeightChangeSnapshot
+	}
+	var snapshots []snapshotEntry
+	k.IterateAllWeightChangeSnapshot(ctx, func(denom string, valAddr sdk.ValAddress, height uint64, snapshot types.RewardWeightChangeSnapshot) bool {
+		if denom == fromDenom {
+			snapshots = append(snapshots, snapshotEntry{valAddr: valAddr, height: height, snapshot: snapshot})
+		}
+		return false
+	})
+	store := ctx.KVStore(k.storeKey)
+	for _, entry := range snapshots {
+		store.Delete(types.GetRewardWeightChangeSnapshotKey(fromDenom, entry.valAddr, entry.height))
+		entry.snapshot.PrevRewardWeight = entry.snapshot.PrevRewardWeight.Quo(rate)
+		entry.snapshot.RewardHistories = migrateRewardHistories(entry.snapshot.RewardHistories, fromDenom, toDenom, rate)
+		if entry.snapshot.TotalTokens != nil && !entry.snapshot.TotalTokens.IsNil() {
+			totalTokens := entry.snapshot.TotalTokens.Mul(rate)
+			entry.snapshot.TotalTokens = &totalTokens
+		}
+		k.setRewardWeightChangeSnapshot(ctx, toDenom, entry.valAddr, entry.height, entry.snapshot)
+	}
+}
+
+// renameDecCoins replaces the denom of a coin and keeps the coins sorted
+func renameDecCoins(coins sdk.DecCoins, fromDenom string, toDenom string) sdk.DecCoins {
+	renamed := sdk.NewDecCoins()
+	for _, coin := range coins {
+		if coin.Denom == fromDenom {
+			coin.Denom = toDenom
+		}
+		renamed = renamed.Add(coin)
+	}
+	return renamed
+}
+
+// migrateRewardHistories moves the reward indices that are only shared by the delegators of the migrated asset to the
+// new denom. These indices are rewards per token of the asset, so they are divided by the conversion rate.
+func migrateRewardHistories(histories types.RewardHistories, fromDenom string, toDenom string, rate sdk.Dec) types.RewardHistories {
+	migrated := make(types.RewardHistories, 0, len(histories))
+	for _, history := range histories {
+		if history.Alliance == fromDenom {
+			history.Alliance = toDenom
+			history.Index = history.Index.Quo(rate)
+		}
+		migrated = append(migrated, history)
+	}
+	return migrated
+}
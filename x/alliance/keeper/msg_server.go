@@ -137,6 +137,104 @@ func (m MsgServer) ClaimDelegationRewards(ctx context.Context, msg *types.MsgCla
 	return &types.MsgClaimDelegationRewardsResponse{}, err
 }
 
+func (m MsgServer) SetRewardForwarding(ctx context.Context, msg *types.MsgSetRewardForwarding) (*types.MsgSetRewardForwardingResponse, error) {
+	err := msg.ValidateBasic()
+	if err != nil {
+		return nil, err
+	}
+
+	sdkCtx := sdk.UnwrapSDKContext(ctx)
+	err = m.Keeper.SetDelegationRewardForwarding(sdkCtx, msg.RewardForwarding())
+
+	return &types.MsgSetRewardForwardingResponse{}, err
+}
+
+func (m MsgServer) ClaimRewardForwardingEscrow(ctx context.Context, msg *types.MsgClaimRewardForwardingEscrow) (*types.MsgClaimRewardForwardingEscrowResponse, error) {
+	delAddr, err := sdk.AccAddressFromBech32(msg.DelegatorAddress)
+	if err != nil {
+		return nil, err
+	}
+	sdkCtx := sdk.UnwrapSDKContext(ctx)
+
+	coins, err := m.Keeper.ClaimRewardForwardingEscrow(sdkCtx, delAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.MsgClaimRewardForwardingEscrowResponse{Coins: coins}, nil
+}
+
+func (m MsgServer) DelegateWithLock(ctx context.Context, msg *types.MsgDelegateWithLock) (*types.MsgDelegateWithLockResponse, error) {
+	err := msg.ValidateBasic()
+	if err != nil {
+		return nil, err
+	}
+
+	delAddr, err := sdk.AccAddressFromBech32(msg.DelegatorAddress)
+	if err != nil {
+		return nil, err
+	}
+	sdkCtx := sdk.UnwrapSDKContext(ctx)
+	valAddr, err := sdk.ValAddressFromBech32(msg.ValidatorAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	validator, err := m.Keeper.GetAllianceValidator(sdkCtx, valAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = m.Keeper.DelegateWithLock(sdkCtx, delAddr, validator, msg.Amount, msg.LockDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.MsgDelegateWithLockResponse{}, nil
+}
+
+func (m MsgServer) FundAllianceRewards(ctx context.Context, msg *types.MsgFundAllianceRewards) (*types.MsgFundAllianceRewardsResponse, error) {
+	err := msg.ValidateBasic()
+	if err != nil {
+		return nil, err
+	}
+
+	funder, err := sdk.AccAddressFromBech32(msg.Funder)
+	if err != nil {
+		return nil, err
+	}
+	var valAddr sdk.ValAddress
+	if msg.ValidatorAddress != "" {
+		valAddr, err = sdk.ValAddressFromBech32(msg.ValidatorAddress)
+		if err != nil {
+			return nil, err
+		}
+	}
+	sdkCtx := sdk.UnwrapSDKContext(ctx)
+
+	incentive, err := m.Keeper.FundAllianceRewards(sdkCtx, funder, msg.Denom, valAddr, msg.Amount, msg.StartTime, msg.EndTime)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.MsgFundAllianceRewardsResponse{Id: incentive.Id}, nil
+}
+
+func (m MsgServer) RefundAllianceRewards(ctx context.Context, msg *types.MsgRefundAllianceRewards) (*types.MsgRefundAllianceRewardsResponse, error) {
+	funder, err := sdk.AccAddressFromBech32(msg.Funder)
+	if err != nil {
+		return nil, err
+	}
+	sdkCtx := sdk.UnwrapSDKContext(ctx)
+
+	coins, err := m.Keeper.RefundAllianceRewards(sdkCtx, funder, msg.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.MsgRefundAllianceRewardsResponse{Coins: coins}, nil
+}
+
 // NewMsgServerImpl returns an implementation of the MsgServer interface
 // for the provided Keeper.
 func NewMsgServerImpl(keeper Keeper) types.MsgServer {
@@ -26,3 +26,62 @@ func (k Keeper) LastRewardClaimTime(ctx sdk.Context) (res time.Time) {
 func (k Keeper) SetLastRewardClaimTime(ctx sdk.Context, lastTime time.Time) {
 	k.paramstore.Set(ctx, types.LastTakeRateClaimTime, &lastTime)
 }
+
+func (k Keeper) MaxTotalRewardWeight(ctx sdk.Context) (res sdk.Dec) {
+	k.paramstore.Get(ctx, types.MaxTotalRewardWeight, &res)
+	return
+}
+
+func (k Keeper) RewardWeightCapMode(ctx sdk.Context) (res types.RewardWeightCapMode) {
+	k.paramstore.Get(ctx, types.RewardWeightCapModeKey, &res)
+	return
+}
+
+func (k Keeper) FullRebalanceThreshold(ctx sdk.Context) (res sdk.Dec) {
+	k.paramstore.Get(ctx, types.FullRebalanceThreshold, &res)
+	return
+}
+
+func (k Keeper) MaxRebalanceAmount(ctx sdk.Context) (res sdk.Int) {
+	k.paramstore.Get(ctx, types.MaxRebalanceAmount, &res)
+	return
+}
+
+func (k Keeper) MaxRebalanceFraction(ctx sdk.Context) (res sdk.Dec) {
+	k.paramstore.Get(ctx, types.MaxRebalanceFraction, &res)
+	return
+}
+
+func (k Keeper) LockDurations(ctx sdk.Context) (res []types.LockDuration) {
+	k.paramstore.Get(ctx, types.LockDurations, &res)
+	return
+}
+
+func (k Keeper) RewardDustSweepInterval(ctx sdk.Context) (res time.Duration) {
+	k.paramstore.Get(ctx, types.RewardDustSweepInterval, &res)
+	return
+}
+
+func (k Keeper) LastRewardDustSweepTime(ctx sdk.Context) (res time.Time) {
+	k.paramstore.Get(ctx, types.LastRewardDustSweepTime, &res)
+	return
+}
+
+func (k Keeper) SetLastRewardDustSweepTime(ctx sdk.Context, lastTime time.Time) {
+	k.paramstore.Set(ctx, types.LastRewardDustSweepTime, &lastTime)
+}
+
+func (k Keeper) RewardDustSweepMode(ctx sdk.Context) (res types.RewardDustSweepMode) {
+	k.paramstore.Get(ctx, types.RewardDustSweepModeKey, &res)
+	return
+}
+
+func (k Keeper) RewardRateCheckpointInterval(ctx sdk.Context) (res time.Duration) {
+	k.paramstore.Get(ctx, types.RewardRateCheckpointInterval, &res)
+	return
+}
+
+func (k Keeper) RewardRateRetention(ctx sdk.Context) (res time.Duration) {
+	k.paramstore.Get(ctx, types.RewardRateRetention, &res)
+	return
+}
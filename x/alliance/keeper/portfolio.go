@@ -0,0 +1,134 @@
+package keeper
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/terra-money/alliance/x/alliance/types"
+)
+
+// portfolioValidator caches a validator for the delegations of a portfolio together with the bond tokens attributed
+// to each of its assets
+type portfolioValidator struct {
+	validator      types.AllianceValidator
+	bondedPerAsset sdk.DecCoins
+}
+
+// GetDelegatorPortfolio gathers the delegations of a delegator with their balances, pending rewards and attributed
+// voting power, together with the pending undelegations and redelegations. The rewards of every validator are claimed
+// from the distribution module once in a cache context, so that the pending rewards include the rewards that were not
+// transferred to the alliance module yet.
+func (k Keeper) GetDelegatorPortfolio(ctx sdk.Context, delAddr sdk.AccAddress) (*types.QueryDelegatorPortfolioResponse, error) {
+	cacheCtx, _ := ctx.CacheContext()
+	assets := k.GetAllAssets(cacheCtx)
+	inputs, err := k.getRebalanceInputs(cacheCtx, assets)
+	if err != nil {
+		return nil, err
+	}
+	res := &types.QueryDelegatorPortfolioResponse{
+		TotalPendingRewards: sdk.NewCoins(),
+		TotalVotingPower:    sdk.ZeroDec(),
+	}
+
+	// The delegations are collected first since claiming the validator rewards writes to the store
+	var delegations []types.Delegation
+	iter := sdk.KVStorePrefixIterator(cacheCtx.KVStore(k.storeKey), types.GetDelegationsKey(delAddr))
+	for ; iter.Valid(); iter.Next() {
+		var delegation types.Delegation
+		k.cdc.MustUnmarshal(iter.Value(), &delegation)
+		delegations = append(delegations, delegation)
+	}
+	iter.Close()
+
+	assetsByDenom := map[string]types.AllianceAsset{}
+	validators := map[string]portfolioValidator{}
+	for _, delegation := range delegations {
+		asset, found := assetsByDenom[delegation.Denom]
+		if !found {
+			asset, found = k.GetDelegationAsset(cacheCtx, delegation.Denom)
+			if !found {
+				return nil, types.ErrUnknownAsset
+			}
+			assetsByDenom[delegation.Denom] = asset
+		}
+		val, found := validators[delegation.ValidatorAddress]
+		if !found {
+			val, err = k.getPortfolioValidator(cacheCtx, assets, inputs, delegation.ValidatorAddress)
+			if err != nil {
+				return nil, err
+			}
+			validators[delegation.ValidatorAddress] = val
+		}
+
+		rewards := sdk.NewCoins()
+		if asset.RewardsStarted(ctx.BlockTime()) {
+			rewards, _, err = k.CalculateDelegationRewards(cacheCtx, delegation, val.validator, asset)
+			if err != nil {
+				return nil, err
+			}
+		}
+		votingPower := k.delegationVotingPower(cacheCtx, delegation, val.validator, asset.Denom, val.bondedPerAsset.AmountOf(asset.Denom))
+		res.Delegations = append(res.Delegations, types.PortfolioDelegation{
+			Delegation:     delegation,
+			Balance:        types.GetDelegationTokens(delegation, val.validator, asset),
+			PendingRewards: rewards,
+			VotingPower:    votingPower,
+		})
+		res.TotalPendingRewards = res.TotalPendingRewards.Add(rewards...)
+		res.TotalVotingPower = res.TotalVotingPower.Add(votingPower)
+	}
+
+	store := ctx.KVStore(k.storeKey)
+	iter = k.IterateRedelegationsByDelegator(ctx, delAddr)
+	for ; iter.Valid(); iter.Next() {
+		var redelegation types.Redelegation
+		k.cdc.MustUnmarshal(iter.Value(), &redelegation)
+		res.Redelegations = append(res.Redelegations, types.PortfolioRedelegation{
+			Redelegation:   redelegation,
+			CompletionTime: types.ParseRedelegationKeyForCompletionTime(iter.Key()),
+		})
+	}
+	iter.Close()
+
+	iter = k.IterateUndelegationsByDelegator(ctx, delAddr)
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		completionTime, err := types.ParseUndelegationByDelegatorIndexKey(iter.Key())
+		if err != nil {
+			return nil, err
+		}
+		var queued types.QueuedUndelegation
+		k.cdc.MustUnmarshal(store.Get(types.GetUndelegationQueueKey(completionTime, delAddr)), &queued)
+		for _, undelegation := range queued.Entries {
+			res.Undelegations = append(res.Undelegations, types.PortfolioUndelegation{
+				Undelegation:   *undelegation,
+				CompletionTime: completionTime,
+			})
+		}
+	}
+	return res, nil
+}
+
+// getPortfolioValidator claims the rewards of a validator from the distribution module before it is read, so that its
+// reward indices are up to date
+func (k Keeper) getPortfolioValidator(ctx sdk.Context, assets []*types.AllianceAsset, inputs rebalanceInputs, validatorAddr string) (portfolioValidator, error) {
+	valAddr, err := sdk.ValAddressFromBech32(validatorAddr)
+	if err != nil {
+		return portfolioValidator{}, err
+	}
+	validator, err := k.GetAllianceValidator(ctx, valAddr)
+	if err != nil {
+		return portfolioValidator{}, err
+	}
+	if _, err := k.ClaimValidatorRewards(ctx, validator); err != nil {
+		return portfolioValidator{}, err
+	}
+	validator, err = k.GetAllianceValidator(ctx, valAddr)
+	if err != nil {
+		return portfolioValidator{}, err
+	}
+	_, bondedPerAsset := k.bondedAmountPerAsset(ctx, assets, inputs, validator)
+	return portfolioValidator{
+		validator:      validator,
+		bondedPerAsset: bondedPerAsset,
+	}, nil
+}
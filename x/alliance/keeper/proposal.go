@@ -18,6 +18,14 @@ func (k Keeper) CreateAlliance(ctx context.Context, req *types.MsgCreateAlliance
 	if found {
 		return status.Errorf(codes.AlreadyExists, "Asset with denom: %s already exists", req.Denom)
 	}
+	// Delegations of a migrated asset keep the old denom until they are moved
+	if err := k.checkAssetNotMigrating(sdkCtx, req.Denom); err != nil {
+		return err
+	}
+	assets := k.GetAllAssets(sdkCtx)
+	if err := k.ValidateTotalRewardWeight(sdkCtx, totalRewardWeight(assets).Add(req.RewardWeight)); err != nil {
+		return err
+	}
 	rewardStartTime := sdkCtx.BlockTime().Add(k.RewardDelayTime(sdkCtx))
 	asset := types.AllianceAsset{
 		Denom:                req.Denom,
@@ -30,9 +38,13 @@ func (k Keeper) CreateAlliance(ctx context.Context, req *types.MsgCreateAlliance
 		RewardChangeRate:     req.RewardChangeRate,
 		RewardChangeInterval: req.RewardChangeInterval,
 		LastRewardChangeTime: rewardStartTime,
+		RewardWeightCurve:    req.RewardWeightCurve,
 	}
 	k.SetAsset(sdkCtx, asset)
-	return nil
+	if err := k.UpdateRewardWeightScale(sdkCtx, append(assets, &asset)); err != nil {
+		return err
+	}
+	return k.afterAllianceAssetCreated(sdkCtx, asset.Denom)
 }
 
 func (k Keeper) UpdateAlliance(ctx context.Context, req *types.MsgUpdateAllianceProposal) error {
@@ -45,17 +57,32 @@ func (k Keeper) UpdateAlliance(ctx context.Context, req *types.MsgUpdateAlliance
 	if asset.RewardWeightRange.Min.GT(req.RewardWeight) || asset.RewardWeightRange.Max.LT(req.RewardWeight) {
 		return types.ErrRewardWeightOutOfBound
 	}
+	// The reward weight of group members is set by the group
+	if !req.RewardWeight.Equal(asset.RewardWeight) || req.RewardWeightCurve != nil {
+		if err := k.checkAssetNotInGroup(sdkCtx, req.Denom); err != nil {
+			return err
+		}
+	}
+	total := totalRewardWeight(k.GetAllAssets(sdkCtx)).Sub(asset.RewardWeight).Add(req.RewardWeight)
+	if err := k.ValidateTotalRewardWeight(sdkCtx, total); err != nil {
+		return err
+	}
 	asset.RewardWeight = req.RewardWeight
 	asset.TakeRate = req.TakeRate
 	asset.RewardChangeRate = req.RewardChangeRate
 	asset.RewardChangeInterval = req.RewardChangeInterval
+	asset.RewardWeightCurve = req.RewardWeightCurve
 
 	err := k.UpdateAllianceAsset(sdkCtx, asset)
 	if err != nil {
 		return err
 	}
+	k.ReleaseAssetFromQuarantine(sdkCtx, req.Denom)
 
-	return nil
+	if err := k.UpdateRewardWeightScale(sdkCtx, k.GetAllAssets(sdkCtx)); err != nil {
+		return err
+	}
+	return k.afterAllianceAssetUpdated(sdkCtx, req.Denom)
 }
 
 func (k Keeper) DeleteAlliance(ctx context.Context, req *types.MsgDeleteAllianceProposal) error {
@@ -69,11 +96,78 @@ func (k Keeper) DeleteAlliance(ctx context.Context, req *types.MsgDeleteAlliance
 	if asset.TotalTokens.GT(math.ZeroInt()) {
 		return status.Errorf(codes.Internal, "Asset cannot be deleted because there are still %s delegations associated with it", asset.TotalTokens)
 	}
+	if err := k.checkAssetNotInGroup(sdkCtx, req.Denom); err != nil {
+		return err
+	}
 
 	err := k.DeleteAsset(sdkCtx, asset)
 	if err != nil {
 		return err
 	}
 
+	return k.UpdateRewardWeightScale(sdkCtx, k.GetAllAssets(sdkCtx))
+}
+
+func (k Keeper) MigrateAlliance(ctx context.Context, req *types.MsgMigrateAllianceProposal) error {
+	sdkCtx := sdk.UnwrapSDKContext(ctx)
+	escrowRecipient, err := sdk.AccAddressFromBech32(req.EscrowRecipient)
+	if err != nil {
+		return err
+	}
+	if err := k.StartAssetMigration(sdkCtx, req.FromDenom, req.ToDenom, req.ConversionRate, escrowRecipient); err != nil {
+		return err
+	}
+	return k.afterAllianceAssetUpdated(sdkCtx, req.ToDenom)
+}
+
+func (k Keeper) CreateAssetGroup(ctx context.Context, req *types.MsgCreateAssetGroupProposal) error {
+	sdkCtx := sdk.UnwrapSDKContext(ctx)
+	if _, found := k.GetAssetGroup(sdkCtx, req.Name); found {
+		return status.Errorf(codes.AlreadyExists, "Asset group with name: %s already exists", req.Name)
+	}
+	return k.setAssetGroupFromProposal(sdkCtx, types.NewAssetGroup(req.Name, req.RewardWeight, req.Members, req.UpdateInterval))
+}
+
+func (k Keeper) UpdateAssetGroup(ctx context.Context, req *types.MsgUpdateAssetGroupProposal) error {
+	sdkCtx := sdk.UnwrapSDKContext(ctx)
+	if _, found := k.GetAssetGroup(sdkCtx, req.Name); !found {
+		return types.ErrAssetGroupNotFound.Wrapf("%s", req.Name)
+	}
+	return k.setAssetGroupFromProposal(sdkCtx, types.NewAssetGroup(req.Name, req.RewardWeight, req.Members, req.UpdateInterval))
+}
+
+// DeleteAssetGroup removes an asset group. The members keep their last reward weight and can be updated or deleted
+// by governance again.
+func (k Keeper) DeleteAssetGroup(ctx context.Context, req *types.MsgDeleteAssetGroupProposal) error {
+	sdkCtx := sdk.UnwrapSDKContext(ctx)
+	if _, found := k.GetAssetGroup(sdkCtx, req.Name); !found {
+		return types.ErrAssetGroupNotFound.Wrapf("%s", req.Name)
+	}
+	k.deleteAssetGroup(sdkCtx, req.Name)
+	return nil
+}
+
+// setAssetGroupFromProposal stores the group and splits its reward weight among the members right away
+func (k Keeper) setAssetGroupFromProposal(ctx sdk.Context, group types.AssetGroup) error {
+	if err := k.validateAssetGroupMembers(ctx, group); err != nil {
+		return err
+	}
+	assets := k.GetAllAssets(ctx)
+	if err := k.ValidateTotalRewardWeight(ctx, assetGroupTotalRewardWeight(assets, group)); err != nil {
+		return err
+	}
+	if err := k.updateAssetGroupRewardWeights(ctx, group, assets); err != nil {
+		return err
+	}
+	if err := k.UpdateRewardWeightScale(ctx, assets); err != nil {
+		return err
+	}
+	for _, member := range group.Members {
+		// Members quarantined by a failed group update are processed by the end blocker again
+		k.ReleaseAssetFromQuarantine(ctx, member.Denom)
+		if err := k.afterAllianceAssetUpdated(ctx, member.Denom); err != nil {
+			return err
+		}
+	}
 	return nil
 }
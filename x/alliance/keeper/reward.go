@@ -35,6 +35,15 @@ func (k Keeper) ClaimValidatorRewards(ctx sdk.Context, val types.AllianceValidat
 // ClaimDelegationRewards claims delegation rewards and transfers to the delegator account
 // This method updates the delegation so you will need to re-query an updated version from the database
 func (k Keeper) ClaimDelegationRewards(ctx sdk.Context, delAddr sdk.AccAddress, val types.AllianceValidator, denom string) (sdk.Coins, error) {
+	return k.claimDelegationRewards(ctx, delAddr, val, denom, true, true)
+}
+
+// claimDelegationRewards only claims the rewards of the validator from the distribution module first if
+// claimValidatorRewards is set. Otherwise only the rewards that were already added to the reward indices of the
+// validator are paid out. Rewards of delegations with a forwarding destination are only sent over IBC if forward is
+// set, and paid out to the delegator account otherwise, which is used where no packets must be sent.
+func (k Keeper) claimDelegationRewards(ctx sdk.Context, delAddr sdk.AccAddress, val types.AllianceValidator, denom string, claimValidatorRewards bool, forward bool) (sdk.Coins, error) {
+	k.moveMigratedDelegation(ctx, delAddr, val.GetOperator(), denom)
 	asset, found := k.GetAssetByDenom(ctx, denom)
 	if !found {
 		return nil, types.ErrUnknownAsset
@@ -49,24 +58,34 @@ func (k Keeper) ClaimDelegationRewards(ctx sdk.Context, delAddr sdk.AccAddress,
 		return sdk.Coins{}, stakingtypes.ErrNoDelegatorForAddress
 	}
 
-	_, err := k.ClaimValidatorRewards(ctx, val)
-	if err != nil {
-		return nil, err
+	if claimValidatorRewards {
+		if _, err := k.ClaimValidatorRewards(ctx, val); err != nil {
+			return nil, err
+		}
 	}
 
-	coins, newIndices, err := k.CalculateDelegationRewards(ctx, delegation, val, asset)
+	coins, dust, newIndices, err := k.calculateDelegationRewards(ctx, delegation, val, asset)
 	if err != nil {
 		return nil, err
 	}
+	k.addRewardDust(ctx, dust)
 
 	delegation.RewardHistory = newIndices
 	delegation.LastRewardClaimHeight = uint64(ctx.BlockHeight())
 	k.SetDelegation(ctx, delAddr, val.GetOperator(), denom, delegation)
 
-	err = k.bankKeeper.SendCoinsFromModuleToAccount(ctx, types.RewardsPoolName, delAddr, coins)
+	// Rewards of delegations with a forwarding destination are sent over IBC instead of to the delegator account
+	if forwarding, found := k.GetRewardForwarding(ctx, delAddr, val.GetOperator(), denom); found && forward {
+		err = k.forwardRewards(ctx, forwarding, coins)
+	} else {
+		err = k.bankKeeper.SendCoinsFromModuleToAccount(ctx, types.RewardsPoolName, delAddr, coins)
+	}
 	if err != nil {
 		return nil, err
 	}
+	if err := k.afterAllianceRewardsClaimed(ctx, delAddr, val.GetOperator(), denom, coins); err != nil {
+		return nil, err
+	}
 
 	_ = ctx.EventManager().EmitTypedEvent(
 		&types.ClaimAllianceRewardsEvent{
@@ -82,49 +101,80 @@ func (k Keeper) ClaimDelegationRewards(ctx sdk.Context, delAddr sdk.AccAddress,
 // CalculateDelegationRewards calculates the rewards that can be claimed for a delegation
 // It takes past reward_rate changes into account by using the RewardRateChangeSnapshot entry
 func (k Keeper) CalculateDelegationRewards(ctx sdk.Context, delegation types.Delegation, val types.AllianceValidator, asset types.AllianceAsset) (sdk.Coins, types.RewardHistories, error) {
+	rewards, _, histories, err := k.calculateDelegationRewards(ctx, delegation, val, asset)
+	return rewards, histories, err
+}
+
+// calculateDelegationRewards also returns the fractions of the rewards that are truncated since only whole tokens
+// can be paid out
+func (k Keeper) calculateDelegationRewards(ctx sdk.Context, delegation types.Delegation, val types.AllianceValidator, asset types.AllianceAsset) (sdk.Coins, sdk.DecCoins, types.RewardHistories, error) {
 	totalRewards := sdk.NewCoins()
+	totalDust := sdk.NewDecCoins()
 	currentRewardHistory := types.NewRewardHistories(val.GlobalRewardHistory)
 	delegationRewardHistories := types.NewRewardHistories(delegation.RewardHistory)
+	// Locked delegations earn rewards on their boost shares as well
+	delegationShares := delegation.Shares.Add(k.GetDelegationBoostShares(ctx, delegation))
 	// If there are reward rate changes between last and current claim, sequentially claim with the help of the snapshots
-	snapshotIter := k.IterateWeightChangeSnapshot(ctx, asset.Denom, val.GetOperator(), delegation.LastRewardClaimHeight)
-	for ; snapshotIter.Valid(); snapshotIter.Next() {
-		var snapshot types.RewardWeightChangeSnapshot
-		b := snapshotIter.Value()
-		k.cdc.MustUnmarshal(b, &snapshot)
+	snapshots := k.getWeightChangeSnapshots(ctx, asset.Denom, val.GetOperator(), delegation.LastRewardClaimHeight)
+	// Each period is paid on the tokens the delegation was worth at its end. Snapshots that did not record the tokens
+	// use the tokens of the next period that did.
+	delegationTokens := sdk.NewDecFromInt(types.GetDelegationTokensWithShares(delegationShares, val, asset).Amount)
+	periodTokens := make([]sdk.Dec, len(snapshots))
+	tokens := delegationTokens
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		if snapshotTokens, found := snapshots[i].DelegationTokens(delegationShares); found {
+			tokens = snapshotTokens
+		}
+		periodTokens[i] = tokens
+	}
+	for i, snapshot := range snapshots {
 		var rewards sdk.Coins
-		rewards, delegationRewardHistories = accumulateRewards(types.NewRewardHistories(snapshot.RewardHistories), delegationRewardHistories, asset, snapshot.PrevRewardWeight, delegation, val)
+		var dust sdk.DecCoins
+		rewards, dust, delegationRewardHistories = accumulateRewards(types.NewRewardHistories(snapshot.RewardHistories), delegationRewardHistories, asset, snapshot.PrevRewardWeight, periodTokens[i])
 		totalRewards = totalRewards.Add(rewards...)
+		totalDust = totalDust.Add(dust...)
 	}
-	rewards, _ := accumulateRewards(currentRewardHistory, delegationRewardHistories, asset, asset.RewardWeight, delegation, val)
+	rewards, dust, _ := accumulateRewards(currentRewardHistory, delegationRewardHistories, asset, k.RewardIndexWeight(ctx, asset), delegationTokens)
 	totalRewards = totalRewards.Add(rewards...)
-	return totalRewards, currentRewardHistory, nil
+	totalDust = totalDust.Add(dust...)
+	return totalRewards, totalDust, currentRewardHistory, nil
 }
 
 // accumulateRewards compares the latest reward history with the delegation's reward history
-// It takes the difference and calculates how much can be claimed
-func accumulateRewards(latestRewardHistories types.RewardHistories, rewardHistories types.RewardHistories, asset types.AllianceAsset, rewardWeight sdk.Dec, delegation types.Delegation, validator types.AllianceValidator) (sdk.Coins, types.RewardHistories) {
+// It takes the difference and calculates how much can be claimed and how much is truncated
+func accumulateRewards(latestRewardHistories types.RewardHistories, rewardHistories types.RewardHistories, asset types.AllianceAsset, rewardWeight sdk.Dec, delegationTokens sdk.Dec) (sdk.Coins, sdk.DecCoins, types.RewardHistories) {
 	// Go through each reward denom and accumulate rewards
 	var rewards sdk.Coins
+	var dust sdk.DecCoins
 
-	delegationTokens := sdk.NewDecFromInt(types.GetDelegationTokens(delegation, validator, asset).Amount)
 	for _, history := range latestRewardHistories {
-		rewardHistory, found := rewardHistories.GetIndexByDenom(history.Denom)
+		// Rewards of a single alliance asset are only shared by its delegators
+		if history.Alliance != "" && history.Alliance != asset.Denom {
+			continue
+		}
+		rewardHistory, found := rewardHistories.GetIndexByAlliance(history.Denom, history.Alliance)
 		if !found {
 			rewardHistory.Denom = history.Denom
+			rewardHistory.Alliance = history.Alliance
 			rewardHistory.Index = sdk.ZeroDec()
 		}
 		if rewardHistory.Index.GTE(history.Index) {
 			continue
 		}
 		claimWeight := delegationTokens.Mul(rewardWeight)
+		if history.Alliance != "" {
+			claimWeight = delegationTokens
+		}
 		totalClaimable := (history.Index.Sub(rewardHistory.Index)).Mul(claimWeight)
 		rewardHistory.Index = history.Index
-		rewards = rewards.Add(sdk.NewCoin(history.Denom, totalClaimable.TruncateInt()))
+		claimed := totalClaimable.TruncateInt()
+		rewards = rewards.Add(sdk.NewCoin(history.Denom, claimed))
+		dust = dust.Add(sdk.NewDecCoinFromDec(history.Denom, totalClaimable.Sub(sdk.NewDecFromInt(claimed))))
 		if !found {
 			rewardHistories = append(rewardHistories, *rewardHistory)
 		}
 	}
-	return rewards, rewardHistories
+	return rewards, dust, rewardHistories
 }
 
 // AddAssetsToRewardPool increments a reward history array. A reward history stores the average reward per token/reward_weight.
@@ -142,7 +192,8 @@ func (k Keeper) AddAssetsToRewardPool(ctx sdk.Context, from sdk.AccAddress, val
 		return nil
 	}
 
-	for _, c := range coins {
+	// Recycled reward dust is already in the rewards pool, so it is only added to the indices
+	for _, c := range coins.Add(k.popRecycledRewardDust(ctx)...) {
 		rewardHistory, found := rewardHistories.GetIndexByDenom(c.Denom)
 		if !found {
 			rewardHistories = append(rewardHistories, types.RewardHistory{
@@ -164,8 +215,34 @@ func (k Keeper) AddAssetsToRewardPool(ctx sdk.Context, from sdk.AccAddress, val
 	return nil
 }
 
+// addAllianceRewardsToPool increments the reward history of an alliance asset on a validator. The index is the average
+// reward per token of the asset, so the rewards are shared by the delegators of the asset regardless of the reward
+// weight. The coins have to be sent to the rewards pool by the caller.
+func (k Keeper) addAllianceRewardsToPool(ctx sdk.Context, val types.AllianceValidator, asset types.AllianceAsset, coins sdk.Coins) {
+	totalTokens := val.TotalTokensWithAsset(asset).Add(val.BoostedTokensWithAsset(asset))
+	if totalTokens.IsZero() {
+		return
+	}
+	rewardHistories := types.NewRewardHistories(val.GlobalRewardHistory)
+	for _, c := range coins {
+		rewardHistory, found := rewardHistories.GetIndexByAlliance(c.Denom, asset.Denom)
+		if !found {
+			rewardHistories = append(rewardHistories, types.RewardHistory{
+				Denom:    c.Denom,
+				Index:    sdk.NewDecFromInt(c.Amount).Quo(totalTokens),
+				Alliance: asset.Denom,
+			})
+		} else {
+			rewardHistory.Index = rewardHistory.Index.Add(sdk.NewDecFromInt(c.Amount).Quo(totalTokens))
+		}
+	}
+	val.GlobalRewardHistory = rewardHistories
+	k.SetValidator(ctx, val)
+}
+
 func (k Keeper) totalAssetWeight(ctx sdk.Context, val types.AllianceValidator) sdk.Dec {
 	total := sdk.ZeroDec()
+	rewardWeightScale := k.GetRewardWeightScale(ctx)
 	for _, token := range val.TotalDelegatorShares {
 		asset, found := k.GetAssetByDenom(ctx, token.Denom)
 		if !found {
@@ -174,8 +251,59 @@ func (k Keeper) totalAssetWeight(ctx sdk.Context, val types.AllianceValidator) s
 		if !asset.RewardsStarted(ctx.BlockTime()) {
 			continue
 		}
-		totalValTokens := val.TotalTokensWithAsset(asset)
-		total = total.Add(asset.RewardWeight.Mul(totalValTokens))
+		totalValTokens := val.TotalTokensWithAsset(asset).Add(val.BoostedTokensWithAsset(asset))
+		total = total.Add(asset.RewardWeight.Mul(rewardWeightScale).Quo(asset.GetConversionRate()).Mul(totalValTokens))
 	}
 	return total
 }
+
+// GetRewardsPoolBalance returns the rewards that were claimed from the distribution module and not yet paid out
+func (k Keeper) GetRewardsPoolBalance(ctx sdk.Context) sdk.Coins {
+	return k.bankKeeper.GetAllBalances(ctx, k.accountKeeper.GetModuleAddress(types.RewardsPoolName))
+}
+
+// TotalClaimableRewards sums up the rewards that every delegation can currently claim from the rewards pool.
+// It also returns the number of delegations that were summed up.
+func (k Keeper) TotalClaimableRewards(ctx sdk.Context) (total sdk.Coins, delegations int64, err error) {
+	total = sdk.NewCoins()
+	assets := map[string]types.AllianceAsset{}
+	for _, asset := range k.GetAllAssets(ctx) {
+		assets[asset.Denom] = *asset
+	}
+	// Delegations that were not moved yet by an asset migration still use the old denom
+	migration, migrating := k.GetAssetMigration(ctx)
+	validators := map[string]types.AllianceValidator{}
+	k.IterateDelegations(ctx, func(delegation types.Delegation) bool {
+		denom := delegation.Denom
+		if migrating && denom == migration.FromDenom {
+			denom = migration.ToDenom
+			delegation.RewardHistory = migrateRewardHistories(delegation.RewardHistory, migration.FromDenom, migration.ToDenom, migration.ConversionRate)
+		}
+		asset, found := assets[denom]
+		if !found || !asset.RewardsStarted(ctx.BlockTime()) {
+			return false
+		}
+		val, found := validators[delegation.ValidatorAddress]
+		if !found {
+			var valAddr sdk.ValAddress
+			valAddr, err = sdk.ValAddressFromBech32(delegation.ValidatorAddress)
+			if err != nil {
+				return true
+			}
+			val, err = k.GetAllianceValidator(ctx, valAddr)
+			if err != nil {
+				return true
+			}
+			validators[delegation.ValidatorAddress] = val
+		}
+		var rewards sdk.Coins
+		rewards, _, err = k.CalculateDelegationRewards(ctx, delegation, val, asset)
+		if err != nil {
+			return true
+		}
+		total = total.Add(rewards...)
+		delegations++
+		return false
+	})
+	return total, delegations, err
+}
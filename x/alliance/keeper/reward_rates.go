@@ -0,0 +1,253 @@
+package keeper
+
+import (
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/terra-money/alliance/x/alliance/types"
+)
+
+// secondsPerYear is used to annualise the reward rates
+const secondsPerYear = 365 * 24 * 60 * 60
+
+// CheckpointRewardRatesHook records the reward rate checkpoints once every reward_rate_checkpoint_interval and prunes
+// the ones older than reward_rate_retention. A zero interval disables checkpointing.
+func (k Keeper) CheckpointRewardRatesHook(ctx sdk.Context) error {
+	interval := k.RewardRateCheckpointInterval(ctx)
+	if interval <= 0 {
+		return nil
+	}
+	if last, found := k.GetLastRewardWeightsCheckpoint(ctx); found && ctx.BlockTime().Before(last.Time.Add(interval)) {
+		return nil
+	}
+	if err := k.CheckpointRewardRates(ctx); err != nil {
+		return err
+	}
+	k.PruneRewardRateCheckpoints(ctx, ctx.BlockTime().Add(-k.RewardRateRetention(ctx)))
+	return nil
+}
+
+// CheckpointRewardRates records the reward indices of every alliance validator together with the tokens of each asset
+// delegated to it, and the reward index weights of the assets that earn rewards. The indices only move when the
+// rewards of a validator are claimed from the distribution module, so rates over short windows can be uneven.
+func (k Keeper) CheckpointRewardRates(ctx sdk.Context) error {
+	now := ctx.BlockTime()
+	weights := sdk.NewDecCoins()
+	assets := map[string]types.AllianceAsset{}
+	for _, asset := range k.GetAllAssets(ctx) {
+		assets[asset.Denom] = *asset
+		if !asset.RewardsStarted(now) {
+			continue
+		}
+		weights = weights.Add(sdk.NewDecCoinFromDec(asset.Denom, k.RewardIndexWeight(ctx, *asset)))
+	}
+
+	var valAddrs []sdk.ValAddress
+	k.IterateAllianceValidatorInfo(ctx, func(valAddr sdk.ValAddress, info types.AllianceValidatorInfo) bool {
+		if len(info.GlobalRewardHistory) > 0 || !sdk.DecCoins(info.TotalDelegatorShares).IsZero() {
+			valAddrs = append(valAddrs, valAddr)
+		}
+		return false
+	})
+	for _, valAddr := range valAddrs {
+		// Validators that were removed from the staking module do not earn rewards anymore
+		if _, found := k.stakingKeeper.GetValidator(ctx, valAddr); !found {
+			continue
+		}
+		val, err := k.GetAllianceValidator(ctx, valAddr)
+		if err != nil {
+			return err
+		}
+		tokens := sdk.NewDecCoins()
+		for _, share := range val.TotalDelegatorShares {
+			if asset, found := assets[share.Denom]; found {
+				tokens = tokens.Add(sdk.NewDecCoinFromDec(asset.Denom, val.TotalTokensWithAsset(asset)))
+			}
+		}
+		k.setRewardRateCheckpoint(ctx, types.RewardRateCheckpoint{
+			ValidatorAddress: valAddr.String(),
+			Time:             now,
+			RewardHistories:  val.GlobalRewardHistory,
+			Tokens:           tokens,
+		})
+	}
+	k.setRewardWeightsCheckpoint(ctx, types.RewardWeightsCheckpoint{
+		Time:          now,
+		RewardWeights: weights,
+	})
+	return nil
+}
+
+// PruneRewardRateCheckpoints deletes the checkpoints that were recorded before the cutoff time
+func (k Keeper) PruneRewardRateCheckpoints(ctx sdk.Context, cutoff time.Time) {
+	store := ctx.KVStore(k.storeKey)
+	var keys [][]byte
+	iter := store.Iterator(types.RewardRateCheckpointKey, types.GetRewardRateCheckpointKeyByTime(cutoff))
+	for ; iter.Valid(); iter.Next() {
+		keys = append(keys, iter.Key())
+	}
+	iter.Close()
+	iter = store.Iterator(types.RewardWeightsCheckpointKey, types.GetRewardWeightsCheckpointKey(cutoff))
+	for ; iter.Valid(); iter.Next() {
+		keys = append(keys, iter.Key())
+	}
+	iter.Close()
+	for _, key := range keys {
+		store.Delete(key)
+	}
+}
+
+// GetAllianceRewardRates derives the reward tokens that a token of an asset earns per year from the checkpoints within
+// the window before the last checkpoint. The rewards between two checkpoints are the increase of the reward indices
+// times the reward weight at the first one. Each validator gets its own rate and the rate of the asset is the average
+// over the validators by the tokens delegated to them. Periods in which the asset did not earn rewards are left out.
+// Without a validator address the rates of all validators are derived, and without a window all checkpoints are used.
+func (k Keeper) GetAllianceRewardRates(ctx sdk.Context, denom string, valAddr sdk.ValAddress, window time.Duration) (rates sdk.DecCoins, validatorRates []types.ValidatorRewardRate, startTime time.Time, endTime time.Time) {
+	rates = sdk.NewDecCoins()
+	var times []time.Time
+	weights := map[int64]sdk.Dec{}
+	k.IterateRewardWeightsCheckpoints(ctx, func(checkpoint types.RewardWeightsCheckpoint) bool {
+		times = append(times, checkpoint.Time)
+		if weight := checkpoint.RewardWeights.AmountOf(denom); weight.IsPositive() {
+			weights[checkpoint.Time.UnixNano()] = weight
+		}
+		return false
+	})
+	if len(times) == 0 {
+		return rates, validatorRates, startTime, endTime
+	}
+	endTime = times[len(times)-1]
+	for _, t := range times {
+		if window <= 0 || !t.Before(endTime.Add(-window)) {
+			startTime = t
+			break
+		}
+	}
+
+	// The checkpoints are iterated in time order, so the checkpoints of each validator are sorted as well
+	var valAddrs []string
+	checkpoints := map[string][]types.RewardRateCheckpoint{}
+	store := ctx.KVStore(k.storeKey)
+	iter := store.Iterator(types.GetRewardRateCheckpointKeyByTime(startTime), sdk.PrefixEndBytes(types.GetRewardRateCheckpointKeyByTime(endTime)))
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		var checkpoint types.RewardRateCheckpoint
+		k.cdc.MustUnmarshal(iter.Value(), &checkpoint)
+		if valAddr != nil && checkpoint.ValidatorAddress != valAddr.String() {
+			continue
+		}
+		if _, found := checkpoints[checkpoint.ValidatorAddress]; !found {
+			valAddrs = append(valAddrs, checkpoint.ValidatorAddress)
+		}
+		checkpoints[checkpoint.ValidatorAddress] = append(checkpoints[checkpoint.ValidatorAddress], checkpoint)
+	}
+
+	totalRewards := sdk.NewDecCoins()
+	totalTokenSeconds := sdk.ZeroDec()
+	for _, addr := range valAddrs {
+		validatorRewards := sdk.NewDecCoins()
+		validatorSeconds := sdk.ZeroDec()
+		delegated := false
+		history := checkpoints[addr]
+		for i := 0; i+1 < len(history); i++ {
+			from, to := history[i], history[i+1]
+			weight, found := weights[from.Time.UnixNano()]
+			seconds := sdk.NewDec(int64(to.Time.Sub(from.Time) / time.Second))
+			if !found || !seconds.IsPositive() {
+				continue
+			}
+			rewards := rewardsPerToken(types.NewRewardHistories(from.RewardHistories), to.RewardHistories, denom, weight)
+			validatorRewards = validatorRewards.Add(rewards...)
+			validatorSeconds = validatorSeconds.Add(seconds)
+
+			tokens := from.Tokens.AmountOf(denom)
+			if tokens.IsPositive() {
+				delegated = true
+				totalRewards = totalRewards.Add(rewards.MulDec(tokens)...)
+				totalTokenSeconds = totalTokenSeconds.Add(tokens.Mul(seconds))
+			}
+		}
+		if validatorSeconds.IsZero() || (valAddr == nil && !delegated) {
+			continue
+		}
+		validatorRates = append(validatorRates, types.ValidatorRewardRate{
+			ValidatorAddress: addr,
+			AnnualRates:      validatorRewards.MulDec(sdk.NewDec(secondsPerYear)).QuoDec(validatorSeconds),
+		})
+	}
+	if totalTokenSeconds.IsPositive() {
+		rates = totalRewards.MulDec(sdk.NewDec(secondsPerYear)).QuoDec(totalTokenSeconds)
+	}
+	return rates, validatorRates, startTime, endTime
+}
+
+// rewardsPerToken returns the rewards that a token of the asset earned between two reward indices of a validator in
+// the same way as accumulateRewards
+func rewardsPerToken(from types.RewardHistories, to types.RewardHistories, denom string, rewardWeight sdk.Dec) sdk.DecCoins {
+	rewards := sdk.NewDecCoins()
+	for _, history := range to {
+		if history.Alliance != "" && history.Alliance != denom {
+			continue
+		}
+		index := sdk.ZeroDec()
+		if prev, found := from.GetIndexByAlliance(history.Denom, history.Alliance); found {
+			index = prev.Index
+		}
+		if !history.Index.GT(index) {
+			continue
+		}
+		reward := history.Index.Sub(index)
+		if history.Alliance == "" {
+			reward = reward.Mul(rewardWeight)
+		}
+		rewards = rewards.Add(sdk.NewDecCoinFromDec(history.Denom, reward))
+	}
+	return rewards
+}
+
+func (k Keeper) setRewardRateCheckpoint(ctx sdk.Context, checkpoint types.RewardRateCheckpoint) {
+	valAddr, _ := sdk.ValAddressFromBech32(checkpoint.ValidatorAddress)
+	b := k.cdc.MustMarshal(&checkpoint)
+	ctx.KVStore(k.storeKey).Set(types.GetRewardRateCheckpointKey(checkpoint.Time, valAddr), b)
+}
+
+func (k Keeper) IterateRewardRateCheckpoints(ctx sdk.Context, cb func(checkpoint types.RewardRateCheckpoint) (stop bool)) {
+	iter := sdk.KVStorePrefixIterator(ctx.KVStore(k.storeKey), types.RewardRateCheckpointKey)
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		var checkpoint types.RewardRateCheckpoint
+		k.cdc.MustUnmarshal(iter.Value(), &checkpoint)
+		if cb(checkpoint) {
+			break
+		}
+	}
+}
+
+func (k Keeper) setRewardWeightsCheckpoint(ctx sdk.Context, checkpoint types.RewardWeightsCheckpoint) {
+	b := k.cdc.MustMarshal(&checkpoint)
+	ctx.KVStore(k.storeKey).Set(types.GetRewardWeightsCheckpointKey(checkpoint.Time), b)
+}
+
+func (k Keeper) IterateRewardWeightsCheckpoints(ctx sdk.Context, cb func(checkpoint types.RewardWeightsCheckpoint) (stop bool)) {
+	iter := sdk.KVStorePrefixIterator(ctx.KVStore(k.storeKey), types.RewardWeightsCheckpointKey)
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		var checkpoint types.RewardWeightsCheckpoint
+		k.cdc.MustUnmarshal(iter.Value(), &checkpoint)
+		if cb(checkpoint) {
+			break
+		}
+	}
+}
+
+// GetLastRewardWeightsCheckpoint returns the latest checkpoint, which marks the time of the last reward rate checkpoint
+func (k Keeper) GetLastRewardWeightsCheckpoint(ctx sdk.Context) (checkpoint types.RewardWeightsCheckpoint, found bool) {
+	iter := sdk.KVStoreReversePrefixIterator(ctx.KVStore(k.storeKey), types.RewardWeightsCheckpointKey)
+	defer iter.Close()
+	if !iter.Valid() {
+		return checkpoint, false
+	}
+	k.cdc.MustUnmarshal(iter.Value(), &checkpoint)
+	return checkpoint, true
+}
@@ -0,0 +1,114 @@
+package keeper
+
+import (
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/terra-money/alliance/x/alliance/types"
+)
+
+// SimulateAllianceAction applies a delegation, undelegation or redelegation and the rebalance of the affected
+// validators in a cache context that is discarded afterwards, and returns the outcome. The destination validator is
+// only used for redelegations.
+func (k Keeper) SimulateAllianceAction(ctx sdk.Context, action types.AllianceAction, delAddr sdk.AccAddress, valAddr sdk.ValAddress, dstValAddr sdk.ValAddress, coin sdk.Coin) (*types.QuerySimulateAllianceActionResponse, error) {
+	if _, found := k.GetAssetByDenom(ctx, coin.Denom); !found {
+		return nil, status.Errorf(codes.NotFound, "Asset with denom: %s does not exist", coin.Denom)
+	}
+	valAddrs := []sdk.ValAddress{valAddr}
+	if action == types.AllianceActionRedelegate {
+		valAddrs = append(valAddrs, dstValAddr)
+	}
+
+	cacheCtx, _ := ctx.CacheContext()
+	res := &types.QuerySimulateAllianceActionResponse{
+		DstShares:      sdk.ZeroDec(),
+		Amount:         coin,
+		ClaimedRewards: sdk.NewCoins(),
+	}
+
+	// The rewards of existing delegations are claimed before they change, so they are claimed up front to report them
+	sharesBefore := make([]sdk.Dec, len(valAddrs))
+	for i, addr := range valAddrs {
+		sharesBefore[i] = sdk.ZeroDec()
+		if _, found := k.GetDelegation(cacheCtx, delAddr, addr, coin.Denom); !found {
+			continue
+		}
+		val, err := k.GetAllianceValidator(cacheCtx, addr)
+		if err != nil {
+			return nil, err
+		}
+		rewards, err := k.ClaimDelegationRewards(cacheCtx, delAddr, val, coin.Denom)
+		if err != nil {
+			return nil, err
+		}
+		res.ClaimedRewards = res.ClaimedRewards.Add(rewards...)
+		// The delegation is queried again since claiming rewards can modify it
+		delegation, _ := k.GetDelegation(cacheCtx, delAddr, addr, coin.Denom)
+		sharesBefore[i] = delegation.Shares
+	}
+
+	val, err := k.GetAllianceValidator(cacheCtx, valAddr)
+	if err != nil {
+		return nil, err
+	}
+	var completionTime *time.Time
+	switch action {
+	case types.AllianceActionDelegate:
+		_, err = k.Delegate(cacheCtx, delAddr, val, coin)
+	case types.AllianceActionUndelegate:
+		completionTime, err = k.Undelegate(cacheCtx, delAddr, val, coin)
+	case types.AllianceActionRedelegate:
+		var dstVal types.AllianceValidator
+		dstVal, err = k.GetAllianceValidator(cacheCtx, dstValAddr)
+		if err != nil {
+			return nil, err
+		}
+		completionTime, err = k.Redelegate(cacheCtx, delAddr, val, dstVal, coin)
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown alliance action %s", action)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if completionTime != nil {
+		res.CompletionTime = *completionTime
+	}
+
+	sharesAfter := make([]sdk.Dec, len(valAddrs))
+	for i, addr := range valAddrs {
+		sharesAfter[i] = sdk.ZeroDec()
+		if delegation, found := k.GetDelegation(cacheCtx, delAddr, addr, coin.Denom); found {
+			sharesAfter[i] = delegation.Shares
+		}
+	}
+	res.Shares = sharesAfter[0].Sub(sharesBefore[0]).Abs()
+	if action == types.AllianceActionRedelegate {
+		res.DstShares = sharesAfter[1].Sub(sharesBefore[1])
+	}
+	val, err = k.GetAllianceValidator(cacheCtx, valAddr)
+	if err != nil {
+		return nil, err
+	}
+	asset, _ := k.GetAssetByDenom(cacheCtx, coin.Denom)
+	res.RemainingTokens = types.GetDelegationTokensWithShares(sharesAfter[0], val, asset)
+
+	if err := k.RebalanceHook(cacheCtx, k.GetAllAssets(cacheCtx)); err != nil {
+		return nil, err
+	}
+	for _, addr := range valAddrs {
+		before, found := k.stakingKeeper.GetValidator(ctx, addr)
+		if !found {
+			continue
+		}
+		after, _ := k.stakingKeeper.GetValidator(cacheCtx, addr)
+		res.Validators = append(res.Validators, types.ValidatorPowerChange{
+			ValidatorAddr: addr.String(),
+			TokensBefore:  before.GetTokens(),
+			TokensAfter:   after.GetTokens(),
+		})
+	}
+	return res, nil
+}
@@ -23,6 +23,7 @@ func (k Keeper) SlashValidator(ctx sdk.Context, valAddr sdk.ValAddress, fraction
 	if err != nil {
 		return err
 	}
+	k.snapshotSlashedAssets(ctx, val)
 	// slashedValidatorShares accumulates the final validator shares after slashing
 	slashedValidatorShares := sdk.NewDecCoins()
 	for _, share := range val.ValidatorShares {
@@ -48,7 +49,40 @@ func (k Keeper) SlashValidator(ctx sdk.Context, valAddr sdk.ValAddress, fraction
 	if err != nil {
 		return err
 	}
-	return nil
+	return k.afterAllianceSlash(ctx, valAddr, fraction)
+}
+
+// snapshotSlashedAssets takes a snapshot of every asset held by a slashed validator on all validators that hold it.
+// Slashing moves tokens of these assets from the slashed validator to all other validators, and the snapshots keep
+// the tokens the delegations were worth before, so that the rewards accrued until the slash are paid on them when
+// the delegations claim. The rewards of the validators are not withdrawn from the distribution module since the stake
+// of the slashed validator is not reduced yet, and they are split by the new tokens later.
+func (k Keeper) snapshotSlashedAssets(ctx sdk.Context, slashedVal types.AllianceValidator) {
+	var assets []types.AllianceAsset
+	for _, share := range slashedVal.ValidatorShares {
+		asset, found := k.GetAssetByDenom(ctx, share.Denom)
+		// Assets that are not initialized yet earn no rewards until they get their first snapshot
+		if found && asset.IsInitialized {
+			assets = append(assets, asset)
+		}
+	}
+	if len(assets) == 0 {
+		return
+	}
+	k.IterateAllianceValidatorInfo(ctx, func(valAddr sdk.ValAddress, info types.AllianceValidatorInfo) (stop bool) {
+		val := types.AllianceValidator{AllianceValidatorInfo: &info}
+		for _, asset := range assets {
+			if !val.TotalDelegationSharesWithDenom(asset.Denom).IsPositive() {
+				continue
+			}
+			if ctx.KVStore(k.storeKey).Has(types.GetRewardWeightChangeSnapshotKey(asset.Denom, valAddr, uint64(ctx.BlockHeight()))) {
+				continue
+			}
+			snapshot := types.NewRewardWeightChangeSnapshot(k.RewardIndexWeight(ctx, asset), val, asset)
+			k.setRewardWeightChangeSnapshot(ctx, asset.Denom, valAddr, uint64(ctx.BlockHeight()), snapshot)
+		}
+		return false
+	})
 }
 
 func (k Keeper) slashRedelegations(ctx sdk.Context, valAddr sdk.ValAddress, fraction sdk.Dec) error {
@@ -81,7 +115,10 @@ func (k Keeper) slashRedelegations(ctx sdk.Context, valAddr sdk.ValAddress, frac
 			return err
 		}
 
-		_, err = k.ClaimDelegationRewards(ctx, delAddr, dstVal, redelegation.Balance.Denom)
+		// Rewards are paid out to the delegator since no packets are sent from the slashing hook. The rewards of the
+		// validator are left in the distribution module like in snapshotSlashedAssets, since adding them to the reward
+		// indices now would split them by tokens that the remaining slashes of the block still change.
+		_, err = k.claimDelegationRewards(ctx, delAddr, dstVal, redelegation.Balance.Denom, false, false)
 		if err != nil {
 			return err
 		}
@@ -95,6 +132,10 @@ func (k Keeper) slashRedelegations(ctx sdk.Context, valAddr sdk.ValAddress, frac
 		if !found {
 			continue
 		}
+		// Delegations that are worth no tokens have nothing left to slash
+		if dstVal.InvalidExRateWithAsset(asset) {
+			continue
+		}
 
 		// Slash delegation shares
 		tokensToSlash := fraction.MulInt(redelegation.Balance.Amount).TruncateInt()
@@ -105,8 +146,17 @@ func (k Keeper) slashRedelegations(ctx sdk.Context, valAddr sdk.ValAddress, frac
 		dstVal.TotalDelegatorShares = sdk.DecCoins(dstVal.TotalDelegatorShares).Sub(sdk.NewDecCoins(sdk.NewDecCoinFromDec(asset.Denom, sharesToSlash)))
 		k.SetValidator(ctx, dstVal)
 
+		sharesBefore := delegation.Shares
 		delegation.Shares = delegation.Shares.Sub(sharesToSlash)
 		k.SetDelegation(ctx, delAddr, dstVal.GetOperator(), asset.Denom, delegation)
+		if sharesBefore.IsPositive() {
+			if err := k.slashDelegationLocks(ctx, delAddr, dstVal.GetOperator(), asset.Denom, delegation.Shares.Quo(sharesBefore)); err != nil {
+				return err
+			}
+		}
+		if err := k.afterAllianceDelegationModified(ctx, delAddr, dstVal.GetOperator(), asset.Denom); err != nil {
+			return err
+		}
 	}
 	return nil
 }
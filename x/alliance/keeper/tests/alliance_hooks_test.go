@@ -0,0 +1,164 @@
+package tests_test
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	test_helpers "github.com/terra-money/alliance/app"
+	"github.com/terra-money/alliance/x/alliance/keeper"
+	"github.com/terra-money/alliance/x/alliance/types"
+)
+
+// newKeeperWithHooks builds an alliance keeper over the app's stores with the given hooks, since the app keeper
+// already has its hooks set
+func newKeeperWithHooks(app *test_helpers.App, hooks types.AllianceHooks) *keeper.Keeper {
+	k := keeper.NewKeeper(
+		app.AppCodec(),
+		app.GetKey(types.StoreKey),
+		app.GetSubspace(types.ModuleName),
+		app.AccountKeeper,
+		app.BankKeeper,
+		app.StakingKeeper,
+		app.DistrKeeper,
+		app.TransferKeeper,
+	)
+	return k.SetHooks(hooks)
+}
+
+// hooksRecorder records the alliance hooks in the order they were called
+type hooksRecorder struct {
+	calls []string
+}
+
+var _ types.AllianceHooks = &hooksRecorder{}
+
+func (h *hooksRecorder) AfterAllianceDelegationModified(_ sdk.Context, _ sdk.AccAddress, _ sdk.ValAddress, denom string) error {
+	h.calls = append(h.calls, "modified:"+denom)
+	return nil
+}
+
+func (h *hooksRecorder) BeforeAllianceDelegationRemoved(_ sdk.Context, _ sdk.AccAddress, _ sdk.ValAddress, denom string) error {
+	h.calls = append(h.calls, "removed:"+denom)
+	return nil
+}
+
+func (h *hooksRecorder) AfterAllianceRewardsClaimed(_ sdk.Context, _ sdk.AccAddress, _ sdk.ValAddress, denom string, _ sdk.Coins) error {
+	h.calls = append(h.calls, "claimed:"+denom)
+	return nil
+}
+
+func (h *hooksRecorder) AfterAllianceAssetCreated(_ sdk.Context, denom string) error {
+	h.calls = append(h.calls, "created:"+denom)
+	return nil
+}
+
+func (h *hooksRecorder) AfterAllianceAssetUpdated(_ sdk.Context, denom string) error {
+	h.calls = append(h.calls, "updated:"+denom)
+	return nil
+}
+
+func (h *hooksRecorder) AfterAllianceSlash(_ sdk.Context, _ sdk.ValAddress, _ sdk.Dec) error {
+	h.calls = append(h.calls, "slashed")
+	return nil
+}
+
+// failingHooks rejects every delegation change
+type failingHooks struct {
+	hooksRecorder
+}
+
+func (h *failingHooks) AfterAllianceDelegationModified(_ sdk.Context, _ sdk.AccAddress, _ sdk.ValAddress, _ string) error {
+	return types.ErrZeroDelegations
+}
+
+func TestAllianceHooksAreCalled(t *testing.T) {
+	// GIVEN: an alliance keeper with hooks and a delegation
+	app, ctx, val, user := setupEndBlockerTest(t, time.Now().UTC())
+	recorder := &hooksRecorder{}
+	k := newKeeperWithHooks(app, types.NewMultiAllianceHooks(recorder))
+	require.Panics(t, func() {
+		k.SetHooks(recorder)
+	})
+	require.Panics(t, func() {
+		app.AllianceKeeper.SetHooks(recorder)
+	})
+
+	// WHEN: the delegation is partially and then fully undelegated
+	_, err := k.Undelegate(ctx, user, val, sdk.NewCoin(AllianceDenom, sdk.NewInt(500_000_000)))
+	require.NoError(t, err)
+	_, err = k.Undelegate(ctx, user, val, sdk.NewCoin(AllianceDenom, sdk.NewInt(500_000_000)))
+	require.NoError(t, err)
+
+	// THEN: the rewards are claimed before each change and the removal is reported instead of a modification
+	require.Equal(t, []string{
+		"claimed:" + AllianceDenom, "modified:" + AllianceDenom,
+		"claimed:" + AllianceDenom, "removed:" + AllianceDenom,
+	}, recorder.calls)
+
+	// WHEN: the validator is slashed and governance updates and creates assets
+	recorder.calls = nil
+	require.NoError(t, k.SlashValidator(ctx, val.GetOperator(), sdk.MustNewDecFromStr("0.1")))
+	err = k.UpdateAlliance(ctx, &types.MsgUpdateAllianceProposal{
+		Denom:                AllianceDenomTwo,
+		RewardWeight:         sdk.NewDec(11),
+		TakeRate:             sdk.ZeroDec(),
+		RewardChangeRate:     sdk.OneDec(),
+		RewardChangeInterval: 0,
+	})
+	require.NoError(t, err)
+	err = k.CreateAlliance(ctx, &types.MsgCreateAllianceProposal{
+		Denom:                "newdenom",
+		RewardWeight:         sdk.OneDec(),
+		RewardWeightRange:    types.RewardWeightRange{Min: sdk.ZeroDec(), Max: sdk.NewDec(2)},
+		TakeRate:             sdk.ZeroDec(),
+		RewardChangeRate:     sdk.OneDec(),
+		RewardChangeInterval: 0,
+	})
+	require.NoError(t, err)
+
+	// THEN: the hooks are called for the validator and the assets
+	require.Equal(t, []string{"slashed", "updated:" + AllianceDenomTwo, "created:newdenom"}, recorder.calls)
+}
+
+func TestAllianceHooksErrorAbortsDelegation(t *testing.T) {
+	// GIVEN: hooks that reject delegation changes
+	app, ctx, val, user := setupEndBlockerTest(t, time.Now().UTC())
+	k := newKeeperWithHooks(app, &failingHooks{})
+
+	// WHEN: a delegation is changed
+	_, err := k.Undelegate(ctx, user, val, sdk.NewCoin(AllianceDenom, sdk.NewInt(1)))
+
+	// THEN: the error of the hook is returned
+	require.ErrorIs(t, err, types.ErrZeroDelegations)
+}
+
+// failingSlashHooks rejects every slash
+type failingSlashHooks struct {
+	hooksRecorder
+}
+
+func (h *failingSlashHooks) AfterAllianceSlash(_ sdk.Context, _ sdk.ValAddress, _ sdk.Dec) error {
+	return types.ErrZeroDelegations
+}
+
+func TestAllianceHooksErrorDiscardsSlash(t *testing.T) {
+	// GIVEN: hooks that reject slashes
+	app, ctx, val, _ := setupEndBlockerTest(t, time.Now().UTC())
+	k := newKeeperWithHooks(app, &failingSlashHooks{})
+	ctx = ctx.WithBlockHeight(2)
+
+	// WHEN: the validator is slashed through the staking hooks
+	err := k.StakingHooks().BeforeValidatorSlashed(ctx, val.GetOperator(), sdk.MustNewDecFromStr("0.1"))
+
+	// THEN: the error of the hook is returned and nothing of the slash is written
+	require.ErrorIs(t, err, types.ErrZeroDelegations)
+	slashed, err := k.GetAllianceValidator(ctx, val.GetOperator())
+	require.NoError(t, err)
+	require.Equal(t, val.ValidatorShares, slashed.ValidatorShares)
+	iter := k.IterateWeightChangeSnapshot(ctx, AllianceDenom, val.GetOperator(), 2)
+	require.False(t, iter.Valid())
+	iter.Close()
+}
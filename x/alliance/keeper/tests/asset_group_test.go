@@ -0,0 +1,318 @@
+package tests_test
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	"github.com/stretchr/testify/require"
+
+	test_helpers "github.com/terra-money/alliance/app"
+	"github.com/terra-money/alliance/x/alliance"
+	"github.com/terra-money/alliance/x/alliance/keeper"
+	"github.com/terra-money/alliance/x/alliance/types"
+)
+
+func assetRewardWeight(t *testing.T, app *test_helpers.App, ctx sdk.Context, denom string) sdk.Dec {
+	asset, found := app.AllianceKeeper.GetAssetByDenom(ctx, denom)
+	require.True(t, found)
+	return asset.RewardWeight
+}
+
+func TestCreateAssetGroup(t *testing.T) {
+	// GIVEN: the same amount delegated to two assets, one of them worth three times more
+	app, ctx, _, _ := setupEndBlockerTest(t, time.Now().UTC())
+	members := []types.AssetGroupMember{
+		types.NewAssetGroupMember(AllianceDenom, sdk.OneDec()),
+		types.NewAssetGroupMember(AllianceDenomTwo, sdk.NewDec(3)),
+	}
+
+	// WHEN: both assets are grouped with a reward weight of 8
+	err := app.AllianceKeeper.CreateAssetGroup(ctx, &types.MsgCreateAssetGroupProposal{
+		Name:           "group",
+		RewardWeight:   sdk.NewDec(8),
+		Members:        members,
+		UpdateInterval: time.Hour,
+	})
+	require.NoError(t, err)
+
+	// THEN: the reward weight is split by value
+	require.Equal(t, sdk.NewDec(2), assetRewardWeight(t, app, ctx, AllianceDenom))
+	require.Equal(t, sdk.NewDec(6), assetRewardWeight(t, app, ctx, AllianceDenomTwo))
+	res, err := keeper.NewQueryServerImpl(app.AllianceKeeper).AssetGroup(ctx, &types.QueryAssetGroupRequest{Name: "group"})
+	require.NoError(t, err)
+	require.Equal(t, members, res.Group.Members)
+	require.Equal(t, ctx.BlockTime(), res.Group.LastUpdateTime)
+	groups, err := keeper.NewQueryServerImpl(app.AllianceKeeper).AssetGroups(ctx, &types.QueryAssetGroupsRequest{})
+	require.NoError(t, err)
+	require.Len(t, groups.Groups, 1)
+
+	// WHEN: the group is created again and another group takes one of the members
+	err = app.AllianceKeeper.CreateAssetGroup(ctx, &types.MsgCreateAssetGroupProposal{
+		Name:         "group",
+		RewardWeight: sdk.NewDec(8),
+		Members:      members,
+	})
+	require.Error(t, err)
+	err = app.AllianceKeeper.CreateAssetGroup(ctx, &types.MsgCreateAssetGroupProposal{
+		Name:         "other",
+		RewardWeight: sdk.NewDec(8),
+		Members:      members[:1],
+	})
+
+	// THEN: both are rejected
+	require.ErrorIs(t, err, types.ErrAssetInGroup)
+
+	// THEN: the group is exported and valid
+	genesis := app.AllianceKeeper.ExportGenesis(ctx)
+	require.NoError(t, alliance.ValidateGenesis(genesis))
+	require.Len(t, genesis.AssetGroups, 1)
+}
+
+func TestAssetGroupMembersCannotBeChangedDirectly(t *testing.T) {
+	// GIVEN: a group of both assets
+	app, ctx, _, _ := setupEndBlockerTest(t, time.Now().UTC())
+	err := app.AllianceKeeper.CreateAssetGroup(ctx, &types.MsgCreateAssetGroupProposal{
+		Name:         "group",
+		RewardWeight: sdk.NewDec(8),
+		Members: []types.AssetGroupMember{
+			types.NewAssetGroupMember(AllianceDenom, sdk.OneDec()),
+			types.NewAssetGroupMember(AllianceDenomTwo, sdk.OneDec()),
+		},
+	})
+	require.NoError(t, err)
+
+	// WHEN: the reward weight of a member is updated and a member is migrated
+	updateErr := app.AllianceKeeper.UpdateAlliance(ctx, &types.MsgUpdateAllianceProposal{
+		Denom:            AllianceDenom,
+		RewardWeight:     sdk.NewDec(1),
+		TakeRate:         sdk.ZeroDec(),
+		RewardChangeRate: sdk.OneDec(),
+	})
+	migrateErr := app.AllianceKeeper.StartAssetMigration(ctx, AllianceDenomTwo, MigratedDenom, sdk.OneDec(), migrationRecipient)
+
+	// THEN: the changes are rejected
+	require.ErrorIs(t, updateErr, types.ErrAssetInGroup)
+	require.ErrorIs(t, migrateErr, types.ErrAssetInGroup)
+	require.Equal(t, sdk.NewDec(4), assetRewardWeight(t, app, ctx, AllianceDenom))
+}
+
+func TestAssetGroupRewardWeightsFollowValue(t *testing.T) {
+	// GIVEN: a group of both assets that is updated every hour
+	startTime := time.Now().UTC()
+	app, ctx, val, user := setupEndBlockerTest(t, startTime)
+	err := app.AllianceKeeper.CreateAssetGroup(ctx, &types.MsgCreateAssetGroupProposal{
+		Name:         "group",
+		RewardWeight: sdk.NewDec(8),
+		Members: []types.AssetGroupMember{
+			types.NewAssetGroupMember(AllianceDenom, sdk.OneDec()),
+			types.NewAssetGroupMember(AllianceDenomTwo, sdk.OneDec()),
+		},
+		UpdateInterval: time.Hour,
+	})
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewDec(4), assetRewardWeight(t, app, ctx, AllianceDenom))
+
+	// WHEN: half of one asset is undelegated and the end blocker runs before and after the update interval
+	_, err = app.AllianceKeeper.Undelegate(ctx, user, val, sdk.NewCoin(AllianceDenomTwo, sdk.NewInt(500_000_000)))
+	require.NoError(t, err)
+	ctx = ctx.WithBlockTime(startTime.Add(time.Minute)).WithBlockHeight(2)
+	alliance.EndBlocker(ctx, app.AllianceKeeper)
+
+	// THEN: the weights are only updated once the interval elapsed
+	require.Equal(t, sdk.NewDec(4), assetRewardWeight(t, app, ctx, AllianceDenom))
+
+	ctx = ctx.WithBlockTime(startTime.Add(time.Hour)).WithBlockHeight(3)
+	alliance.EndBlocker(ctx, app.AllianceKeeper)
+
+	// THEN: the weights follow the value once the interval elapsed. 16/3 is bounded by the reward weight range of the
+	// first asset, so the second asset gets the rest of the group reward weight instead of 8/3
+	require.Equal(t, sdk.NewDec(5), assetRewardWeight(t, app, ctx, AllianceDenom))
+	require.Equal(t, sdk.NewDec(3), assetRewardWeight(t, app, ctx, AllianceDenomTwo))
+
+	// THEN: the previous weights are kept in snapshots
+	iter := app.AllianceKeeper.IterateWeightChangeSnapshot(ctx, AllianceDenom, val.GetOperator(), 0)
+	defer iter.Close()
+	require.True(t, iter.Valid())
+}
+
+func TestAssetGroupRangesMustHoldRewardWeight(t *testing.T) {
+	// GIVEN: two assets whose reward weight ranges add up to [2, 17]
+	app, ctx, _, _ := setupEndBlockerTest(t, time.Now().UTC())
+	members := []types.AssetGroupMember{
+		types.NewAssetGroupMember(AllianceDenom, sdk.OneDec()),
+		types.NewAssetGroupMember(AllianceDenomTwo, sdk.OneDec()),
+	}
+
+	// WHEN: groups are created with reward weights outside of the ranges
+	tooHigh := app.AllianceKeeper.CreateAssetGroup(ctx, &types.MsgCreateAssetGroupProposal{
+		Name:         "group",
+		RewardWeight: sdk.NewDec(18),
+		Members:      members,
+	})
+	tooLow := app.AllianceKeeper.CreateAssetGroup(ctx, &types.MsgCreateAssetGroupProposal{
+		Name:         "group",
+		RewardWeight: sdk.OneDec(),
+		Members:      members,
+	})
+
+	// THEN: both are rejected
+	require.ErrorIs(t, tooHigh, types.ErrInvalidAssetGroup)
+	require.ErrorIs(t, tooLow, types.ErrInvalidAssetGroup)
+	_, found := app.AllianceKeeper.GetAssetGroup(ctx, "group")
+	require.False(t, found)
+}
+
+func TestAssetGroupRewardWeightsAddUpWithinRanges(t *testing.T) {
+	// GIVEN: the same value delegated to two assets with the ranges [0, 5] and [2, 12]
+	app, ctx, _, _ := setupEndBlockerTest(t, time.Now().UTC())
+
+	// WHEN: the group reward weight is split evenly
+	err := app.AllianceKeeper.CreateAssetGroup(ctx, &types.MsgCreateAssetGroupProposal{
+		Name:         "group",
+		RewardWeight: sdk.NewDec(16),
+		Members: []types.AssetGroupMember{
+			types.NewAssetGroupMember(AllianceDenom, sdk.OneDec()),
+			types.NewAssetGroupMember(AllianceDenomTwo, sdk.OneDec()),
+		},
+	})
+	require.NoError(t, err)
+
+	// THEN: the first asset is bounded by its range and the weights still add up to the group reward weight
+	require.Equal(t, sdk.NewDec(5), assetRewardWeight(t, app, ctx, AllianceDenom))
+	require.Equal(t, sdk.NewDec(11), assetRewardWeight(t, app, ctx, AllianceDenomTwo))
+}
+
+func TestDeleteAssetGroup(t *testing.T) {
+	// GIVEN: a group of both assets without any delegation of the second asset
+	app, ctx, val, user := setupEndBlockerTest(t, time.Now().UTC())
+	_, err := app.AllianceKeeper.Undelegate(ctx, user, val, sdk.NewCoin(AllianceDenomTwo, sdk.NewInt(1000_000_000)))
+	require.NoError(t, err)
+	err = app.AllianceKeeper.CreateAssetGroup(ctx, &types.MsgCreateAssetGroupProposal{
+		Name:         "group",
+		RewardWeight: sdk.NewDec(8),
+		Members: []types.AssetGroupMember{
+			types.NewAssetGroupMember(AllianceDenom, sdk.OneDec()),
+			types.NewAssetGroupMember(AllianceDenomTwo, sdk.OneDec()),
+		},
+	})
+	require.NoError(t, err)
+
+	// WHEN: the group is deleted
+	err = app.AllianceKeeper.DeleteAssetGroup(ctx, &types.MsgDeleteAssetGroupProposal{Name: "group"})
+	require.NoError(t, err)
+
+	// THEN: the group is gone and the members keep their last reward weight
+	_, found := app.AllianceKeeper.GetAssetGroup(ctx, "group")
+	require.False(t, found)
+	_, found = app.AllianceKeeper.GetAssetGroupByDenom(ctx, AllianceDenom)
+	require.False(t, found)
+	require.Equal(t, sdk.NewDec(5), assetRewardWeight(t, app, ctx, AllianceDenom))
+	require.Equal(t, sdk.NewDec(3), assetRewardWeight(t, app, ctx, AllianceDenomTwo))
+	err = app.AllianceKeeper.DeleteAssetGroup(ctx, &types.MsgDeleteAssetGroupProposal{Name: "group"})
+	require.ErrorIs(t, err, types.ErrAssetGroupNotFound)
+
+	// WHEN: a former member is updated and the other one is deleted
+	err = app.AllianceKeeper.UpdateAlliance(ctx, &types.MsgUpdateAllianceProposal{
+		Denom:            AllianceDenom,
+		RewardWeight:     sdk.NewDec(1),
+		TakeRate:         sdk.ZeroDec(),
+		RewardChangeRate: sdk.OneDec(),
+	})
+	require.NoError(t, err)
+	err = app.AllianceKeeper.DeleteAlliance(ctx, &types.MsgDeleteAllianceProposal{Denom: AllianceDenomTwo})
+	require.NoError(t, err)
+
+	// THEN: both changes are applied
+	require.Equal(t, sdk.NewDec(1), assetRewardWeight(t, app, ctx, AllianceDenom))
+	_, found = app.AllianceKeeper.GetAssetByDenom(ctx, AllianceDenomTwo)
+	require.False(t, found)
+}
+
+func TestAssetGroupFailuresOnlyQuarantineTheGroup(t *testing.T) {
+	// GIVEN: a group whose reward weights have to change while the rewards of the validator cannot be withdrawn, and
+	// a second group whose reward weight stays the same
+	startTime := time.Now().UTC()
+	app, ctx, val, user := setupEndBlockerTest(t, startTime)
+	err := app.AllianceKeeper.CreateAlliance(ctx, &types.MsgCreateAllianceProposal{
+		Denom:                "newdenom",
+		RewardWeight:         sdk.OneDec(),
+		RewardWeightRange:    types.RewardWeightRange{Min: sdk.ZeroDec(), Max: sdk.NewDec(2)},
+		TakeRate:             sdk.ZeroDec(),
+		RewardChangeRate:     sdk.OneDec(),
+		RewardChangeInterval: 0,
+	})
+	require.NoError(t, err)
+	err = app.AllianceKeeper.CreateAssetGroup(ctx, &types.MsgCreateAssetGroupProposal{
+		Name:         "first",
+		RewardWeight: sdk.NewDec(8),
+		Members: []types.AssetGroupMember{
+			types.NewAssetGroupMember(AllianceDenom, sdk.OneDec()),
+			types.NewAssetGroupMember(AllianceDenomTwo, sdk.OneDec()),
+		},
+		UpdateInterval: time.Hour,
+	})
+	require.NoError(t, err)
+	err = app.AllianceKeeper.CreateAssetGroup(ctx, &types.MsgCreateAssetGroupProposal{
+		Name:           "second",
+		RewardWeight:   sdk.OneDec(),
+		Members:        []types.AssetGroupMember{types.NewAssetGroupMember("newdenom", sdk.OneDec())},
+		UpdateInterval: time.Hour,
+	})
+	require.NoError(t, err)
+	require.NoError(t, app.AllianceKeeper.RebalanceBondTokenWeights(ctx, app.AllianceKeeper.GetAllAssets(ctx)))
+	_, err = app.AllianceKeeper.Undelegate(ctx, user, val, sdk.NewCoin(AllianceDenomTwo, sdk.NewInt(500_000_000)))
+	require.NoError(t, err)
+	ctx = ctx.WithBlockTime(startTime.Add(time.Hour)).WithBlockHeight(2)
+	bondDenom := app.StakingKeeper.BondDenom(ctx)
+	app.DistrKeeper.AllocateTokensToValidator(ctx, val.Validator, sdk.NewDecCoins(sdk.NewDecCoin(bondDenom, sdk.NewInt(1000_000))))
+	distrAddr := app.AccountKeeper.GetModuleAddress(distrtypes.ModuleName)
+	distrBalance := sdk.NewCoins(app.BankKeeper.GetBalance(ctx, distrAddr, bondDenom))
+	require.NoError(t, app.BankKeeper.SendCoinsFromModuleToAccount(ctx, distrtypes.ModuleName, sdk.AccAddress("treasury____________"), distrBalance))
+
+	// WHEN: the groups are updated by the end blocker hook
+	ctx = ctx.WithEventManager(sdk.NewEventManager())
+	assets := app.AllianceKeeper.GetAllAssets(ctx)
+	err = app.AllianceKeeper.AssetGroupsHook(ctx, assets)
+
+	// THEN: the failure of the first group is reported, its members keep their weights and are quarantined
+	require.NoError(t, err)
+	events := endBlockerErrorEvents(t, ctx)
+	require.Len(t, events, 1)
+	require.Equal(t, types.EndBlockerStepAssetGroup, events[0].Step)
+	require.Contains(t, events[0].Error, "asset group first")
+	for _, denom := range []string{AllianceDenom, AllianceDenomTwo} {
+		asset, _ := app.AllianceKeeper.GetAssetByDenom(ctx, denom)
+		require.True(t, asset.IsQuarantined)
+		require.Equal(t, sdk.NewDec(4), asset.RewardWeight)
+	}
+	for _, asset := range assets {
+		stored, _ := app.AllianceKeeper.GetAssetByDenom(ctx, asset.Denom)
+		require.Equal(t, stored, *asset)
+	}
+	first, _ := app.AllianceKeeper.GetAssetGroup(ctx, "first")
+	require.Equal(t, startTime, first.LastUpdateTime)
+
+	// THEN: the second group is still updated
+	second, _ := app.AllianceKeeper.GetAssetGroup(ctx, "second")
+	require.Equal(t, ctx.BlockTime(), second.LastUpdateTime)
+	newAsset, _ := app.AllianceKeeper.GetAssetByDenom(ctx, "newdenom")
+	require.False(t, newAsset.IsQuarantined)
+
+	// WHEN: the rewards can be withdrawn again and governance updates the first group
+	require.NoError(t, app.BankKeeper.SendCoinsFromAccountToModule(ctx, sdk.AccAddress("treasury____________"), distrtypes.ModuleName, distrBalance))
+	err = app.AllianceKeeper.UpdateAssetGroup(ctx, &types.MsgUpdateAssetGroupProposal{
+		Name:           "first",
+		RewardWeight:   sdk.NewDec(8),
+		Members:        first.Members,
+		UpdateInterval: time.Hour,
+	})
+
+	// THEN: the members are released from quarantine
+	require.NoError(t, err)
+	for _, denom := range []string{AllianceDenom, AllianceDenomTwo} {
+		asset, _ := app.AllianceKeeper.GetAssetByDenom(ctx, denom)
+		require.False(t, asset.IsQuarantined)
+	}
+}
@@ -662,6 +662,49 @@ func TestRewardRangeWithChangeRateOverTime(t *testing.T) {
 	}, updatedAsset)
 }
 
+func TestRewardWeightChangeAfterManyIntervals(t *testing.T) {
+	app, ctx := createTestContext(t)
+	changeInterval := time.Minute
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: types.DefaultParams(),
+		Assets: []types.AllianceAsset{
+			{
+				Denom:                AllianceDenom,
+				RewardWeight:         sdk.MustNewDecFromStr("0.075"),
+				RewardWeightRange:    types.RewardWeightRange{Min: sdk.MustNewDecFromStr("0.05"), Max: sdk.MustNewDecFromStr("0.10")},
+				TakeRate:             sdk.NewDec(0),
+				TotalTokens:          sdk.ZeroInt(),
+				RewardChangeRate:     sdk.MustNewDecFromStr("1.5"),
+				RewardChangeInterval: changeInterval,
+			},
+			{
+				Denom:                AllianceDenomTwo,
+				RewardWeight:         sdk.ZeroDec(),
+				RewardWeightRange:    types.RewardWeightRange{Min: sdk.ZeroDec(), Max: sdk.MustNewDecFromStr("0.10")},
+				TakeRate:             sdk.NewDec(0),
+				TotalTokens:          sdk.ZeroInt(),
+				RewardChangeRate:     sdk.MustNewDecFromStr("1.5"),
+				RewardChangeInterval: changeInterval,
+			},
+		},
+	})
+	asset, _ := app.AllianceKeeper.GetAssetByDenom(ctx, AllianceDenom)
+
+	// GIVEN: the chain was halted for a year, so 1.5 would be compounded for half a million intervals
+	ctx = ctx.WithBlockTime(asset.RewardStartTime.Add(time.Hour * 24 * 365))
+
+	// WHEN: applying the reward weight changes
+	err := app.AllianceKeeper.RewardWeightChangeHook(ctx, app.AllianceKeeper.GetAllAssets(ctx))
+	require.NoError(t, err)
+
+	// THEN: the reward weight stops at the max of the range instead of overflowing
+	updatedAsset, _ := app.AllianceKeeper.GetAssetByDenom(ctx, AllianceDenom)
+	require.Equal(t, sdk.MustNewDecFromStr("0.10"), updatedAsset.RewardWeight)
+	require.Equal(t, ctx.BlockTime(), updatedAsset.LastRewardChangeTime)
+	updatedAsset, _ = app.AllianceKeeper.GetAssetByDenom(ctx, AllianceDenomTwo)
+	require.True(t, updatedAsset.RewardWeight.IsZero())
+}
+
 func TestRewardWeightDecay(t *testing.T) {
 	var err error
 	app, ctx := createTestContext(t)
@@ -1121,3 +1164,367 @@ func TestRewardWeightRateChange(t *testing.T) {
 	alliance, _ = app.AllianceKeeper.GetAssetByDenom(ctx, alliance.Denom)
 	require.Equal(t, alliance.LastRewardChangeTime, ctx.BlockTime())
 }
+
+func TestRebalancingWithScaledRewardWeights(t *testing.T) {
+	app, ctx := createTestContext(t)
+	startTime := time.Now()
+	ctx = ctx.WithBlockTime(startTime).WithBlockHeight(1)
+	params := types.DefaultParams()
+	params.MaxTotalRewardWeight = sdk.NewDec(1)
+	params.RewardWeightCapMode = types.RewardWeightCapModeScale
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: params,
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset(AllianceDenom, sdk.NewDec(2), sdk.ZeroDec(), sdk.NewDec(4), sdk.ZeroDec(), startTime),
+			types.NewAllianceAsset(AllianceDenomTwo, sdk.NewDec(2), sdk.ZeroDec(), sdk.NewDec(4), sdk.ZeroDec(), startTime),
+		},
+	})
+	require.Equal(t, sdk.MustNewDecFromStr("0.25"), app.AllianceKeeper.GetRewardWeightScale(ctx))
+
+	// Accounts
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 4, sdk.NewCoins(
+		sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)),
+		sdk.NewCoin(AllianceDenomTwo, sdk.NewInt(1000_000)),
+	))
+	pks := test_helpers.CreateTestPubKeys(2)
+
+	valAddr1 := sdk.ValAddress(addrs[0])
+	_val1 := teststaking.NewValidator(t, valAddr1, pks[0])
+	_val1.Commission = stakingtypes.Commission{
+		CommissionRates: stakingtypes.CommissionRates{
+			Rate:          sdk.NewDec(0),
+			MaxRate:       sdk.NewDec(0),
+			MaxChangeRate: sdk.NewDec(0),
+		},
+		UpdateTime: time.Now(),
+	}
+	_val1.Status = stakingtypes.Bonded
+	test_helpers.RegisterNewValidator(t, app, ctx, _val1)
+	val1, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr1)
+	require.NoError(t, err)
+
+	user1 := addrs[2]
+	_, err = app.AllianceKeeper.Delegate(ctx, user1, val1, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+
+	// Only a quarter of the reward weight is used to mint voting power
+	assets := app.AllianceKeeper.GetAllAssets(ctx)
+	err = app.AllianceKeeper.RebalanceBondTokenWeights(ctx, assets)
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewInt(1_500_000), app.StakingKeeper.TotalBondedTokens(ctx))
+
+	// Lowering the weight of the other asset reduces the total weight and the scaling applied
+	err = app.AllianceKeeper.UpdateAlliance(ctx, &types.MsgUpdateAllianceProposal{
+		Denom:            AllianceDenomTwo,
+		RewardWeight:     sdk.ZeroDec(),
+		TakeRate:         sdk.ZeroDec(),
+		RewardChangeRate: sdk.OneDec(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, sdk.MustNewDecFromStr("0.5"), app.AllianceKeeper.GetRewardWeightScale(ctx))
+
+	// A snapshot keeps the previous effective reward weight for reward calculations
+	iter := app.AllianceKeeper.IterateWeightChangeSnapshot(ctx, AllianceDenom, valAddr1, 0)
+	require.True(t, iter.Valid())
+	var snapshot types.RewardWeightChangeSnapshot
+	app.AppCodec().MustUnmarshal(iter.Value(), &snapshot)
+	require.Equal(t, sdk.MustNewDecFromStr("0.5"), snapshot.PrevRewardWeight)
+	iter.Close()
+
+	assets = app.AllianceKeeper.GetAllAssets(ctx)
+	err = app.AllianceKeeper.RebalanceBondTokenWeights(ctx, assets)
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewInt(2_000_000), app.StakingKeeper.TotalBondedTokens(ctx))
+
+	_, stop := alliance.RunAllInvariants(ctx, app.AllianceKeeper)
+	require.False(t, stop)
+}
+
+func TestRewardWeightChangeStopsAtRewardWeightCap(t *testing.T) {
+	app, ctx := createTestContext(t)
+	startTime := time.Now()
+	ctx = ctx.WithBlockTime(startTime).WithBlockHeight(1)
+	changeInterval := time.Hour * 24
+	params := types.DefaultParams()
+	params.MaxTotalRewardWeight = sdk.NewDec(3)
+	params.RewardWeightCapMode = types.RewardWeightCapModeReject
+	growingAsset := types.NewAllianceAsset(AllianceDenom, sdk.NewDec(1), sdk.ZeroDec(), sdk.NewDec(10), sdk.ZeroDec(), startTime)
+	growingAsset.RewardChangeRate = sdk.NewDec(2)
+	growingAsset.RewardChangeInterval = changeInterval
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: params,
+		Assets: []types.AllianceAsset{
+			growingAsset,
+			types.NewAllianceAsset(AllianceDenomTwo, sdk.NewDec(1), sdk.ZeroDec(), sdk.NewDec(10), sdk.ZeroDec(), startTime),
+		},
+	})
+
+	// Without a cap the reward weight would grow to 4 after two intervals
+	ctx = ctx.WithBlockTime(startTime.Add(changeInterval * 2))
+	assets := app.AllianceKeeper.GetAllAssets(ctx)
+	err := app.AllianceKeeper.RewardWeightChangeHook(ctx, assets)
+	require.NoError(t, err)
+
+	asset, _ := app.AllianceKeeper.GetAssetByDenom(ctx, AllianceDenom)
+	require.Equal(t, sdk.NewDec(2), asset.RewardWeight)
+	require.Equal(t, ctx.BlockTime(), asset.LastRewardChangeTime)
+	require.Equal(t, sdk.OneDec(), app.AllianceKeeper.GetRewardWeightScale(ctx))
+}
+
+func TestRewardWeightFollowsCurve(t *testing.T) {
+	app, ctx := createTestContext(t)
+	startTime := time.Now()
+	ctx = ctx.WithBlockTime(startTime).WithBlockHeight(1)
+	curveAsset := types.NewAllianceAsset(AllianceDenom, sdk.NewDec(2), sdk.OneDec(), sdk.NewDec(10), sdk.ZeroDec(), startTime)
+	curveAsset.TotalTokens = sdk.NewInt(500)
+	curveAsset.RewardWeightCurve = types.NewRewardWeightCurve(sdk.NewInt(1000), []types.RewardWeightCurvePoint{
+		types.NewRewardWeightCurvePoint(sdk.ZeroDec(), sdk.NewDec(2)),
+		types.NewRewardWeightCurvePoint(sdk.OneDec(), sdk.MustNewDecFromStr("0.5")),
+	}, sdk.MustNewDecFromStr("0.1"))
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: types.DefaultParams(),
+		Assets: []types.AllianceAsset{curveAsset},
+	})
+
+	// Half of the target tokens is halfway between the points of the curve
+	err := app.AllianceKeeper.RewardWeightChangeHook(ctx, app.AllianceKeeper.GetAllAssets(ctx))
+	require.NoError(t, err)
+	asset, _ := app.AllianceKeeper.GetAssetByDenom(ctx, AllianceDenom)
+	require.Equal(t, sdk.MustNewDecFromStr("1.25"), asset.RewardWeight)
+
+	// Moves within the update threshold are ignored
+	asset.TotalTokens = sdk.NewInt(520)
+	app.AllianceKeeper.SetAsset(ctx, asset)
+	err = app.AllianceKeeper.RewardWeightChangeHook(ctx, app.AllianceKeeper.GetAllAssets(ctx))
+	require.NoError(t, err)
+	asset, _ = app.AllianceKeeper.GetAssetByDenom(ctx, AllianceDenom)
+	require.Equal(t, sdk.MustNewDecFromStr("1.25"), asset.RewardWeight)
+
+	// Above the target the curve is flat and clamped to the reward weight range
+	asset.TotalTokens = sdk.NewInt(2000)
+	app.AllianceKeeper.SetAsset(ctx, asset)
+	err = app.AllianceKeeper.RewardWeightChangeHook(ctx, app.AllianceKeeper.GetAllAssets(ctx))
+	require.NoError(t, err)
+	asset, _ = app.AllianceKeeper.GetAssetByDenom(ctx, AllianceDenom)
+	require.Equal(t, sdk.OneDec(), asset.RewardWeight)
+}
+
+func TestRebalanceHookOnlyRebalancesChangedValidators(t *testing.T) {
+	app, ctx := createTestContext(t)
+	startTime := time.Now()
+	ctx = ctx.WithBlockTime(startTime)
+	params := types.DefaultParams()
+	params.FullRebalanceThreshold = sdk.OneDec()
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: params,
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset(AllianceDenom, sdk.NewDec(1), sdk.ZeroDec(), sdk.NewDec(5), sdk.ZeroDec(), startTime),
+		},
+	})
+	moduleAddr := app.AccountKeeper.GetModuleAddress(types.ModuleName)
+
+	// GIVEN: two validators with the same alliance stake that were fully rebalanced
+	delegations := app.StakingKeeper.GetAllDelegations(ctx)
+	require.Len(t, delegations, 1)
+	valAddr0, err := sdk.ValAddressFromBech32(delegations[0].ValidatorAddress)
+	require.NoError(t, err)
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 4, sdk.NewCoins(
+		sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)),
+	))
+	valAddr1 := sdk.ValAddress(addrs[0])
+	_val1 := teststaking.NewValidator(t, valAddr1, test_helpers.CreateTestPubKeys(1)[0])
+	test_helpers.RegisterNewValidator(t, app, ctx, _val1)
+
+	val0, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr0)
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Delegate(ctx, addrs[1], val0, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+	val1, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr1)
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Delegate(ctx, addrs[2], val1, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+
+	err = app.AllianceKeeper.RebalanceHook(ctx, app.AllianceKeeper.GetAllAssets(ctx))
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewInt(500_000), moduleBondedAmount(t, app, ctx, moduleAddr, valAddr0))
+	require.Equal(t, sdk.NewInt(500_000), moduleBondedAmount(t, app, ctx, moduleAddr, valAddr1))
+
+	// WHEN: delegating to a single validator without crossing the full rebalance threshold
+	val1, err = app.AllianceKeeper.GetAllianceValidator(ctx, valAddr1)
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Delegate(ctx, addrs[3], val1, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+	err = app.AllianceKeeper.RebalanceHook(ctx, app.AllianceKeeper.GetAllAssets(ctx))
+	require.NoError(t, err)
+
+	// THEN: only that validator is rebalanced
+	require.Equal(t, sdk.NewInt(666_666), moduleBondedAmount(t, app, ctx, moduleAddr, valAddr1))
+	require.Equal(t, sdk.NewInt(500_000), moduleBondedAmount(t, app, ctx, moduleAddr, valAddr0))
+	require.Len(t, app.AllianceKeeper.ConsumeValidatorRebalanceEvents(ctx), 0)
+}
+
+func TestRebalanceHookRebalancesAllValidatorsAboveThreshold(t *testing.T) {
+	app, ctx := createTestContext(t)
+	startTime := time.Now()
+	ctx = ctx.WithBlockTime(startTime)
+	params := types.DefaultParams()
+	params.FullRebalanceThreshold = sdk.MustNewDecFromStr("0.1")
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: params,
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset(AllianceDenom, sdk.NewDec(1), sdk.ZeroDec(), sdk.NewDec(5), sdk.ZeroDec(), startTime),
+		},
+	})
+	moduleAddr := app.AccountKeeper.GetModuleAddress(types.ModuleName)
+
+	// GIVEN: two validators with the same alliance stake that were fully rebalanced
+	delegations := app.StakingKeeper.GetAllDelegations(ctx)
+	require.Len(t, delegations, 1)
+	valAddr0, err := sdk.ValAddressFromBech32(delegations[0].ValidatorAddress)
+	require.NoError(t, err)
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 4, sdk.NewCoins(
+		sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)),
+	))
+	valAddr1 := sdk.ValAddress(addrs[0])
+	_val1 := teststaking.NewValidator(t, valAddr1, test_helpers.CreateTestPubKeys(1)[0])
+	test_helpers.RegisterNewValidator(t, app, ctx, _val1)
+
+	val0, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr0)
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Delegate(ctx, addrs[1], val0, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+	val1, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr1)
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Delegate(ctx, addrs[2], val1, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+
+	err = app.AllianceKeeper.RebalanceHook(ctx, app.AllianceKeeper.GetAllAssets(ctx))
+	require.NoError(t, err)
+
+	// WHEN: a delegation to a single validator moves the bond amount per share by more than the threshold
+	val1, err = app.AllianceKeeper.GetAllianceValidator(ctx, valAddr1)
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Delegate(ctx, addrs[3], val1, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+	err = app.AllianceKeeper.RebalanceHook(ctx, app.AllianceKeeper.GetAllAssets(ctx))
+	require.NoError(t, err)
+
+	// THEN: every validator is rebalanced
+	require.Equal(t, sdk.NewInt(666_666), moduleBondedAmount(t, app, ctx, moduleAddr, valAddr1))
+	require.Equal(t, sdk.NewInt(333_334), moduleBondedAmount(t, app, ctx, moduleAddr, valAddr0))
+}
+
+func moduleBondedAmount(t *testing.T, app *test_helpers.App, ctx sdk.Context, moduleAddr sdk.AccAddress, valAddr sdk.ValAddress) sdk.Int {
+	t.Helper()
+	validator, found := app.StakingKeeper.GetValidator(ctx, valAddr)
+	require.True(t, found)
+	delegation, found := app.StakingKeeper.GetDelegation(ctx, moduleAddr, valAddr)
+	require.True(t, found)
+	return validator.TokensFromShares(delegation.GetShares()).TruncateInt()
+}
+
+func TestRebalanceIsLimitedPerBlockUntilConverged(t *testing.T) {
+	app, ctx := createTestContext(t)
+	startTime := time.Now()
+	ctx = ctx.WithBlockTime(startTime)
+	params := types.DefaultParams()
+	params.MaxRebalanceAmount = sdk.NewInt(300_000)
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: params,
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset(AllianceDenom, sdk.NewDec(1), sdk.ZeroDec(), sdk.NewDec(5), sdk.ZeroDec(), startTime),
+		},
+	})
+	moduleAddr := app.AccountKeeper.GetModuleAddress(types.ModuleName)
+	delegations := app.StakingKeeper.GetAllDelegations(ctx)
+	require.Len(t, delegations, 1)
+	valAddr, err := sdk.ValAddressFromBech32(delegations[0].ValidatorAddress)
+	require.NoError(t, err)
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 1, sdk.NewCoins(
+		sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)),
+	))
+
+	// GIVEN: a delegation that requires 1_000_000 bond tokens to be minted
+	val, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr)
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Delegate(ctx, addrs[0], val, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+
+	// WHEN: rebalancing in consecutive blocks
+	// THEN: at most 300_000 bond tokens are delegated per block and the remainder stays pending
+	for _, expected := range []struct {
+		bonded  int64
+		pending int64
+	}{
+		{300_000, 700_000},
+		{600_000, 400_000},
+		{900_000, 100_000},
+		{1000_000, 0},
+	} {
+		err = app.AllianceKeeper.RebalanceHook(ctx, app.AllianceKeeper.GetAllAssets(ctx))
+		require.NoError(t, err)
+		require.Equal(t, sdk.NewInt(expected.bonded), moduleBondedAmount(t, app, ctx, moduleAddr, valAddr))
+		require.Equal(t, sdk.NewInt(expected.pending), app.AllianceKeeper.GetPendingRebalance(ctx, valAddr))
+	}
+	// Nothing is queued anymore once the validator converged
+	require.Len(t, app.AllianceKeeper.ConsumeValidatorRebalanceEvents(ctx), 0)
+	require.False(t, app.AllianceKeeper.ConsumeAssetRebalanceEvent(ctx))
+
+	// WHEN: the reward weight drops and the module has to unbond 800_000 bond tokens
+	ctx = ctx.WithBlockHeight(ctx.BlockHeight() + 1)
+	asset, _ := app.AllianceKeeper.GetAssetByDenom(ctx, AllianceDenom)
+	asset.RewardWeight = sdk.MustNewDecFromStr("0.2")
+	err = app.AllianceKeeper.UpdateAllianceAsset(ctx, asset)
+	require.NoError(t, err)
+	err = app.AllianceKeeper.RebalanceHook(ctx, app.AllianceKeeper.GetAllAssets(ctx))
+	require.NoError(t, err)
+
+	// THEN: only 300_000 are unbonded and the rest is pending as a negative amount
+	require.Equal(t, sdk.NewInt(700_000), moduleBondedAmount(t, app, ctx, moduleAddr, valAddr))
+	require.Equal(t, sdk.NewInt(-500_000), app.AllianceKeeper.GetPendingRebalance(ctx, valAddr))
+}
+
+func TestRebalanceIsLimitedByFractionOfValidatorTokens(t *testing.T) {
+	app, ctx := createTestContext(t)
+	startTime := time.Now()
+	ctx = ctx.WithBlockTime(startTime)
+	params := types.DefaultParams()
+	params.MaxRebalanceAmount = sdk.NewInt(800_000)
+	params.MaxRebalanceFraction = sdk.MustNewDecFromStr("0.5")
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: params,
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset(AllianceDenom, sdk.NewDec(1), sdk.ZeroDec(), sdk.NewDec(5), sdk.ZeroDec(), startTime),
+		},
+	})
+	moduleAddr := app.AccountKeeper.GetModuleAddress(types.ModuleName)
+	delegations := app.StakingKeeper.GetAllDelegations(ctx)
+	require.Len(t, delegations, 1)
+	valAddr, err := sdk.ValAddressFromBech32(delegations[0].ValidatorAddress)
+	require.NoError(t, err)
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 1, sdk.NewCoins(
+		sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)),
+	))
+
+	// GIVEN: a validator with 1_000_000 tokens and a delegation that requires 1_000_000 bond tokens to be minted
+	val, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr)
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Delegate(ctx, addrs[0], val, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+
+	// WHEN: rebalancing
+	err = app.AllianceKeeper.RebalanceHook(ctx, app.AllianceKeeper.GetAllAssets(ctx))
+	require.NoError(t, err)
+
+	// THEN: the smaller limit of half the validator tokens applies
+	require.Equal(t, sdk.NewInt(500_000), moduleBondedAmount(t, app, ctx, moduleAddr, valAddr))
+	require.Equal(t, sdk.NewInt(500_000), app.AllianceKeeper.GetPendingRebalance(ctx, valAddr))
+
+	// WHEN: rebalancing in the next block with 1_500_000 validator tokens
+	err = app.AllianceKeeper.RebalanceHook(ctx, app.AllianceKeeper.GetAllAssets(ctx))
+	require.NoError(t, err)
+
+	// THEN: the rebalance converges
+	require.Equal(t, sdk.NewInt(1000_000), moduleBondedAmount(t, app, ctx, moduleAddr, valAddr))
+	require.Equal(t, sdk.ZeroInt(), app.AllianceKeeper.GetPendingRebalance(ctx, valAddr))
+}
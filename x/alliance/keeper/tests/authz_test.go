@@ -0,0 +1,60 @@
+package tests_test
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/terra-money/alliance/x/alliance/types"
+)
+
+func TestAllianceAuthorizationWithMsgExec(t *testing.T) {
+	// GIVEN: a delegator that granted a delegate authorization with a budget to a bot
+	startTime := time.Now().UTC()
+	app, ctx, val, user := setupEndBlockerTest(t, startTime)
+	_, err := app.AllianceKeeper.Undelegate(ctx, user, val, sdk.NewCoin(AllianceDenom, sdk.NewInt(500_000_000)))
+	require.NoError(t, err)
+	ctx = ctx.WithBlockTime(startTime.Add(app.StakingKeeper.UnbondingTime(ctx)).Add(time.Second)).WithBlockHeight(2)
+	require.NoError(t, app.AllianceKeeper.CompleteUndelegations(ctx))
+
+	bot := sdk.AccAddress("bot_________________")
+	authorization, err := types.NewAllianceAuthorization([]sdk.ValAddress{val.GetOperator()}, nil, []string{AllianceDenom},
+		types.AllianceAuthorizationType_ALLIANCE_AUTHORIZATION_TYPE_DELEGATE, sdk.NewCoins(sdk.NewCoin(AllianceDenom, sdk.NewInt(300_000_000))))
+	require.NoError(t, err)
+	require.NoError(t, app.AuthzKeeper.SaveGrant(ctx, bot, user, authorization, nil))
+
+	// WHEN: the bot delegates on behalf of the delegator
+	_, err = app.AuthzKeeper.DispatchActions(ctx, bot, []sdk.Msg{
+		types.NewMsgDelegate(user.String(), val.GetOperator().String(), sdk.NewCoin(AllianceDenom, sdk.NewInt(200_000_000))),
+	})
+	require.NoError(t, err)
+
+	// THEN: the delegation is made and the budget is decremented
+	require.Equal(t, sdk.NewInt(300_000_000), app.BankKeeper.GetBalance(ctx, user, AllianceDenom).Amount)
+	asset, found := app.AllianceKeeper.GetAssetByDenom(ctx, AllianceDenom)
+	require.True(t, found)
+	require.Equal(t, sdk.NewInt(700_000_000), asset.TotalTokens)
+	updated, _ := app.AuthzKeeper.GetAuthorization(ctx, bot, user, sdk.MsgTypeURL(&types.MsgDelegate{}))
+	require.Equal(t, sdk.NewCoins(sdk.NewCoin(AllianceDenom, sdk.NewInt(100_000_000))), updated.(*types.AllianceAuthorization).MaxTokens)
+
+	// WHEN: the bot exceeds the budget or delegates a denom that was not granted
+	_, err = app.AuthzKeeper.DispatchActions(ctx, bot, []sdk.Msg{
+		types.NewMsgDelegate(user.String(), val.GetOperator().String(), sdk.NewCoin(AllianceDenom, sdk.NewInt(200_000_000))),
+	})
+	require.ErrorIs(t, err, sdkerrors.ErrInsufficientFunds)
+	_, err = app.AuthzKeeper.DispatchActions(ctx, bot, []sdk.Msg{
+		types.NewMsgDelegate(user.String(), val.GetOperator().String(), sdk.NewCoin(AllianceDenomTwo, sdk.NewInt(1))),
+	})
+	require.ErrorIs(t, err, sdkerrors.ErrUnauthorized)
+
+	// WHEN: the bot sends a message type that was not granted
+	_, err = app.AuthzKeeper.DispatchActions(ctx, bot, []sdk.Msg{
+		types.NewMsgUndelegate(user.String(), val.GetOperator().String(), sdk.NewCoin(AllianceDenom, sdk.NewInt(1))),
+	})
+
+	// THEN: the message is rejected
+	require.Error(t, err)
+}
@@ -0,0 +1,138 @@
+package tests_test
+
+import (
+	"testing"
+	"time"
+
+	test_helpers "github.com/terra-money/alliance/app"
+	"github.com/terra-money/alliance/x/alliance"
+	"github.com/terra-money/alliance/x/alliance/keeper"
+	v6 "github.com/terra-money/alliance/x/alliance/migrations/v6"
+	"github.com/terra-money/alliance/x/alliance/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	"github.com/cosmos/cosmos-sdk/x/staking/teststaking"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatorAllianceDelegations(t *testing.T) {
+	app, ctx := createTestContext(t)
+	startTime := time.Now().UTC()
+	ctx = ctx.WithBlockTime(startTime).WithBlockHeight(1)
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: types.DefaultParams(),
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset(AllianceDenom, sdk.NewDec(2), sdk.ZeroDec(), sdk.NewDec(5), sdk.ZeroDec(), startTime),
+			types.NewAllianceAsset(AllianceDenomTwo, sdk.NewDec(10), sdk.ZeroDec(), sdk.NewDec(12), sdk.ZeroDec(), startTime),
+		},
+	})
+	delegations := app.StakingKeeper.GetAllDelegations(ctx)
+	valAddr1, err := sdk.ValAddressFromBech32(delegations[0].ValidatorAddress)
+	require.NoError(t, err)
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 3, sdk.NewCoins(
+		sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)),
+		sdk.NewCoin(AllianceDenomTwo, sdk.NewInt(1000_000)),
+	))
+	valAddr2 := sdk.ValAddress(addrs[2])
+	test_helpers.RegisterNewValidator(t, app, ctx, teststaking.NewValidator(t, valAddr2, test_helpers.CreateTestPubKeys(1)[0]))
+	queryServer := keeper.NewQueryServerImpl(app.AllianceKeeper)
+
+	delegate := func(delAddr sdk.AccAddress, valAddr sdk.ValAddress, coin sdk.Coin) {
+		val, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr)
+		require.NoError(t, err)
+		_, err = app.AllianceKeeper.Delegate(ctx, delAddr, val, coin)
+		require.NoError(t, err)
+	}
+	delegate(addrs[0], valAddr1, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	delegate(addrs[0], valAddr1, sdk.NewCoin(AllianceDenomTwo, sdk.NewInt(500_000)))
+	delegate(addrs[1], valAddr1, sdk.NewCoin(AllianceDenomTwo, sdk.NewInt(1000_000)))
+	delegate(addrs[0], valAddr2, sdk.NewCoin(AllianceDenomTwo, sdk.NewInt(500_000)))
+	alliance.EndBlocker(ctx, app.AllianceKeeper)
+
+	// All delegations to a validator are returned
+	res, err := queryServer.ValidatorAllianceDelegations(ctx, &types.QueryValidatorAllianceDelegationsRequest{
+		ValidatorAddr: valAddr1.String(),
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Delegations, 3)
+	for _, delegation := range res.Delegations {
+		require.Equal(t, valAddr1.String(), delegation.Delegation.ValidatorAddress)
+	}
+
+	// Delegations can be filtered by denom and paginated
+	res, err = queryServer.ValidatorAllianceDelegations(ctx, &types.QueryValidatorAllianceDelegationsRequest{
+		ValidatorAddr: valAddr1.String(),
+		Denom:         AllianceDenomTwo,
+		Pagination:    &query.PageRequest{Limit: 1, CountTotal: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Delegations, 1)
+	require.Equal(t, uint64(2), res.Pagination.Total)
+	require.Equal(t, AllianceDenomTwo, res.Delegations[0].Delegation.Denom)
+	res, err = queryServer.ValidatorAllianceDelegations(ctx, &types.QueryValidatorAllianceDelegationsRequest{
+		ValidatorAddr: valAddr1.String(),
+		Denom:         AllianceDenomTwo,
+		Pagination:    &query.PageRequest{Key: res.Pagination.NextKey, Limit: 1},
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Delegations, 1)
+	require.Equal(t, sdk.NewCoin(AllianceDenomTwo, sdk.NewInt(1000_000)), res.Delegations[0].Balance)
+
+	var iterated []types.Delegation
+	app.AllianceKeeper.IterateValidatorDelegations(ctx, valAddr2, func(d types.Delegation) bool {
+		iterated = append(iterated, d)
+		return false
+	})
+	require.Len(t, iterated, 1)
+	require.Equal(t, addrs[0].String(), iterated[0].DelegatorAddress)
+
+	// Removing a delegation removes it from the index
+	val1, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr1)
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Undelegate(ctx, addrs[1], val1, sdk.NewCoin(AllianceDenomTwo, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+	res, err = queryServer.ValidatorAllianceDelegations(ctx, &types.QueryValidatorAllianceDelegationsRequest{
+		ValidatorAddr: valAddr1.String(),
+		Denom:         AllianceDenomTwo,
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Delegations, 1)
+	require.Equal(t, addrs[0].String(), res.Delegations[0].Delegation.DelegatorAddress)
+	_, stop := alliance.DelegationIndexInvariant(app.AllianceKeeper)(ctx)
+	require.False(t, stop)
+
+	_, err = queryServer.ValidatorAllianceDelegations(ctx, &types.QueryValidatorAllianceDelegationsRequest{
+		ValidatorAddr: "invalid",
+	})
+	require.Error(t, err)
+}
+
+func TestMigrateDelegationsByValidatorIndex(t *testing.T) {
+	app, ctx, _, _ := setupEndBlockerTest(t, time.Now().UTC())
+	_, stop := alliance.DelegationIndexInvariant(app.AllianceKeeper)(ctx)
+	require.False(t, stop)
+
+	// GIVEN: a store from before the index existed
+	store := ctx.KVStore(app.GetKey(types.StoreKey))
+	iter := sdk.KVStorePrefixIterator(store, types.DelegationByValidatorIndexKey)
+	var keys [][]byte
+	for ; iter.Valid(); iter.Next() {
+		keys = append(keys, iter.Key())
+	}
+	iter.Close()
+	require.NotEmpty(t, keys)
+	for _, key := range keys {
+		store.Delete(key)
+	}
+	_, stop = alliance.DelegationIndexInvariant(app.AllianceKeeper)(ctx)
+	require.True(t, stop)
+
+	// WHEN: migrating the store
+	err := v6.Migrate(app.AllianceKeeper)(ctx)
+	require.NoError(t, err)
+
+	// THEN: the index is backfilled
+	_, stop = alliance.DelegationIndexInvariant(app.AllianceKeeper)(ctx)
+	require.False(t, stop)
+}
@@ -250,6 +250,79 @@ func TestDelegationWithUnknownAssets(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestDelegationToValidatorWorthNoTokens(t *testing.T) {
+	app, ctx := createTestContext(t)
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: types.DefaultParams(),
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset(AllianceDenom, sdk.NewDec(2), sdk.ZeroDec(), sdk.NewDec(5), sdk.NewDec(0), ctx.BlockTime()),
+		},
+	})
+	valAddr, err := sdk.ValAddressFromBech32(app.StakingKeeper.GetAllDelegations(ctx)[0].ValidatorAddress)
+	require.NoError(t, err)
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 2, sdk.NewCoins(sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000))))
+	val, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr)
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Delegate(ctx, addrs[0], val, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+
+	// GIVEN: the take rate left so few tokens of the asset that the delegations to the validator are worth no tokens
+	asset, found := app.AllianceKeeper.GetAssetByDenom(ctx, AllianceDenom)
+	require.True(t, found)
+	asset.TotalValidatorShares = asset.TotalValidatorShares.Mul(sdk.NewDec(10).Power(30))
+	app.AllianceKeeper.SetAsset(ctx, asset)
+	val, err = app.AllianceKeeper.GetAllianceValidator(ctx, valAddr)
+	require.NoError(t, err)
+	require.True(t, val.InvalidExRateWithAsset(asset))
+
+	// WHEN: delegating to or undelegating from the validator
+	_, err = app.AllianceKeeper.Delegate(ctx, addrs[1], val, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+
+	// THEN: both are rejected instead of dividing by zero tokens
+	require.ErrorIs(t, err, types.ErrInvalidExchangeRate)
+	_, err = app.AllianceKeeper.Undelegate(ctx, addrs[0], val, sdk.NewCoin(AllianceDenom, sdk.NewInt(1)))
+	require.Error(t, err)
+}
+
+func TestDelegationToDrainedAsset(t *testing.T) {
+	app, ctx := createTestContext(t)
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: types.DefaultParams(),
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset(AllianceDenom, sdk.NewDec(2), sdk.ZeroDec(), sdk.NewDec(5), sdk.NewDec(0), ctx.BlockTime()),
+		},
+	})
+	valAddr, err := sdk.ValAddressFromBech32(app.StakingKeeper.GetAllDelegations(ctx)[0].ValidatorAddress)
+	require.NoError(t, err)
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 2, sdk.NewCoins(sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000))))
+	val, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr)
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Delegate(ctx, addrs[0], val, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+
+	// GIVEN: the take rate drained the asset down to 2 tokens while its validator shares kept growing with delegations
+	multiplier := sdk.NewDec(10).Power(50)
+	asset, found := app.AllianceKeeper.GetAssetByDenom(ctx, AllianceDenom)
+	require.True(t, found)
+	asset.TotalTokens = sdk.NewInt(2)
+	asset.TotalValidatorShares = asset.TotalValidatorShares.Mul(multiplier)
+	app.AllianceKeeper.SetAsset(ctx, asset)
+	info, found := app.AllianceKeeper.GetAllianceValidatorInfo(ctx, valAddr)
+	require.True(t, found)
+	info.ValidatorShares = sdk.NewDecCoins(sdk.NewDecCoinFromDec(AllianceDenom, sdk.DecCoins(info.ValidatorShares).AmountOf(AllianceDenom).Mul(multiplier)))
+	app.AllianceKeeper.SetValidatorInfo(ctx, valAddr, info)
+	val, err = app.AllianceKeeper.GetAllianceValidator(ctx, valAddr)
+	require.NoError(t, err)
+	require.False(t, val.InvalidExRateWithAsset(asset))
+
+	// WHEN: delegating more tokens of the asset
+	_, err = app.AllianceKeeper.Delegate(ctx, addrs[1], val, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+
+	// THEN: the delegation is rejected instead of overflowing the validator shares
+	require.ErrorIs(t, err, types.ErrAssetDrained)
+	require.Equal(t, sdk.NewInt(1000_000), app.BankKeeper.GetBalance(ctx, addrs[1], AllianceDenom).Amount)
+}
+
 func TestSuccessfulRedelegation(t *testing.T) {
 	app, ctx := createTestContext(t)
 	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
@@ -0,0 +1,156 @@
+package tests_test
+
+import (
+	"testing"
+	"time"
+
+	test_helpers "github.com/terra-money/alliance/app"
+	"github.com/terra-money/alliance/x/alliance"
+	"github.com/terra-money/alliance/x/alliance/keeper"
+	"github.com/terra-money/alliance/x/alliance/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	minttypes "github.com/cosmos/cosmos-sdk/x/mint/types"
+	"github.com/stretchr/testify/require"
+)
+
+// claimRewardsWithDust delegates the same amount from two accounts and pays out an odd amount of rewards to them, so
+// that each claim truncates half a token
+func claimRewardsWithDust(t *testing.T, mode types.RewardDustSweepMode) (*test_helpers.App, sdk.Context, sdk.ValAddress, []sdk.AccAddress) {
+	app, ctx := createTestContext(t)
+	startTime := time.Now().UTC()
+	ctx = ctx.WithBlockTime(startTime).WithBlockHeight(1)
+	params := types.DefaultParams()
+	params.RewardDustSweepMode = mode
+	params.LastRewardDustSweepTime = startTime
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: params,
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset(AllianceDenom, sdk.NewDec(2), sdk.ZeroDec(), sdk.NewDec(5), sdk.ZeroDec(), startTime),
+		},
+	})
+	mintPoolAddr := app.AccountKeeper.GetModuleAddress(minttypes.ModuleName)
+	delegations := app.StakingKeeper.GetAllDelegations(ctx)
+	valAddr, err := sdk.ValAddressFromBech32(delegations[0].ValidatorAddress)
+	require.NoError(t, err)
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 2, sdk.NewCoins(sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000))))
+	for _, addr := range addrs {
+		val, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr)
+		require.NoError(t, err)
+		_, err = app.AllianceKeeper.Delegate(ctx, addr, val, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+		require.NoError(t, err)
+	}
+
+	err = app.BankKeeper.MintCoins(ctx, minttypes.ModuleName, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1001))))
+	require.NoError(t, err)
+	val, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr)
+	require.NoError(t, err)
+	err = app.AllianceKeeper.AddAssetsToRewardPool(ctx, mintPoolAddr, val, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1001))))
+	require.NoError(t, err)
+	for _, addr := range addrs {
+		val, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr)
+		require.NoError(t, err)
+		coins, err := app.AllianceKeeper.ClaimDelegationRewards(ctx, addr, val, AllianceDenom)
+		require.NoError(t, err)
+		require.Equal(t, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(500))), coins)
+	}
+
+	// The truncated halves add up to a whole token that is left in the rewards pool
+	queryServer := keeper.NewQueryServerImpl(app.AllianceKeeper)
+	res, err := queryServer.RewardDust(ctx, &types.QueryRewardDustRequest{})
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewDecCoins(sdk.NewDecCoin("stake", sdk.NewInt(1))), res.Dust)
+	require.Equal(t, sdk.NewInt(1), app.AllianceKeeper.GetRewardsPoolBalance(ctx).AmountOf("stake"))
+	return app, ctx, valAddr, addrs
+}
+
+func TestSweepRewardDustToCommunityPool(t *testing.T) {
+	app, ctx, _, _ := claimRewardsWithDust(t, types.RewardDustSweepModeCommunityPool)
+	communityPool := app.DistrKeeper.GetFeePoolCommunityCoins(ctx).AmountOf("stake")
+
+	// Nothing is swept before the interval has passed
+	err := app.AllianceKeeper.SweepRewardDustHook(ctx)
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewInt(1), app.AllianceKeeper.GetRewardsPoolBalance(ctx).AmountOf("stake"))
+
+	ctx = ctx.WithBlockTime(ctx.BlockTime().Add(app.AllianceKeeper.RewardDustSweepInterval(ctx) + time.Second)).WithBlockHeight(2)
+	alliance.EndBlocker(ctx, app.AllianceKeeper)
+	require.True(t, app.AllianceKeeper.GetAllRewardDust(ctx).IsZero())
+	require.True(t, app.AllianceKeeper.GetRewardsPoolBalance(ctx).AmountOf("stake").IsZero())
+	require.Equal(t, communityPool.Add(sdk.OneDec()), app.DistrKeeper.GetFeePoolCommunityCoins(ctx).AmountOf("stake"))
+	require.Equal(t, ctx.BlockTime(), app.AllianceKeeper.LastRewardDustSweepTime(ctx))
+
+	_, stop := alliance.RunAllInvariants(ctx, app.AllianceKeeper)
+	require.False(t, stop)
+}
+
+func TestRecycleRewardDust(t *testing.T) {
+	app, ctx, valAddr, addrs := claimRewardsWithDust(t, types.RewardDustSweepModeRecycle)
+	mintPoolAddr := app.AccountKeeper.GetModuleAddress(minttypes.ModuleName)
+
+	swept, err := app.AllianceKeeper.SweepRewardDust(ctx)
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1))), swept)
+	require.True(t, app.AllianceKeeper.GetAllRewardDust(ctx).IsZero())
+	require.Equal(t, swept, app.AllianceKeeper.GetAllRecycledRewardDust(ctx))
+
+	// The recycled token is added to the next rewards of a validator
+	err = app.BankKeeper.MintCoins(ctx, minttypes.ModuleName, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(999))))
+	require.NoError(t, err)
+	val, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr)
+	require.NoError(t, err)
+	err = app.AllianceKeeper.AddAssetsToRewardPool(ctx, mintPoolAddr, val, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(999))))
+	require.NoError(t, err)
+	require.True(t, app.AllianceKeeper.GetAllRecycledRewardDust(ctx).IsZero())
+	for _, addr := range addrs {
+		val, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr)
+		require.NoError(t, err)
+		coins, err := app.AllianceKeeper.ClaimDelegationRewards(ctx, addr, val, AllianceDenom)
+		require.NoError(t, err)
+		require.Equal(t, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(500))), coins)
+	}
+	require.True(t, app.AllianceKeeper.GetRewardsPoolBalance(ctx).AmountOf("stake").IsZero())
+
+	_, stop := alliance.RunAllInvariants(ctx, app.AllianceKeeper)
+	require.False(t, stop)
+}
+
+func TestUndelegationRemainderStaysWithOtherDelegations(t *testing.T) {
+	// GIVEN: a second delegation of 3 tokens whose share price is no longer round after the take rate is deducted
+	startTime := time.Now().UTC()
+	app, ctx, val, user := setupEndBlockerTest(t, startTime)
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 2, sdk.NewCoins(sdk.NewCoin(AllianceDenom, sdk.NewInt(3))))
+	_, err := app.AllianceKeeper.Delegate(ctx, addrs[1], val, sdk.NewCoin(AllianceDenom, sdk.NewInt(3)))
+	require.NoError(t, err)
+	ctx = ctx.WithBlockTime(startTime.Add(time.Minute*5 + time.Second)).WithBlockHeight(2)
+	alliance.EndBlocker(ctx, app.AllianceKeeper)
+	queryServer := keeper.NewQueryServerImpl(app.AllianceKeeper)
+	res, err := queryServer.AllianceDelegation(ctx, &types.QueryAllianceDelegationRequest{
+		DelegatorAddr: addrs[1].String(),
+		ValidatorAddr: val.OperatorAddress,
+		Denom:         AllianceDenom,
+	})
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewInt(1), res.Delegation.Balance.Amount)
+
+	// WHEN: the whole token that the second delegation is worth is undelegated
+	val, err = app.AllianceKeeper.GetAllianceValidator(ctx, val.GetOperator())
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Undelegate(ctx, addrs[1], val, res.Delegation.Balance)
+	require.NoError(t, err)
+
+	// THEN: the remaining shares are cleared, the remainder goes to the other delegation and no reward dust is tracked
+	_, found := app.AllianceKeeper.GetDelegation(ctx, addrs[1], val.GetOperator(), AllianceDenom)
+	require.False(t, found)
+	asset, _ := app.AllianceKeeper.GetAssetByDenom(ctx, AllianceDenom)
+	res, err = queryServer.AllianceDelegation(ctx, &types.QueryAllianceDelegationRequest{
+		DelegatorAddr: user.String(),
+		ValidatorAddr: val.OperatorAddress,
+		Denom:         AllianceDenom,
+	})
+	require.NoError(t, err)
+	require.Equal(t, asset.TotalTokens, res.Delegation.Balance.Amount)
+	require.True(t, app.AllianceKeeper.GetAllRewardDust(ctx).AmountOf(AllianceDenom).IsZero())
+	msg, broken := alliance.RunAllInvariants(ctx, app.AllianceKeeper)
+	require.False(t, broken, msg)
+}
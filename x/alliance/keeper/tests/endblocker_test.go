@@ -0,0 +1,233 @@
+package tests_test
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/require"
+
+	test_helpers "github.com/terra-money/alliance/app"
+	"github.com/terra-money/alliance/x/alliance"
+	"github.com/terra-money/alliance/x/alliance/keeper"
+	"github.com/terra-money/alliance/x/alliance/types"
+)
+
+// setupEndBlockerTest delegates both alliance assets to the first validator and returns the delegator
+func setupEndBlockerTest(t *testing.T, startTime time.Time) (*test_helpers.App, sdk.Context, types.AllianceValidator, sdk.AccAddress) {
+	app, ctx := createTestContext(t)
+	ctx = ctx.WithBlockTime(startTime).WithBlockHeight(1)
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: types.Params{
+			RewardDelayTime:       time.Minute * 60,
+			TakeRateClaimInterval: time.Minute * 5,
+			LastTakeRateClaimTime: startTime,
+		},
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset(AllianceDenom, sdk.NewDec(2), sdk.ZeroDec(), sdk.NewDec(5), sdk.MustNewDecFromStr("0.5"), startTime),
+			types.NewAllianceAsset(AllianceDenomTwo, sdk.NewDec(10), sdk.NewDec(2), sdk.NewDec(12), sdk.MustNewDecFromStr("0.5"), startTime),
+		},
+	})
+
+	delegations := app.StakingKeeper.GetAllDelegations(ctx)
+	valAddr, err := sdk.ValAddressFromBech32(delegations[0].ValidatorAddress)
+	require.NoError(t, err)
+	val, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr)
+	require.NoError(t, err)
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 1, sdk.NewCoins(
+		sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000_000)),
+		sdk.NewCoin(AllianceDenomTwo, sdk.NewInt(1000_000_000)),
+	))
+	_, err = app.AllianceKeeper.Delegate(ctx, addrs[0], val, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000_000)))
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Delegate(ctx, addrs[0], val, sdk.NewCoin(AllianceDenomTwo, sdk.NewInt(1000_000_000)))
+	require.NoError(t, err)
+	return app, ctx, val, addrs[0]
+}
+
+// drainModuleBalance moves the module balance of a denom away so that any transfer of that denom by the module fails
+func drainModuleBalance(t *testing.T, app *test_helpers.App, ctx sdk.Context, denom string, to sdk.AccAddress) sdk.Coins {
+	moduleAddr := app.AccountKeeper.GetModuleAddress(types.ModuleName)
+	balance := sdk.NewCoins(app.BankKeeper.GetBalance(ctx, moduleAddr, denom))
+	require.NoError(t, app.BankKeeper.SendCoinsFromModuleToAccount(ctx, types.ModuleName, to, balance))
+	return balance
+}
+
+func endBlockerErrorEvents(t *testing.T, ctx sdk.Context) []*types.EndBlockerErrorEvent {
+	var events []*types.EndBlockerErrorEvent
+	for _, event := range ctx.EventManager().ABCIEvents() {
+		if event.Type != proto.MessageName(&types.EndBlockerErrorEvent{}) {
+			continue
+		}
+		msg, err := sdk.ParseTypedEvent(event)
+		require.NoError(t, err)
+		if e, ok := msg.(*types.EndBlockerErrorEvent); ok {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+func TestEndBlockerQuarantinesAssetWhenTakeRateCannotBeDeducted(t *testing.T) {
+	// GIVEN: two assets with a take rate and no balance of the first asset in the module account
+	startTime := time.Now().UTC()
+	app, ctx, _, user := setupEndBlockerTest(t, startTime)
+	alliance.EndBlocker(ctx, app.AllianceKeeper)
+	drainModuleBalance(t, app, ctx, AllianceDenom, user)
+
+	// WHEN: the take rate is claimed at the end of the block
+	ctx = ctx.WithBlockTime(startTime.Add(time.Minute*5 + time.Second)).WithBlockHeight(2).WithEventManager(sdk.NewEventManager())
+	require.NotPanics(t, func() {
+		alliance.EndBlocker(ctx, app.AllianceKeeper)
+	})
+
+	// THEN: only the failing asset is rolled back and quarantined
+	asset, found := app.AllianceKeeper.GetAssetByDenom(ctx, AllianceDenom)
+	require.True(t, found)
+	require.True(t, asset.IsQuarantined)
+	require.Equal(t, sdk.NewInt(1000_000_000), asset.TotalTokens)
+
+	assetTwo, found := app.AllianceKeeper.GetAssetByDenom(ctx, AllianceDenomTwo)
+	require.True(t, found)
+	require.False(t, assetTwo.IsQuarantined)
+	require.Equal(t, sdk.NewInt(500_000_000), assetTwo.TotalTokens)
+
+	feeCollectorAddr := app.AccountKeeper.GetModuleAddress(authtypes.FeeCollectorName)
+	require.True(t, app.BankKeeper.GetBalance(ctx, feeCollectorAddr, AllianceDenom).IsZero())
+
+	// AND: an error event is emitted for the asset
+	events := endBlockerErrorEvents(t, ctx)
+	require.Len(t, events, 1)
+	require.Equal(t, types.EndBlockerStepDeductTakeRate, events[0].Step)
+	require.Equal(t, AllianceDenom, events[0].Denom)
+
+	// AND: the quarantine is visible in queries
+	queryServer := keeper.NewQueryServerImpl(app.AllianceKeeper)
+	res, err := queryServer.Alliance(ctx, &types.QueryAllianceRequest{Denom: AllianceDenom})
+	require.NoError(t, err)
+	require.True(t, res.Alliance.IsQuarantined)
+
+	// WHEN: the next take rate is claimed
+	ctx = ctx.WithBlockTime(startTime.Add(time.Minute*10 + time.Second)).WithBlockHeight(3).WithEventManager(sdk.NewEventManager())
+	alliance.EndBlocker(ctx, app.AllianceKeeper)
+
+	// THEN: the quarantined asset is skipped
+	asset, _ = app.AllianceKeeper.GetAssetByDenom(ctx, AllianceDenom)
+	require.Equal(t, sdk.NewInt(1000_000_000), asset.TotalTokens)
+	require.Len(t, endBlockerErrorEvents(t, ctx), 0)
+}
+
+func TestEndBlockerKeepsFailedUndelegationsQueued(t *testing.T) {
+	// GIVEN: an undelegation of an asset that the module cannot send back
+	startTime := time.Now().UTC()
+	app, ctx, val, user := setupEndBlockerTest(t, startTime)
+	_, err := app.AllianceKeeper.Undelegate(ctx, user, val, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000_000)))
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Undelegate(ctx, user, val, sdk.NewCoin(AllianceDenomTwo, sdk.NewInt(1000_000_000)))
+	require.NoError(t, err)
+	treasury := sdk.AccAddress("treasury____________")
+	drained := drainModuleBalance(t, app, ctx, AllianceDenom, treasury)
+
+	// WHEN: the undelegations complete
+	ctx = ctx.WithBlockTime(startTime.Add(app.StakingKeeper.UnbondingTime(ctx)).Add(time.Second)).WithBlockHeight(2).WithEventManager(sdk.NewEventManager())
+	require.NotPanics(t, func() {
+		alliance.EndBlocker(ctx, app.AllianceKeeper)
+	})
+
+	// THEN: the other undelegation is completed
+	require.Equal(t, sdk.NewInt(1000_000_000), app.BankKeeper.GetBalance(ctx, user, AllianceDenomTwo).Amount)
+	require.True(t, app.BankKeeper.GetBalance(ctx, user, AllianceDenom).IsZero())
+
+	// AND: the failed undelegation stays queued and its asset is quarantined
+	var queued []types.Undelegation
+	app.AllianceKeeper.IterateUndelegations(ctx, func(undelegation types.QueuedUndelegation, _ time.Time) bool {
+		for _, entry := range undelegation.Entries {
+			queued = append(queued, *entry)
+		}
+		return false
+	})
+	require.Len(t, queued, 1)
+	require.Equal(t, AllianceDenom, queued[0].Balance.Denom)
+	asset, _ := app.AllianceKeeper.GetAssetByDenom(ctx, AllianceDenom)
+	require.True(t, asset.IsQuarantined)
+
+	events := endBlockerErrorEvents(t, ctx)
+	require.Len(t, events, 1)
+	require.Equal(t, types.EndBlockerStepCompleteUndelegations, events[0].Step)
+
+	// WHEN: the module is funded again
+	require.NoError(t, app.BankKeeper.SendCoinsFromAccountToModule(ctx, treasury, types.ModuleName, drained))
+	ctx = ctx.WithBlockHeight(3).WithEventManager(sdk.NewEventManager())
+	alliance.EndBlocker(ctx, app.AllianceKeeper)
+
+	// THEN: the undelegation is retried and completed
+	require.Equal(t, sdk.NewInt(1000_000_000), app.BankKeeper.GetBalance(ctx, user, AllianceDenom).Amount)
+	queued = nil
+	app.AllianceKeeper.IterateUndelegations(ctx, func(undelegation types.QueuedUndelegation, _ time.Time) bool {
+		for _, entry := range undelegation.Entries {
+			queued = append(queued, *entry)
+		}
+		return false
+	})
+	require.Len(t, queued, 0)
+}
+
+func TestUpdateAllianceReleasesQuarantine(t *testing.T) {
+	// GIVEN: a quarantined asset
+	startTime := time.Now().UTC()
+	app, ctx, _, _ := setupEndBlockerTest(t, startTime)
+	app.AllianceKeeper.QuarantineAsset(ctx, AllianceDenom)
+
+	// WHEN: governance updates the asset
+	err := app.AllianceKeeper.UpdateAlliance(ctx, &types.MsgUpdateAllianceProposal{
+		Denom:                AllianceDenom,
+		RewardWeight:         sdk.NewDec(3),
+		TakeRate:             sdk.ZeroDec(),
+		RewardChangeRate:     sdk.OneDec(),
+		RewardChangeInterval: 0,
+	})
+	require.NoError(t, err)
+
+	// THEN: the asset is processed again by the end blocker
+	asset, found := app.AllianceKeeper.GetAssetByDenom(ctx, AllianceDenom)
+	require.True(t, found)
+	require.False(t, asset.IsQuarantined)
+	require.Equal(t, sdk.NewDec(3), asset.RewardWeight)
+}
+
+func TestRebalanceFailuresAreOnlyIsolatedInTheEndBlocker(t *testing.T) {
+	// GIVEN: a validator that has to be rebalanced down while its rewards cannot be withdrawn from distribution
+	startTime := time.Now().UTC()
+	app, ctx, val, user := setupEndBlockerTest(t, startTime)
+	require.NoError(t, app.AllianceKeeper.RebalanceBondTokenWeights(ctx, app.AllianceKeeper.GetAllAssets(ctx)))
+	_, err := app.AllianceKeeper.Undelegate(ctx, user, val, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000_000)))
+	require.NoError(t, err)
+	ctx = ctx.WithBlockHeight(2)
+	bondDenom := app.StakingKeeper.BondDenom(ctx)
+	app.DistrKeeper.AllocateTokensToValidator(ctx, val.Validator, sdk.NewDecCoins(sdk.NewDecCoin(bondDenom, sdk.NewInt(1000_000))))
+	distrAddr := app.AccountKeeper.GetModuleAddress(distrtypes.ModuleName)
+	distrBalance := sdk.NewCoins(app.BankKeeper.GetBalance(ctx, distrAddr, bondDenom))
+	require.NoError(t, app.BankKeeper.SendCoinsFromModuleToAccount(ctx, distrtypes.ModuleName, sdk.AccAddress("treasury____________"), distrBalance))
+
+	// WHEN: the validators are rebalanced directly
+	cacheCtx, _ := ctx.CacheContext()
+	err = app.AllianceKeeper.RebalanceBondTokenWeights(cacheCtx, app.AllianceKeeper.GetAllAssets(cacheCtx))
+
+	// THEN: the error is returned to the caller
+	require.Error(t, err)
+
+	// WHEN: the validators are rebalanced by the end blocker hook
+	ctx = ctx.WithEventManager(sdk.NewEventManager())
+	err = app.AllianceKeeper.RebalanceHook(ctx, app.AllianceKeeper.GetAllAssets(ctx))
+
+	// THEN: the failure is reported and the validator is queued to be rebalanced again
+	require.NoError(t, err)
+	events := endBlockerErrorEvents(t, ctx)
+	require.Len(t, events, 1)
+	require.Equal(t, types.EndBlockerStepRebalance, events[0].Step)
+	require.Equal(t, val.GetOperator().String(), events[0].Validator)
+	require.Equal(t, []sdk.ValAddress{val.GetOperator()}, app.AllianceKeeper.ConsumeValidatorRebalanceEvents(ctx))
+}
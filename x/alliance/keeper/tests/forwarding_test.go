@@ -0,0 +1,127 @@
+package tests_test
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	minttypes "github.com/cosmos/cosmos-sdk/x/mint/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/terra-money/alliance/x/alliance"
+	"github.com/terra-money/alliance/x/alliance/keeper"
+	"github.com/terra-money/alliance/x/alliance/types"
+)
+
+var forwardingReceiver = sdk.AccAddress("receiver____________").String()
+
+func TestSetRewardForwarding(t *testing.T) {
+	// GIVEN: a delegation
+	app, ctx, val, user := setupEndBlockerTest(t, time.Now().UTC())
+	msgServer := keeper.NewMsgServerImpl(app.AllianceKeeper)
+	queryServer := keeper.NewQueryServerImpl(app.AllianceKeeper)
+	other := sdk.AccAddress("other_delegator_____")
+
+	// WHEN: forwardings are set for the delegation, a missing delegation and an invalid channel
+	_, err := msgServer.SetRewardForwarding(ctx, types.NewMsgSetRewardForwarding(user.String(), val.OperatorAddress, AllianceDenom, "channel-0", forwardingReceiver, time.Minute))
+	require.NoError(t, err)
+	_, missingErr := msgServer.SetRewardForwarding(ctx, types.NewMsgSetRewardForwarding(other.String(), val.OperatorAddress, AllianceDenom, "channel-0", forwardingReceiver, time.Minute))
+	_, invalidErr := msgServer.SetRewardForwarding(ctx, types.NewMsgSetRewardForwarding(user.String(), val.OperatorAddress, AllianceDenom, "channel-0", forwardingReceiver, 0))
+
+	// THEN: only the forwarding of the existing delegation is stored
+	require.ErrorIs(t, missingErr, stakingtypes.ErrNoDelegatorForAddress)
+	require.ErrorIs(t, invalidErr, types.ErrInvalidRewardForwarding)
+	res, err := queryServer.RewardForwarding(ctx, &types.QueryRewardForwardingRequest{
+		DelegatorAddr: user.String(),
+		ValidatorAddr: val.OperatorAddress,
+		Denom:         AllianceDenom,
+	})
+	require.NoError(t, err)
+	require.Equal(t, types.NewRewardForwarding(user, val.GetOperator(), AllianceDenom, "channel-0", forwardingReceiver, time.Minute), res.Forwarding)
+
+	// WHEN: the forwarding is removed twice
+	_, err = msgServer.SetRewardForwarding(ctx, types.NewMsgSetRewardForwarding(user.String(), val.OperatorAddress, AllianceDenom, "", "", 0))
+	require.NoError(t, err)
+	_, err = msgServer.SetRewardForwarding(ctx, types.NewMsgSetRewardForwarding(user.String(), val.OperatorAddress, AllianceDenom, "", "", 0))
+
+	// THEN: the second removal fails
+	require.ErrorIs(t, err, types.ErrRewardForwardingNotFound)
+	_, found := app.AllianceKeeper.GetRewardForwarding(ctx, user, val.GetOperator(), AllianceDenom)
+	require.False(t, found)
+}
+
+func TestRewardForwardingEscrowsUnsendableRewards(t *testing.T) {
+	// GIVEN: a delegation that forwards its rewards through a channel that does not exist, and rewards to claim
+	app, ctx, val, user := setupEndBlockerTest(t, time.Now().UTC())
+	msgServer := keeper.NewMsgServerImpl(app.AllianceKeeper)
+	_, err := msgServer.SetRewardForwarding(ctx, types.NewMsgSetRewardForwarding(user.String(), val.OperatorAddress, AllianceDenom, "channel-0", forwardingReceiver, time.Minute))
+	require.NoError(t, err)
+	rewards := sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000_000)))
+	require.NoError(t, app.BankKeeper.MintCoins(ctx, minttypes.ModuleName, rewards))
+	require.NoError(t, app.AllianceKeeper.AddAssetsToRewardPool(ctx, app.AccountKeeper.GetModuleAddress(minttypes.ModuleName), val, rewards))
+	balanceBefore := app.BankKeeper.GetAllBalances(ctx, user)
+
+	// WHEN: the rewards are claimed
+	claimed, err := app.AllianceKeeper.ClaimDelegationRewards(ctx, user, val, AllianceDenom)
+
+	// THEN: the claim succeeds and the rewards are escrowed instead of paid out
+	require.NoError(t, err)
+	require.False(t, claimed.IsZero())
+	require.Equal(t, balanceBefore, app.BankKeeper.GetAllBalances(ctx, user))
+	require.Equal(t, claimed, app.AllianceKeeper.GetRewardForwardingEscrow(ctx, user))
+	_, stop := alliance.RewardForwardingEscrowInvariant(app.AllianceKeeper)(ctx)
+	require.False(t, stop)
+
+	// WHEN: the delegation is exported and imported
+	genesis := app.AllianceKeeper.ExportGenesis(ctx)
+
+	// THEN: the forwarding and the escrow are part of the genesis state
+	require.NoError(t, alliance.ValidateGenesis(genesis))
+	require.Len(t, genesis.RewardForwardings, 1)
+	require.Equal(t, []types.RewardForwardingEscrow{{DelegatorAddress: user.String(), Coins: claimed}}, genesis.RewardForwardingEscrows)
+
+	// WHEN: the escrow is claimed
+	res, err := msgServer.ClaimRewardForwardingEscrow(ctx, types.NewMsgClaimRewardForwardingEscrow(user.String()))
+	require.NoError(t, err)
+	_, err = msgServer.ClaimRewardForwardingEscrow(ctx, types.NewMsgClaimRewardForwardingEscrow(user.String()))
+
+	// THEN: the rewards are paid out once
+	require.ErrorIs(t, err, types.ErrEmptyRewardForwardingEscrow)
+	require.Equal(t, claimed, res.Coins)
+	require.Equal(t, balanceBefore.Add(claimed...), app.BankKeeper.GetAllBalances(ctx, user))
+	require.True(t, app.AllianceKeeper.GetRewardForwardingEscrow(ctx, user).IsZero())
+}
+
+func TestRewardForwardingIsRemovedWithDelegation(t *testing.T) {
+	// GIVEN: a delegation that forwards its rewards
+	app, ctx, val, user := setupEndBlockerTest(t, time.Now().UTC())
+	msgServer := keeper.NewMsgServerImpl(app.AllianceKeeper)
+	_, err := msgServer.SetRewardForwarding(ctx, types.NewMsgSetRewardForwarding(user.String(), val.OperatorAddress, AllianceDenom, "channel-0", forwardingReceiver, time.Minute))
+	require.NoError(t, err)
+
+	// WHEN: the delegation is fully undelegated
+	_, err = app.AllianceKeeper.Undelegate(ctx, user, val, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000_000)))
+	require.NoError(t, err)
+
+	// THEN: the forwarding is removed with it
+	_, found := app.AllianceKeeper.GetRewardForwarding(ctx, user, val.GetOperator(), AllianceDenom)
+	require.False(t, found)
+}
+
+func TestRewardForwardingEscrowInvariant(t *testing.T) {
+	// GIVEN: an escrow that is not backed by the reward forwarding account
+	app, ctx, _, _ := setupEndBlockerTest(t, time.Now().UTC())
+	genesis := app.AllianceKeeper.ExportGenesis(ctx)
+	genesis.RewardForwardingEscrows = []types.RewardForwardingEscrow{{
+		DelegatorAddress: forwardingReceiver,
+		Coins:            sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1))),
+	}}
+	app.AllianceKeeper.InitGenesis(ctx, genesis)
+
+	// WHEN: the invariant is checked
+	_, stop := alliance.RewardForwardingEscrowInvariant(app.AllianceKeeper)(ctx)
+
+	// THEN: it is broken
+	require.True(t, stop)
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/cosmos/cosmos-sdk/x/staking/teststaking"
 
 	test_helpers "github.com/terra-money/alliance/app"
+	"github.com/terra-money/alliance/x/alliance"
+	"github.com/terra-money/alliance/x/alliance/keeper"
 	"github.com/terra-money/alliance/x/alliance/types"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
@@ -125,6 +127,7 @@ func TestExportAndImportGenesis(t *testing.T) {
 	require.Greater(t, len(genesisState.Undelegations), 0)
 	require.Greater(t, len(genesisState.Redelegations), 0)
 	require.Greater(t, len(genesisState.RewardWeightChangeSnaphots), 0)
+	require.NoError(t, alliance.ValidateGenesis(genesisState))
 
 	store := ctx.KVStore(app.AllianceKeeper.StoreKey())
 	iter := store.Iterator(nil, nil)
@@ -158,3 +161,191 @@ func TestGenesisLastRewardClaimTime(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, app.AllianceKeeper.LastRewardClaimTime(ctx), ctx.BlockTime())
 }
+
+func TestValidateGenesisRejectsCorruptedExports(t *testing.T) {
+	// GIVEN: an export with delegations, a redelegation, an undelegation and a snapshot
+	startTime := time.Now().UTC()
+	app, ctx, val, user := setupEndBlockerTest(t, startTime)
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 2, sdk.NewCoins())
+	_val2 := teststaking.NewValidator(t, sdk.ValAddress(addrs[1]), test_helpers.CreateTestPubKeys(2)[1])
+	test_helpers.RegisterNewValidator(t, app, ctx, _val2)
+	val2, err := app.AllianceKeeper.GetAllianceValidator(ctx, sdk.ValAddress(addrs[1]))
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Redelegate(ctx, user, val, val2, sdk.NewCoin(AllianceDenom, sdk.NewInt(500_000_000)))
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Undelegate(ctx, user, val, sdk.NewCoin(AllianceDenomTwo, sdk.NewInt(500_000_000)))
+	require.NoError(t, err)
+	ctx = ctx.WithBlockHeight(2)
+	err = app.AllianceKeeper.UpdateAllianceAsset(ctx, types.NewAllianceAsset(AllianceDenom, sdk.NewDec(3), sdk.ZeroDec(), sdk.NewDec(5), sdk.ZeroDec(), startTime))
+	require.NoError(t, err)
+	require.NoError(t, alliance.ValidateGenesis(app.AllianceKeeper.ExportGenesis(ctx)))
+
+	tests := []struct {
+		name    string
+		corrupt func(state *types.GenesisState)
+		err     string
+	}{
+		{
+			name:    "invalid asset denom",
+			corrupt: func(state *types.GenesisState) { state.Assets[0].Denom = "1" },
+			err:     "asset denom 1 is invalid",
+		},
+		{
+			name:    "duplicated asset",
+			corrupt: func(state *types.GenesisState) { state.Assets = append(state.Assets, state.Assets[0]) },
+			err:     "is duplicated",
+		},
+		{
+			name:    "reward weight out of range",
+			corrupt: func(state *types.GenesisState) { state.Assets[0].RewardWeight = sdk.NewDec(100) },
+			err:     "is not within reward_weight_range",
+		},
+		{
+			name:    "take rate of one",
+			corrupt: func(state *types.GenesisState) { state.Assets[0].TakeRate = sdk.OneDec() },
+			err:     "take_rate 1.000000000000000000 must be more or equal to 0",
+		},
+		{
+			name: "validator shares do not match asset",
+			corrupt: func(state *types.GenesisState) {
+				state.Assets[0].TotalValidatorShares = state.Assets[0].TotalValidatorShares.Add(sdk.OneDec())
+			},
+			err: "TotalValidatorShares",
+		},
+		{
+			name: "delegator shares do not match validator",
+			corrupt: func(state *types.GenesisState) {
+				state.Delegations[0].Shares = state.Delegations[0].Shares.Add(sdk.OneDec())
+			},
+			err: "TotalDelegatorShares",
+		},
+		{
+			name:    "delegation without validator info",
+			corrupt: func(state *types.GenesisState) { state.ValidatorInfos = state.ValidatorInfos[:1] },
+			err:     "without validator info",
+		},
+		{
+			name: "redelegation without delegation",
+			corrupt: func(state *types.GenesisState) {
+				state.Redelegations[0].Redelegation.DelegatorAddress = sdk.AccAddress("other_delegator_____").String()
+			},
+			err: "references a missing delegation",
+		},
+		{
+			name: "snapshot of unknown asset",
+			corrupt: func(state *types.GenesisState) {
+				state.RewardWeightChangeSnaphots[0].Denom = "unknown"
+			},
+			err: "references unknown asset unknown",
+		},
+		{
+			name: "duplicated snapshot",
+			corrupt: func(state *types.GenesisState) {
+				state.RewardWeightChangeSnaphots = append(state.RewardWeightChangeSnaphots, state.RewardWeightChangeSnaphots[0])
+			},
+			err: "at height 2 is duplicated",
+		},
+		{
+			name: "snapshot index above validator history",
+			corrupt: func(state *types.GenesisState) {
+				state.RewardWeightChangeSnaphots[0].Snapshot.RewardHistories = []types.RewardHistory{
+					{Denom: "stake", Index: sdk.NewDec(1)},
+				}
+			},
+			err: "stake reward index above the validator reward history",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// WHEN: a fresh export is corrupted
+			state := app.AllianceKeeper.ExportGenesis(ctx)
+			tc.corrupt(state)
+
+			// THEN: the validation fails with a precise error
+			err := alliance.ValidateGenesis(state)
+			require.ErrorIs(t, err, types.ErrInvalidGenesisState)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestPrepForZeroHeightGenesis(t *testing.T) {
+	// GIVEN: rebalanced module delegations, unclaimed rewards and a reward weight change snapshot
+	startTime := time.Now().UTC()
+	app, ctx, val, user := setupEndBlockerTest(t, startTime)
+	alliance.EndBlocker(ctx, app.AllianceKeeper)
+	moduleAddr := app.AccountKeeper.GetModuleAddress(types.ModuleName)
+	_, found := app.StakingKeeper.GetDelegation(ctx, moduleAddr, val.GetOperator())
+	require.True(t, found)
+
+	rewards := sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000_000)))
+	require.NoError(t, app.BankKeeper.MintCoins(ctx, minttypes.ModuleName, rewards))
+	require.NoError(t, app.BankKeeper.SendCoinsFromModuleToAccount(ctx, minttypes.ModuleName, user, rewards))
+	val, err := app.AllianceKeeper.GetAllianceValidator(ctx, val.GetOperator())
+	require.NoError(t, err)
+	require.NoError(t, app.AllianceKeeper.AddAssetsToRewardPool(ctx, user, val, rewards))
+	ctx = ctx.WithBlockHeight(2)
+	err = app.AllianceKeeper.UpdateAllianceAsset(ctx, types.NewAllianceAsset(AllianceDenom, sdk.NewDec(3), sdk.ZeroDec(), sdk.NewDec(5), sdk.MustNewDecFromStr("0.5"), startTime))
+	require.NoError(t, err)
+	require.NotEmpty(t, app.AllianceKeeper.ExportGenesis(ctx).RewardWeightChangeSnaphots)
+	balanceBefore := app.BankKeeper.GetBalance(ctx, user, "stake")
+
+	// WHEN: the state is prepared for a zero height export
+	require.NoError(t, app.AllianceKeeper.PrepForZeroHeightGenesis(ctx))
+
+	// THEN: the rewards were paid out
+	require.True(t, app.BankKeeper.GetBalance(ctx, user, "stake").Amount.GT(balanceBefore.Amount))
+
+	// AND: the reward bookkeeping is reset
+	genesis := app.AllianceKeeper.ExportGenesis(ctx)
+	require.NoError(t, alliance.ValidateGenesis(genesis))
+	require.Empty(t, genesis.RewardWeightChangeSnaphots)
+	for _, info := range genesis.ValidatorInfos {
+		require.Empty(t, info.Validator.GlobalRewardHistory)
+	}
+	require.Len(t, genesis.Delegations, 2)
+	for _, delegation := range genesis.Delegations {
+		require.Empty(t, delegation.RewardHistory)
+		require.Equal(t, uint64(0), delegation.LastRewardClaimHeight)
+	}
+
+	// AND: the module delegation is removed
+	_, found = app.StakingKeeper.GetDelegation(ctx, moduleAddr, val.GetOperator())
+	require.False(t, found)
+	_, stop := alliance.RunAllInvariants(ctx, app.AllianceKeeper)
+	require.False(t, stop)
+
+	// WHEN: the first block of the restarted chain ends
+	ctx = ctx.WithBlockHeight(3)
+	alliance.EndBlocker(ctx, app.AllianceKeeper)
+
+	// THEN: the module delegation is rebuilt
+	_, found = app.StakingKeeper.GetDelegation(ctx, moduleAddr, val.GetOperator())
+	require.True(t, found)
+}
+
+func TestPrepForZeroHeightGenesisPaysOutForwardedRewards(t *testing.T) {
+	// GIVEN: a delegation that forwards its rewards and unclaimed rewards
+	app, ctx, val, user := setupEndBlockerTest(t, time.Now().UTC())
+	alliance.EndBlocker(ctx, app.AllianceKeeper)
+	_, err := keeper.NewMsgServerImpl(app.AllianceKeeper).SetRewardForwarding(ctx, types.NewMsgSetRewardForwarding(user.String(), val.OperatorAddress, AllianceDenom, "channel-0", forwardingReceiver, time.Minute))
+	require.NoError(t, err)
+	rewards := sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000_000)))
+	require.NoError(t, app.BankKeeper.MintCoins(ctx, minttypes.ModuleName, rewards))
+	val, err = app.AllianceKeeper.GetAllianceValidator(ctx, val.GetOperator())
+	require.NoError(t, err)
+	require.NoError(t, app.AllianceKeeper.AddAssetsToRewardPool(ctx, app.AccountKeeper.GetModuleAddress(minttypes.ModuleName), val, rewards))
+	ctx = ctx.WithBlockHeight(2)
+	balanceBefore := app.BankKeeper.GetBalance(ctx, user, "stake")
+
+	// WHEN: the state is prepared for a zero height export
+	require.NoError(t, app.AllianceKeeper.PrepForZeroHeightGenesis(ctx))
+
+	// THEN: the rewards are paid out to the delegator instead of being forwarded or escrowed
+	require.True(t, app.BankKeeper.GetBalance(ctx, user, "stake").Amount.GT(balanceBefore.Amount))
+	require.True(t, app.AllianceKeeper.GetRewardForwardingEscrow(ctx, user).IsZero())
+	require.True(t, app.AllianceKeeper.GetRewardForwardingBalance(ctx).IsZero())
+	genesis := app.AllianceKeeper.ExportGenesis(ctx)
+	require.Empty(t, genesis.PendingRewardForwards)
+	require.Len(t, genesis.RewardForwardings, 1)
+}
@@ -799,6 +799,7 @@ func TestQueryValidator(t *testing.T) {
 			sdk.NewDecCoinFromDec(AllianceDenom, sdk.NewDec(1000_000)),
 			sdk.NewDecCoinFromDec(AllianceDenomTwo, sdk.NewDec(1000_000)),
 		),
+		BondedAmount: sdk.ZeroDec(),
 	}, queryVal)
 }
 
@@ -890,6 +891,7 @@ func TestQueryValidators(t *testing.T) {
 					TotalStaked: sdk.NewDecCoins(
 						sdk.NewDecCoinFromDec(AllianceDenom, sdk.NewDec(1000_000)),
 					),
+					BondedAmount: sdk.ZeroDec(),
 				},
 			},
 			Pagination: queryVal.Pagination,
@@ -908,6 +910,7 @@ func TestQueryValidators(t *testing.T) {
 					TotalStaked: sdk.NewDecCoins(
 						sdk.NewDecCoinFromDec(AllianceDenomTwo, sdk.NewDec(1000_000)),
 					),
+					BondedAmount: sdk.ZeroDec(),
 				},
 			},
 			Pagination: queryVal.Pagination,
@@ -940,6 +943,7 @@ func TestQueryValidators(t *testing.T) {
 					TotalStaked: sdk.NewDecCoins(
 						sdk.NewDecCoinFromDec(AllianceDenom, sdk.NewDec(1000_000)),
 					),
+					BondedAmount: sdk.ZeroDec(),
 				},
 			},
 			Pagination: queryVal2.Pagination,
@@ -958,9 +962,163 @@ func TestQueryValidators(t *testing.T) {
 					TotalStaked: sdk.NewDecCoins(
 						sdk.NewDecCoinFromDec(AllianceDenomTwo, sdk.NewDec(1000_000)),
 					),
+					BondedAmount: sdk.ZeroDec(),
 				},
 			},
 			Pagination: queryVal2.Pagination,
 		}, queryVal2)
 	}
 }
+
+func TestQueryPendingRebalances(t *testing.T) {
+	// GIVEN: THE BLOCKCHAIN WITH A REBALANCE LIMIT AND A DELEGATION LARGER THAN THE LIMIT
+	app, ctx := createTestContext(t)
+	startTime := time.Now()
+	ctx = ctx.WithBlockTime(startTime).WithBlockHeight(1)
+	params := types.DefaultParams()
+	params.MaxRebalanceAmount = sdk.NewInt(300_000)
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: params,
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset(AllianceDenom, sdk.NewDec(1), sdk.ZeroDec(), sdk.NewDec(5), sdk.ZeroDec(), startTime),
+		},
+	})
+	queryServer := keeper.NewQueryServerImpl(app.AllianceKeeper)
+	delegations := app.StakingKeeper.GetAllDelegations(ctx)
+	valAddr, err := sdk.ValAddressFromBech32(delegations[0].ValidatorAddress)
+	require.NoError(t, err)
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 1, sdk.NewCoins(
+		sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)),
+	))
+	val, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr)
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Delegate(ctx, addrs[0], val, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+	err = app.AllianceKeeper.RebalanceHook(ctx, app.AllianceKeeper.GetAllAssets(ctx))
+	require.NoError(t, err)
+
+	// WHEN: QUERYING THE PENDING REBALANCE OF THE VALIDATOR
+	pendingRes, err := queryServer.PendingRebalance(ctx, &types.QueryPendingRebalanceRequest{
+		ValidatorAddr: valAddr.String(),
+	})
+
+	// THEN: VALIDATE THAT THE REMAINDER OF THE REBALANCE IS PENDING
+	require.NoError(t, err)
+	require.Equal(t, &types.QueryPendingRebalanceResponse{
+		ValidatorAddr: valAddr.String(),
+		Amount:        sdk.NewInt(700_000),
+	}, pendingRes)
+
+	// WHEN: QUERYING ALL PENDING REBALANCES
+	allPendingRes, err := queryServer.AllPendingRebalances(ctx, &types.QueryAllPendingRebalancesRequest{})
+
+	// THEN: VALIDATE THAT ONLY THE VALIDATOR WITH A PENDING REBALANCE IS RETURNED
+	require.NoError(t, err)
+	require.Equal(t, []types.QueryPendingRebalanceResponse{
+		{
+			ValidatorAddr: valAddr.String(),
+			Amount:        sdk.NewInt(700_000),
+		},
+	}, allPendingRes.PendingRebalances)
+
+	// WHEN: QUERYING WITH AN INVALID VALIDATOR ADDRESS
+	_, err = queryServer.PendingRebalance(ctx, &types.QueryPendingRebalanceRequest{
+		ValidatorAddr: "invalid",
+	})
+
+	// THEN: VALIDATE THAT THE REQUEST IS REJECTED
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestQueryRebalancePreview(t *testing.T) {
+	// GIVEN: THE BLOCKCHAIN WITH TWO ALLIANCES, TWO VALIDATORS AND A REBALANCE LIMIT
+	app, ctx := createTestContext(t)
+	startTime := time.Now()
+	ctx = ctx.WithBlockTime(startTime).WithBlockHeight(1)
+	params := types.DefaultParams()
+	params.MaxRebalanceAmount = sdk.NewInt(1000_000)
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: params,
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset(AllianceDenom, sdk.NewDec(1), sdk.ZeroDec(), sdk.NewDec(5), sdk.ZeroDec(), startTime),
+			types.NewAllianceAsset(AllianceDenomTwo, sdk.MustNewDecFromStr("0.5"), sdk.ZeroDec(), sdk.NewDec(5), sdk.ZeroDec(), startTime),
+		},
+	})
+	queryServer := keeper.NewQueryServerImpl(app.AllianceKeeper)
+	delegations := app.StakingKeeper.GetAllDelegations(ctx)
+	valAddr0, err := sdk.ValAddressFromBech32(delegations[0].ValidatorAddress)
+	require.NoError(t, err)
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 2, sdk.NewCoins(
+		sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)),
+		sdk.NewCoin(AllianceDenomTwo, sdk.NewInt(1000_000)),
+	))
+	valAddr1 := sdk.ValAddress(addrs[0])
+	_val1 := teststaking.NewValidator(t, valAddr1, test_helpers.CreateTestPubKeys(1)[0])
+	test_helpers.RegisterNewValidator(t, app, ctx, _val1)
+
+	val0, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr0)
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Delegate(ctx, addrs[1], val0, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+	val0, err = app.AllianceKeeper.GetAllianceValidator(ctx, valAddr0)
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Delegate(ctx, addrs[1], val0, sdk.NewCoin(AllianceDenomTwo, sdk.NewInt(500_000)))
+	require.NoError(t, err)
+	val1, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr1)
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Delegate(ctx, addrs[1], val1, sdk.NewCoin(AllianceDenomTwo, sdk.NewInt(500_000)))
+	require.NoError(t, err)
+
+	// WHEN: QUERYING THE REBALANCE PREVIEW
+	previewRes, err := queryServer.RebalancePreview(ctx, &types.QueryRebalancePreviewRequest{})
+
+	// THEN: VALIDATE THE EXPECTED AMOUNTS AND DELTAS PER VALIDATOR
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewInt(1000_000), previewRes.NativeBondAmount)
+	require.Empty(t, previewRes.UnbondedValidatorShares)
+	require.Len(t, previewRes.Validators, 2)
+	previews := make(map[string]types.RebalancePreviewValidator)
+	for _, preview := range previewRes.Validators {
+		previews[preview.ValidatorAddr] = preview
+	}
+	require.Equal(t, types.RebalancePreviewValidator{
+		ValidatorAddr:       valAddr0.String(),
+		CurrentBondedAmount: sdk.ZeroDec(),
+		ExpectedBondAmount:  sdk.NewDec(1250_000),
+		ExpectedBondAmountPerAsset: []sdk.DecCoin{
+			sdk.NewDecCoinFromDec(AllianceDenom, sdk.NewDec(1000_000)),
+			sdk.NewDecCoinFromDec(AllianceDenomTwo, sdk.NewDec(250_000)),
+		},
+		Delta:        sdk.NewInt(1000_000),
+		PendingDelta: sdk.NewInt(250_000),
+	}, previews[valAddr0.String()])
+	require.Equal(t, types.RebalancePreviewValidator{
+		ValidatorAddr:       valAddr1.String(),
+		CurrentBondedAmount: sdk.ZeroDec(),
+		ExpectedBondAmount:  sdk.NewDec(250_000),
+		ExpectedBondAmountPerAsset: []sdk.DecCoin{
+			sdk.NewDecCoinFromDec(AllianceDenom, sdk.ZeroDec()),
+			sdk.NewDecCoinFromDec(AllianceDenomTwo, sdk.NewDec(250_000)),
+		},
+		Delta:        sdk.NewInt(250_000),
+		PendingDelta: sdk.ZeroInt(),
+	}, previews[valAddr1.String()])
+
+	// WHEN: REBALANCING AND QUERYING THE PREVIEW AGAIN
+	err = app.AllianceKeeper.RebalanceHook(ctx, app.AllianceKeeper.GetAllAssets(ctx))
+	require.NoError(t, err)
+	previewRes, err = queryServer.RebalancePreview(ctx, &types.QueryRebalancePreviewRequest{})
+	require.NoError(t, err)
+
+	// THEN: VALIDATE THAT ONLY THE PENDING DELTA IS LEFT
+	for _, preview := range previewRes.Validators {
+		if preview.ValidatorAddr == valAddr0.String() {
+			require.Equal(t, sdk.NewDec(1000_000), preview.CurrentBondedAmount)
+			require.Equal(t, sdk.NewInt(250_000), preview.Delta)
+		} else {
+			require.Equal(t, sdk.NewDec(250_000), preview.CurrentBondedAmount)
+			require.Equal(t, sdk.ZeroInt(), preview.Delta)
+		}
+		require.Equal(t, sdk.ZeroInt(), preview.PendingDelta)
+	}
+}
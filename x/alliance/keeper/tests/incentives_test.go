@@ -0,0 +1,183 @@
+package tests_test
+
+import (
+	"testing"
+	"time"
+
+	test_helpers "github.com/terra-money/alliance/app"
+	"github.com/terra-money/alliance/x/alliance"
+	"github.com/terra-money/alliance/x/alliance/keeper"
+	"github.com/terra-money/alliance/x/alliance/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/cosmos/cosmos-sdk/x/staking/teststaking"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewardIncentiveStreamsToAssetDelegators(t *testing.T) {
+	app, ctx := createTestContext(t)
+	startTime := time.Now().UTC()
+	ctx = ctx.WithBlockTime(startTime).WithBlockHeight(1)
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: types.DefaultParams(),
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset(AllianceDenom, sdk.NewDec(2), sdk.ZeroDec(), sdk.NewDec(5), sdk.ZeroDec(), startTime),
+			types.NewAllianceAsset(AllianceDenomTwo, sdk.NewDec(2), sdk.ZeroDec(), sdk.NewDec(5), sdk.ZeroDec(), startTime),
+		},
+	})
+	delegations := app.StakingKeeper.GetAllDelegations(ctx)
+	valAddr, err := sdk.ValAddressFromBech32(delegations[0].ValidatorAddress)
+	require.NoError(t, err)
+	val, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr)
+	require.NoError(t, err)
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 3, sdk.NewCoins(
+		sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)),
+		sdk.NewCoin(AllianceDenomTwo, sdk.NewInt(1000_000)),
+		sdk.NewCoin("stake", sdk.NewInt(1000_000)),
+	))
+	user1, user2, funder := addrs[0], addrs[1], addrs[2]
+
+	_, err = app.AllianceKeeper.Delegate(ctx, user1, val, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+	val, err = app.AllianceKeeper.GetAllianceValidator(ctx, valAddr)
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Delegate(ctx, user2, val, sdk.NewCoin(AllianceDenomTwo, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+
+	// Incentives can only be funded for alliance assets and must end after they start
+	_, err = app.AllianceKeeper.FundAllianceRewards(ctx, funder, "unknown", nil, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000_000))), time.Time{}, startTime.Add(100*time.Second))
+	require.ErrorIs(t, err, types.ErrUnknownAsset)
+	_, err = app.AllianceKeeper.FundAllianceRewards(ctx, funder, AllianceDenom, nil, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000_000))), time.Time{}, startTime)
+	require.ErrorIs(t, err, types.ErrInvalidRewardIncentive)
+
+	incentive, err := app.AllianceKeeper.FundAllianceRewards(ctx, funder, AllianceDenom, nil, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000_000))), time.Time{}, startTime.Add(100*time.Second))
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), incentive.Id)
+	require.Equal(t, startTime, incentive.StartTime)
+	require.True(t, app.BankKeeper.GetAllBalances(ctx, funder).AmountOf("stake").IsZero())
+
+	// Half of the deposit is streamed after half of the duration, only to the delegators of the funded asset
+	ctx = ctx.WithBlockTime(startTime.Add(50 * time.Second)).WithBlockHeight(2)
+	err = app.AllianceKeeper.DistributeRewardIncentives(ctx)
+	require.NoError(t, err)
+	val, err = app.AllianceKeeper.GetAllianceValidator(ctx, valAddr)
+	require.NoError(t, err)
+	coins, err := app.AllianceKeeper.ClaimDelegationRewards(ctx, user1, val, AllianceDenom)
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(500_000))), coins)
+	coins, err = app.AllianceKeeper.ClaimDelegationRewards(ctx, user2, val, AllianceDenomTwo)
+	require.NoError(t, err)
+	require.True(t, coins.IsZero())
+
+	queryServer := keeper.NewQueryServerImpl(app.AllianceKeeper)
+	res, err := queryServer.RewardIncentive(ctx, &types.QueryRewardIncentiveRequest{Id: incentive.Id})
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(500_000))), res.Unspent)
+
+	// The rest is streamed at the end time and the finished incentive is removed
+	ctx = ctx.WithBlockTime(startTime.Add(200 * time.Second)).WithBlockHeight(3)
+	alliance.EndBlocker(ctx, app.AllianceKeeper)
+	_, found := app.AllianceKeeper.GetRewardIncentive(ctx, incentive.Id)
+	require.False(t, found)
+	val, err = app.AllianceKeeper.GetAllianceValidator(ctx, valAddr)
+	require.NoError(t, err)
+	coins, err = app.AllianceKeeper.ClaimDelegationRewards(ctx, user1, val, AllianceDenom)
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(500_000))), coins)
+
+	_, stop := alliance.RunAllInvariants(ctx, app.AllianceKeeper)
+	require.False(t, stop)
+}
+
+func TestRewardIncentiveForASingleValidator(t *testing.T) {
+	app, ctx := createTestContext(t)
+	startTime := time.Now().UTC()
+	ctx = ctx.WithBlockTime(startTime).WithBlockHeight(1)
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: types.DefaultParams(),
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset(AllianceDenom, sdk.NewDec(2), sdk.ZeroDec(), sdk.NewDec(5), sdk.ZeroDec(), startTime),
+		},
+	})
+	delegations := app.StakingKeeper.GetAllDelegations(ctx)
+	valAddr1, err := sdk.ValAddressFromBech32(delegations[0].ValidatorAddress)
+	require.NoError(t, err)
+	val1, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr1)
+	require.NoError(t, err)
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 3, sdk.NewCoins(
+		sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)),
+		sdk.NewCoin("stake", sdk.NewInt(1000_000)),
+	))
+	user1, user2, funder := addrs[0], addrs[1], addrs[2]
+	valAddr2 := sdk.ValAddress(addrs[1])
+	test_helpers.RegisterNewValidator(t, app, ctx, teststaking.NewValidator(t, valAddr2, test_helpers.CreateTestPubKeys(1)[0]))
+	val2, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr2)
+	require.NoError(t, err)
+
+	_, err = app.AllianceKeeper.Delegate(ctx, user1, val1, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Delegate(ctx, user2, val2, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+
+	incentive, err := app.AllianceKeeper.FundAllianceRewards(ctx, funder, AllianceDenom, valAddr2, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000_000))), time.Time{}, startTime.Add(100*time.Second))
+	require.NoError(t, err)
+	require.Equal(t, valAddr2.String(), incentive.ValidatorAddress)
+
+	// Only the delegators of the targeted validator receive the incentive
+	ctx = ctx.WithBlockTime(startTime.Add(100 * time.Second)).WithBlockHeight(2)
+	err = app.AllianceKeeper.DistributeRewardIncentives(ctx)
+	require.NoError(t, err)
+	val1, err = app.AllianceKeeper.GetAllianceValidator(ctx, valAddr1)
+	require.NoError(t, err)
+	coins, err := app.AllianceKeeper.ClaimDelegationRewards(ctx, user1, val1, AllianceDenom)
+	require.NoError(t, err)
+	require.True(t, coins.IsZero())
+	val2, err = app.AllianceKeeper.GetAllianceValidator(ctx, valAddr2)
+	require.NoError(t, err)
+	coins, err = app.AllianceKeeper.ClaimDelegationRewards(ctx, user2, val2, AllianceDenom)
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000_000))), coins)
+
+	_, stop := alliance.RunAllInvariants(ctx, app.AllianceKeeper)
+	require.False(t, stop)
+}
+
+func TestRefundRewardIncentive(t *testing.T) {
+	app, ctx := createTestContext(t)
+	startTime := time.Now().UTC()
+	ctx = ctx.WithBlockTime(startTime).WithBlockHeight(1)
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: types.DefaultParams(),
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset(AllianceDenom, sdk.NewDec(2), sdk.ZeroDec(), sdk.NewDec(5), sdk.ZeroDec(), startTime),
+		},
+	})
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 2, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000_000))))
+	funder, other := addrs[0], addrs[1]
+
+	incentive, err := app.AllianceKeeper.FundAllianceRewards(ctx, funder, AllianceDenom, nil, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000_000))), time.Time{}, startTime.Add(100*time.Second))
+	require.NoError(t, err)
+
+	// Nothing is distributed while the asset has no delegators
+	ctx = ctx.WithBlockTime(startTime.Add(50 * time.Second)).WithBlockHeight(2)
+	err = app.AllianceKeeper.DistributeRewardIncentives(ctx)
+	require.NoError(t, err)
+	incentive, found := app.AllianceKeeper.GetRewardIncentive(ctx, incentive.Id)
+	require.True(t, found)
+	require.True(t, incentive.Distributed.IsZero())
+	require.Equal(t, startTime.Add(50*time.Second), incentive.LastDistributionTime)
+
+	// Only the funder can refund the incentive
+	_, err = app.AllianceKeeper.RefundAllianceRewards(ctx, other, incentive.Id)
+	require.ErrorIs(t, err, sdkerrors.ErrUnauthorized)
+	coins, err := app.AllianceKeeper.RefundAllianceRewards(ctx, funder, incentive.Id)
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000_000))), coins)
+	require.Equal(t, sdk.NewInt(1000_000), app.BankKeeper.GetAllBalances(ctx, funder).AmountOf("stake"))
+	_, err = app.AllianceKeeper.RefundAllianceRewards(ctx, funder, incentive.Id)
+	require.ErrorIs(t, err, types.ErrRewardIncentiveNotFound)
+
+	_, stop := alliance.RunAllInvariants(ctx, app.AllianceKeeper)
+	require.False(t, stop)
+}
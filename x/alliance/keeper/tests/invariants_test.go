@@ -0,0 +1,92 @@
+package tests_test
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	minttypes "github.com/cosmos/cosmos-sdk/x/mint/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/terra-money/alliance/x/alliance"
+	"github.com/terra-money/alliance/x/alliance/types"
+)
+
+func TestModuleBalanceInvariant(t *testing.T) {
+	// GIVEN: delegations and an undelegation held by the module account
+	app, ctx, val, user := setupEndBlockerTest(t, time.Now().UTC())
+	_, err := app.AllianceKeeper.Undelegate(ctx, user, val, sdk.NewCoin(AllianceDenom, sdk.NewInt(400_000_000)))
+	require.NoError(t, err)
+	_, stop := alliance.ModuleBalanceInvariant(app.AllianceKeeper)(ctx)
+	require.False(t, stop)
+
+	// WHEN: tokens are sent to the module account
+	require.NoError(t, app.BankKeeper.MintCoins(ctx, minttypes.ModuleName, sdk.NewCoins(sdk.NewCoin(AllianceDenom, sdk.NewInt(1)))))
+	require.NoError(t, app.BankKeeper.SendCoinsFromModuleToModule(ctx, minttypes.ModuleName, types.ModuleName, sdk.NewCoins(sdk.NewCoin(AllianceDenom, sdk.NewInt(1)))))
+
+	// THEN: the module is still solvent
+	_, stop = alliance.ModuleBalanceInvariant(app.AllianceKeeper)(ctx)
+	require.False(t, stop)
+
+	// WHEN: the module account holds less than the delegated and unbonding tokens
+	require.NoError(t, app.BankKeeper.SendCoinsFromModuleToAccount(ctx, types.ModuleName, user, sdk.NewCoins(sdk.NewCoin(AllianceDenom, sdk.NewInt(2)))))
+
+	// THEN: the invariant is broken
+	_, stop = alliance.ModuleBalanceInvariant(app.AllianceKeeper)(ctx)
+	require.True(t, stop)
+}
+
+func TestRewardsPoolInvariant(t *testing.T) {
+	// GIVEN: rewards that were added to the pool for the delegations
+	app, ctx, val, user := setupEndBlockerTest(t, time.Now().UTC())
+	rewards := sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000_000)))
+	require.NoError(t, app.BankKeeper.MintCoins(ctx, minttypes.ModuleName, rewards))
+	require.NoError(t, app.BankKeeper.SendCoinsFromModuleToAccount(ctx, minttypes.ModuleName, user, rewards))
+	require.NoError(t, app.AllianceKeeper.AddAssetsToRewardPool(ctx, user, val, rewards))
+
+	claimable, delegations, err := app.AllianceKeeper.TotalClaimableRewards(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), delegations)
+	require.True(t, claimable.AmountOf("stake").IsPositive())
+	_, stop := alliance.RewardsPoolInvariant(app.AllianceKeeper)(ctx)
+	require.False(t, stop)
+
+	// WHEN: the rewards pool cannot pay out all claimable rewards
+	require.NoError(t, app.BankKeeper.SendCoinsFromModuleToAccount(ctx, types.RewardsPoolName, user, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(500_000)))))
+
+	// THEN: the invariant is broken
+	_, stop = alliance.RewardsPoolInvariant(app.AllianceKeeper)(ctx)
+	require.True(t, stop)
+}
+
+func TestRebalanceTargetInvariant(t *testing.T) {
+	// GIVEN: a rebalanced validator
+	app, ctx, val, _ := setupEndBlockerTest(t, time.Now().UTC())
+	require.NoError(t, app.AllianceKeeper.RebalanceBondTokenWeights(ctx, app.AllianceKeeper.GetAllAssets(ctx)))
+	target, found := app.AllianceKeeper.GetRebalanceTarget(ctx, val.GetOperator())
+	require.True(t, found)
+	require.True(t, target.IsPositive())
+	_, stop := alliance.RebalanceTargetInvariant(app.AllianceKeeper)(ctx)
+	require.False(t, stop)
+
+	// WHEN: the module delegation changes outside of a rebalance
+	moduleAddr := app.AccountKeeper.GetModuleAddress(types.ModuleName)
+	bondDenom := app.StakingKeeper.BondDenom(ctx)
+	require.NoError(t, app.BankKeeper.MintCoins(ctx, types.ModuleName, sdk.NewCoins(sdk.NewCoin(bondDenom, sdk.NewInt(10)))))
+	validator, found := app.StakingKeeper.GetValidator(ctx, val.GetOperator())
+	require.True(t, found)
+	_, err := app.StakingKeeper.Delegate(ctx, moduleAddr, sdk.NewInt(10), stakingtypes.Unbonded, validator, true)
+	require.NoError(t, err)
+
+	// THEN: the invariant is broken
+	_, stop = alliance.RebalanceTargetInvariant(app.AllianceKeeper)(ctx)
+	require.True(t, stop)
+
+	// WHEN: the validator is rebalanced again
+	require.NoError(t, app.AllianceKeeper.RebalanceBondTokenWeights(ctx, app.AllianceKeeper.GetAllAssets(ctx)))
+
+	// THEN: the delegation matches the target
+	_, stop = alliance.RunAllInvariants(ctx, app.AllianceKeeper)
+	require.False(t, stop)
+}
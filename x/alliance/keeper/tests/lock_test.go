@@ -0,0 +1,258 @@
+package tests_test
+
+import (
+	"testing"
+	"time"
+
+	test_helpers "github.com/terra-money/alliance/app"
+	"github.com/terra-money/alliance/x/alliance"
+	"github.com/terra-money/alliance/x/alliance/keeper"
+	"github.com/terra-money/alliance/x/alliance/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	minttypes "github.com/cosmos/cosmos-sdk/x/mint/types"
+	"github.com/cosmos/cosmos-sdk/x/staking/teststaking"
+	"github.com/stretchr/testify/require"
+)
+
+const lockDuration = 30 * 24 * time.Hour
+
+func lockParams() types.Params {
+	params := types.DefaultParams()
+	params.LockDurations = []types.LockDuration{
+		{Duration: lockDuration, RewardMultiplier: sdk.NewDec(2)},
+		{Duration: 3 * lockDuration, RewardMultiplier: sdk.NewDec(3)},
+	}
+	return params
+}
+
+func TestDelegateWithLockBoostsRewards(t *testing.T) {
+	app, ctx := createTestContext(t)
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: lockParams(),
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset(AllianceDenom, sdk.NewDec(2), sdk.ZeroDec(), sdk.NewDec(5), sdk.ZeroDec(), ctx.BlockTime()),
+		},
+	})
+	mintPoolAddr := app.AccountKeeper.GetModuleAddress(minttypes.ModuleName)
+	delegations := app.StakingKeeper.GetAllDelegations(ctx)
+	valAddr, err := sdk.ValAddressFromBech32(delegations[0].ValidatorAddress)
+	require.NoError(t, err)
+	val, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr)
+	require.NoError(t, err)
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 2, sdk.NewCoins(sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000))))
+	user1, user2 := addrs[0], addrs[1]
+	err = app.BankKeeper.MintCoins(ctx, minttypes.ModuleName, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(3000_000))))
+	require.NoError(t, err)
+
+	// Only lock durations from the params are allowed
+	_, err = app.AllianceKeeper.DelegateWithLock(ctx, user1, val, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)), time.Hour)
+	require.ErrorIs(t, err, types.ErrInvalidLockDuration)
+
+	lock, err := app.AllianceKeeper.DelegateWithLock(ctx, user1, val, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)), lockDuration)
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewDec(1000_000), lock.Shares)
+	require.Equal(t, sdk.NewDec(1000_000), lock.BoostShares)
+	require.Equal(t, ctx.BlockTime().Add(lockDuration), lock.EndTime)
+	val, err = app.AllianceKeeper.GetAllianceValidator(ctx, valAddr)
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Delegate(ctx, user2, val, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+
+	val, err = app.AllianceKeeper.GetAllianceValidator(ctx, valAddr)
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewDec(1000_000), val.BoostedDelegationSharesWithDenom(AllianceDenom))
+
+	// The locked delegation earns twice the rewards of the unlocked one
+	err = app.AllianceKeeper.AddAssetsToRewardPool(ctx, mintPoolAddr, val, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(3000_000))))
+	require.NoError(t, err)
+	coins, err := app.AllianceKeeper.ClaimDelegationRewards(ctx, user1, val, AllianceDenom)
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(2000_000))), coins)
+	coins, err = app.AllianceKeeper.ClaimDelegationRewards(ctx, user2, val, AllianceDenom)
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000_000))), coins)
+
+	_, stop := alliance.RunAllInvariants(ctx, app.AllianceKeeper)
+	require.False(t, stop)
+}
+
+func TestLockedDelegationCannotBeMoved(t *testing.T) {
+	app, ctx := createTestContext(t)
+	startTime := time.Now().UTC()
+	ctx = ctx.WithBlockTime(startTime).WithBlockHeight(1)
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: lockParams(),
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset(AllianceDenom, sdk.NewDec(2), sdk.ZeroDec(), sdk.NewDec(5), sdk.ZeroDec(), startTime),
+		},
+	})
+	delegations := app.StakingKeeper.GetAllDelegations(ctx)
+	valAddr1, err := sdk.ValAddressFromBech32(delegations[0].ValidatorAddress)
+	require.NoError(t, err)
+	val1, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr1)
+	require.NoError(t, err)
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 2, sdk.NewCoins(sdk.NewCoin(AllianceDenom, sdk.NewInt(2000_000))))
+	user := addrs[0]
+	valAddr2 := sdk.ValAddress(addrs[1])
+	test_helpers.RegisterNewValidator(t, app, ctx, teststaking.NewValidator(t, valAddr2, test_helpers.CreateTestPubKeys(1)[0]))
+	val2, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr2)
+	require.NoError(t, err)
+
+	_, err = app.AllianceKeeper.Delegate(ctx, user, val1, sdk.NewCoin(AllianceDenom, sdk.NewInt(500_000)))
+	require.NoError(t, err)
+	val1, err = app.AllianceKeeper.GetAllianceValidator(ctx, valAddr1)
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.DelegateWithLock(ctx, user, val1, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)), lockDuration)
+	require.NoError(t, err)
+	val1, err = app.AllianceKeeper.GetAllianceValidator(ctx, valAddr1)
+	require.NoError(t, err)
+
+	// Only the unlocked part of the delegation can be undelegated or redelegated
+	_, err = app.AllianceKeeper.Undelegate(ctx, user, val1, sdk.NewCoin(AllianceDenom, sdk.NewInt(500_001)))
+	require.ErrorIs(t, err, types.ErrDelegationLocked)
+	_, err = app.AllianceKeeper.Redelegate(ctx, user, val1, val2, sdk.NewCoin(AllianceDenom, sdk.NewInt(500_001)))
+	require.ErrorIs(t, err, types.ErrDelegationLocked)
+	_, err = app.AllianceKeeper.Undelegate(ctx, user, val1, sdk.NewCoin(AllianceDenom, sdk.NewInt(500_000)))
+	require.NoError(t, err)
+
+	// Locks are queryable per delegator
+	queryServer := keeper.NewQueryServerImpl(app.AllianceKeeper)
+	res, err := queryServer.AllianceDelegationLocks(ctx, &types.QueryAllianceDelegationLocksRequest{DelegatorAddr: user.String()})
+	require.NoError(t, err)
+	require.Len(t, res.Locks, 1)
+	require.Equal(t, sdk.NewDec(1000_000), res.Locks[0].Shares)
+
+	// The lock is removed once it ends and the delegation can be undelegated
+	ctx = ctx.WithBlockTime(startTime.Add(lockDuration)).WithBlockHeight(2)
+	alliance.EndBlocker(ctx, app.AllianceKeeper)
+	res, err = queryServer.AllianceDelegationLocks(ctx, &types.QueryAllianceDelegationLocksRequest{DelegatorAddr: user.String()})
+	require.NoError(t, err)
+	require.Len(t, res.Locks, 0)
+	val1, err = app.AllianceKeeper.GetAllianceValidator(ctx, valAddr1)
+	require.NoError(t, err)
+	require.True(t, val1.BoostedDelegationSharesWithDenom(AllianceDenom).IsZero())
+	_, err = app.AllianceKeeper.Redelegate(ctx, user, val1, val2, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+
+	_, stop := alliance.RunAllInvariants(ctx, app.AllianceKeeper)
+	require.False(t, stop)
+}
+
+func TestLockedDelegationBoostsVotingPower(t *testing.T) {
+	app, ctx := createTestContext(t)
+	startTime := time.Now().UTC()
+	ctx = ctx.WithBlockTime(startTime).WithBlockHeight(1)
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: lockParams(),
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset(AllianceDenom, sdk.NewDec(2), sdk.ZeroDec(), sdk.NewDec(5), sdk.ZeroDec(), startTime),
+		},
+	})
+	moduleAddr := app.AccountKeeper.GetModuleAddress(types.ModuleName)
+	delegations := app.StakingKeeper.GetAllDelegations(ctx)
+	valAddr1, err := sdk.ValAddressFromBech32(delegations[0].ValidatorAddress)
+	require.NoError(t, err)
+	val1, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr1)
+	require.NoError(t, err)
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 2, sdk.NewCoins(sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000))))
+	valAddr2 := sdk.ValAddress(addrs[1])
+	test_helpers.RegisterNewValidator(t, app, ctx, teststaking.NewValidator(t, valAddr2, test_helpers.CreateTestPubKeys(1)[0]))
+	val2, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr2)
+	require.NoError(t, err)
+
+	_, err = app.AllianceKeeper.DelegateWithLock(ctx, addrs[0], val1, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)), 3*lockDuration)
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Delegate(ctx, addrs[1], val2, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+	_, err = app.StakingKeeper.ApplyAndReturnValidatorSetUpdates(ctx)
+	require.NoError(t, err)
+	err = app.AllianceKeeper.RebalanceBondTokenWeights(ctx, app.AllianceKeeper.GetAllAssets(ctx))
+	require.NoError(t, err)
+
+	// The expected bond amount of the asset is split 3:1 between the validators
+	delegation1, found := app.StakingKeeper.GetDelegation(ctx, moduleAddr, valAddr1)
+	require.True(t, found)
+	delegation2, found := app.StakingKeeper.GetDelegation(ctx, moduleAddr, valAddr2)
+	require.True(t, found)
+	validator1, _ := app.StakingKeeper.GetValidator(ctx, valAddr1)
+	validator2, _ := app.StakingKeeper.GetValidator(ctx, valAddr2)
+	bonded1 := validator1.TokensFromShares(delegation1.Shares).TruncateInt()
+	bonded2 := validator2.TokensFromShares(delegation2.Shares).TruncateInt()
+	require.Equal(t, bonded2.MulRaw(3), bonded1)
+
+	_, stop := alliance.RunAllInvariants(ctx, app.AllianceKeeper)
+	require.False(t, stop)
+}
+
+func TestSlashingRedelegationScalesDelegationLocks(t *testing.T) {
+	app, ctx := createTestContext(t)
+	startTime := time.Now().UTC()
+	ctx = ctx.WithBlockTime(startTime).WithBlockHeight(1)
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: lockParams(),
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset(AllianceDenom, sdk.NewDec(2), sdk.ZeroDec(), sdk.NewDec(5), sdk.ZeroDec(), startTime),
+		},
+	})
+	delegations := app.StakingKeeper.GetAllDelegations(ctx)
+	valAddr1, err := sdk.ValAddressFromBech32(delegations[0].ValidatorAddress)
+	require.NoError(t, err)
+	val1, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr1)
+	require.NoError(t, err)
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 2, sdk.NewCoins(sdk.NewCoin(AllianceDenom, sdk.NewInt(2000_000))))
+	user := addrs[0]
+	valAddr2 := sdk.ValAddress(addrs[1])
+	test_helpers.RegisterNewValidator(t, app, ctx, teststaking.NewValidator(t, valAddr2, test_helpers.CreateTestPubKeys(1)[0]))
+	val2, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr2)
+	require.NoError(t, err)
+
+	// A delegation that was redelegated to the second validator and then locked there
+	_, err = app.AllianceKeeper.Delegate(ctx, user, val1, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+	val1, err = app.AllianceKeeper.GetAllianceValidator(ctx, valAddr1)
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Redelegate(ctx, user, val1, val2, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+	val2, err = app.AllianceKeeper.GetAllianceValidator(ctx, valAddr2)
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.DelegateWithLock(ctx, user, val2, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)), lockDuration)
+	require.NoError(t, err)
+
+	// Slashing the source validator removes a quarter of the delegation, so the lock and its boost lose a quarter too
+	err = app.AllianceKeeper.SlashValidator(ctx, valAddr1, sdk.MustNewDecFromStr("0.5"))
+	require.NoError(t, err)
+	delegation, found := app.AllianceKeeper.GetDelegation(ctx, user, valAddr2, AllianceDenom)
+	require.True(t, found)
+	require.Equal(t, sdk.NewDec(1500_000), delegation.Shares)
+	locks := app.AllianceKeeper.GetDelegationLocks(ctx, user, valAddr2, AllianceDenom)
+	require.Len(t, locks, 1)
+	require.Equal(t, sdk.NewDec(750_000), locks[0].Shares)
+	require.Equal(t, sdk.NewDec(750_000), locks[0].BoostShares)
+	val2, err = app.AllianceKeeper.GetAllianceValidator(ctx, valAddr2)
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewDec(750_000), val2.BoostedDelegationSharesWithDenom(AllianceDenom))
+
+	// Only the locked shares that are left are checked when undelegating, which is half of the delegation
+	res, err := keeper.NewQueryServerImpl(app.AllianceKeeper).AllianceDelegation(ctx, &types.QueryAllianceDelegationRequest{
+		DelegatorAddr: user.String(),
+		ValidatorAddr: valAddr2.String(),
+		Denom:         AllianceDenom,
+	})
+	require.NoError(t, err)
+	unlocked := res.Delegation.Balance.Amount.QuoRaw(2)
+	_, err = app.AllianceKeeper.Undelegate(ctx, user, val2, sdk.NewCoin(AllianceDenom, unlocked.AddRaw(10)))
+	require.ErrorIs(t, err, types.ErrDelegationLocked)
+	_, err = app.AllianceKeeper.Undelegate(ctx, user, val2, sdk.NewCoin(AllianceDenom, unlocked))
+	require.NoError(t, err)
+
+	// The boost is removed completely once the lock ends
+	ctx = ctx.WithBlockTime(startTime.Add(lockDuration)).WithBlockHeight(2)
+	alliance.EndBlocker(ctx, app.AllianceKeeper)
+	val2, err = app.AllianceKeeper.GetAllianceValidator(ctx, valAddr2)
+	require.NoError(t, err)
+	require.True(t, val2.BoostedDelegationSharesWithDenom(AllianceDenom).IsZero())
+
+	_, stop := alliance.RunAllInvariants(ctx, app.AllianceKeeper)
+	require.False(t, stop)
+}
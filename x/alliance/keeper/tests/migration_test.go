@@ -0,0 +1,258 @@
+package tests_test
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	minttypes "github.com/cosmos/cosmos-sdk/x/mint/types"
+	"github.com/stretchr/testify/require"
+
+	test_helpers "github.com/terra-money/alliance/app"
+	"github.com/terra-money/alliance/x/alliance"
+	"github.com/terra-money/alliance/x/alliance/keeper"
+	"github.com/terra-money/alliance/x/alliance/types"
+)
+
+const MigratedDenom = "migrated"
+
+var migrationRecipient = sdk.AccAddress("migration_recipient_")
+
+// fundMigrationEscrow mints the tokens of the new denom into the asset migration escrow
+func fundMigrationEscrow(t *testing.T, app *test_helpers.App, ctx sdk.Context, amount sdk.Coin) {
+	coins := sdk.NewCoins(amount)
+	require.NoError(t, app.BankKeeper.MintCoins(ctx, minttypes.ModuleName, coins))
+	require.NoError(t, app.BankKeeper.SendCoinsFromModuleToModule(ctx, minttypes.ModuleName, types.AssetMigrationEscrowName, coins))
+}
+
+func TestAssetMigrationRequiresFundedEscrow(t *testing.T) {
+	// GIVEN: a delegated asset and an escrow without enough tokens of the new denom
+	app, ctx, _, _ := setupEndBlockerTest(t, time.Now().UTC())
+	fundMigrationEscrow(t, app, ctx, sdk.NewCoin(MigratedDenom, sdk.NewInt(1999_999_999)))
+
+	// WHEN: the asset is migrated at a rate of 2
+	err := app.AllianceKeeper.StartAssetMigration(ctx, AllianceDenom, MigratedDenom, sdk.NewDec(2), migrationRecipient)
+
+	// THEN: the migration fails and the asset is unchanged
+	require.ErrorIs(t, err, types.ErrInsufficientMigrationEscrow)
+	_, found := app.AllianceKeeper.GetAssetByDenom(ctx, AllianceDenom)
+	require.True(t, found)
+	_, found = app.AllianceKeeper.GetAssetMigration(ctx)
+	require.False(t, found)
+}
+
+func TestAssetMigration(t *testing.T) {
+	// GIVEN: a delegation with a pending undelegation, rewards to claim and a funded escrow
+	startTime := time.Now().UTC()
+	app, ctx, val, user := setupEndBlockerTest(t, startTime)
+	_, err := app.AllianceKeeper.Undelegate(ctx, user, val, sdk.NewCoin(AllianceDenom, sdk.NewInt(100_000_000)))
+	require.NoError(t, err)
+	ctx = ctx.WithBlockTime(startTime.Add(time.Hour * 2)).WithBlockHeight(2)
+	app.AllianceKeeper.InitializeAllianceAssets(ctx, app.AllianceKeeper.GetAllAssets(ctx))
+	ctx = ctx.WithBlockHeight(3)
+	rewards := sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000_000)))
+	require.NoError(t, app.BankKeeper.MintCoins(ctx, minttypes.ModuleName, rewards))
+	require.NoError(t, app.AllianceKeeper.AddAssetsToRewardPool(ctx, app.AccountKeeper.GetModuleAddress(minttypes.ModuleName), val, rewards))
+	val, err = app.AllianceKeeper.GetAllianceValidator(ctx, val.GetOperator())
+	require.NoError(t, err)
+	delegation, found := app.AllianceKeeper.GetDelegation(ctx, user, val.GetOperator(), AllianceDenom)
+	require.True(t, found)
+	asset, _ := app.AllianceKeeper.GetAssetByDenom(ctx, AllianceDenom)
+	rewardsBefore, _, err := app.AllianceKeeper.CalculateDelegationRewards(ctx, delegation, val, asset)
+	require.NoError(t, err)
+	fundMigrationEscrow(t, app, ctx, sdk.NewCoin(MigratedDenom, sdk.NewInt(2500_000_000)))
+	moduleAddr := app.AccountKeeper.GetModuleAddress(types.ModuleName)
+
+	// WHEN: the asset is migrated at a rate of 2
+	err = app.AllianceKeeper.StartAssetMigration(ctx, AllianceDenom, MigratedDenom, sdk.NewDec(2), migrationRecipient)
+	require.NoError(t, err)
+
+	// THEN: the asset and the module balance are converted and the escrow refunds the recipient
+	_, found = app.AllianceKeeper.GetAssetByDenom(ctx, AllianceDenom)
+	require.False(t, found)
+	migrated, found := app.AllianceKeeper.GetAssetByDenom(ctx, MigratedDenom)
+	require.True(t, found)
+	require.Equal(t, asset.TotalTokens.MulRaw(2), migrated.TotalTokens)
+	require.True(t, app.BankKeeper.GetBalance(ctx, moduleAddr, AllianceDenom).IsZero())
+	require.Equal(t, sdk.NewInt(2000_000_000), app.BankKeeper.GetBalance(ctx, moduleAddr, MigratedDenom).Amount)
+	require.Equal(t, sdk.NewCoins(
+		sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000_000)),
+		sdk.NewCoin(MigratedDenom, sdk.NewInt(500_000_000)),
+	), app.BankKeeper.GetAllBalances(ctx, migrationRecipient))
+	var undelegations []types.Undelegation
+	app.AllianceKeeper.IterateUndelegations(ctx, func(queued types.QueuedUndelegation, _ time.Time) bool {
+		for _, undel := range queued.Entries {
+			undelegations = append(undelegations, *undel)
+		}
+		return false
+	})
+	require.Len(t, undelegations, 1)
+	require.Equal(t, sdk.NewCoin(MigratedDenom, sdk.NewInt(200_000_000)), undelegations[0].Balance)
+
+	// THEN: the delegation keeps its value and its unclaimed rewards
+	val, err = app.AllianceKeeper.GetAllianceValidator(ctx, val.GetOperator())
+	require.NoError(t, err)
+	res, err := keeper.NewQueryServerImpl(app.AllianceKeeper).AllianceDelegation(ctx, &types.QueryAllianceDelegationRequest{
+		DelegatorAddr: user.String(),
+		ValidatorAddr: val.OperatorAddress,
+		Denom:         MigratedDenom,
+	})
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewCoin(MigratedDenom, sdk.NewInt(1800_000_000)), res.Delegation.Balance)
+	claimed, err := app.AllianceKeeper.ClaimDelegationRewards(ctx, user, val, MigratedDenom)
+	require.NoError(t, err)
+	require.Equal(t, rewardsBefore, claimed)
+	_, found = app.AllianceKeeper.GetDelegation(ctx, user, val.GetOperator(), MigratedDenom)
+	require.True(t, found)
+
+	// THEN: the invariants hold
+	msg, broken := alliance.RunAllInvariants(ctx, app.AllianceKeeper)
+	require.False(t, broken, msg)
+}
+
+func TestAssetMigrationKeepsRewardAccrual(t *testing.T) {
+	// GIVEN: a validator that only holds delegations of the migrated asset and a funded escrow
+	startTime := time.Now().UTC()
+	app, ctx, val, user := setupEndBlockerTest(t, startTime)
+	_, err := app.AllianceKeeper.Undelegate(ctx, user, val, sdk.NewCoin(AllianceDenomTwo, sdk.NewInt(1000_000_000)))
+	require.NoError(t, err)
+	ctx = ctx.WithBlockTime(startTime.Add(time.Hour * 2)).WithBlockHeight(2)
+	app.AllianceKeeper.InitializeAllianceAssets(ctx, app.AllianceKeeper.GetAllAssets(ctx))
+	ctx = ctx.WithBlockHeight(3)
+	mintAddr := app.AccountKeeper.GetModuleAddress(minttypes.ModuleName)
+	rewards := sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000_000)))
+	addRewards := func() {
+		require.NoError(t, app.BankKeeper.MintCoins(ctx, minttypes.ModuleName, rewards))
+		val, err = app.AllianceKeeper.GetAllianceValidator(ctx, val.GetOperator())
+		require.NoError(t, err)
+		require.NoError(t, app.AllianceKeeper.AddAssetsToRewardPool(ctx, mintAddr, val, rewards))
+		val, err = app.AllianceKeeper.GetAllianceValidator(ctx, val.GetOperator())
+		require.NoError(t, err)
+	}
+	addRewards()
+	claimedBefore, err := app.AllianceKeeper.ClaimDelegationRewards(ctx, user, val, AllianceDenom)
+	require.NoError(t, err)
+	asset, _ := app.AllianceKeeper.GetAssetByDenom(ctx, AllianceDenom)
+	fundMigrationEscrow(t, app, ctx, sdk.NewCoin(MigratedDenom, sdk.NewInt(2000_000_000)))
+
+	// WHEN: the asset is migrated at a rate of 2 and the validator earns the same rewards again
+	require.NoError(t, app.AllianceKeeper.StartAssetMigration(ctx, AllianceDenom, MigratedDenom, sdk.NewDec(2), migrationRecipient))
+	require.NoError(t, app.AllianceKeeper.MigrateAssetDelegations(ctx, 0))
+	ctx = ctx.WithBlockHeight(4)
+	addRewards()
+	claimedAfter, err := app.AllianceKeeper.ClaimDelegationRewards(ctx, user, val, MigratedDenom)
+	require.NoError(t, err)
+
+	// THEN: the asset keeps its reward weight and the delegation accrues the same rewards as before
+	migrated, _ := app.AllianceKeeper.GetAssetByDenom(ctx, MigratedDenom)
+	require.Equal(t, asset.RewardWeight, migrated.RewardWeight)
+	require.Equal(t, asset.RewardWeightRange, migrated.RewardWeightRange)
+	require.False(t, claimedBefore.IsZero())
+	require.Equal(t, claimedBefore, claimedAfter)
+}
+
+func TestAssetMigrationKeepsRewardSplit(t *testing.T) {
+	// GIVEN: a validator that holds delegations of the migrated asset and of another asset
+	startTime := time.Now().UTC()
+	app, ctx, val, user := setupEndBlockerTest(t, startTime)
+	ctx = ctx.WithBlockTime(startTime.Add(time.Hour * 2)).WithBlockHeight(2)
+	app.AllianceKeeper.InitializeAllianceAssets(ctx, app.AllianceKeeper.GetAllAssets(ctx))
+	ctx = ctx.WithBlockHeight(3)
+	mintAddr := app.AccountKeeper.GetModuleAddress(minttypes.ModuleName)
+	rewards := sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000_000)))
+	addRewards := func() {
+		require.NoError(t, app.BankKeeper.MintCoins(ctx, minttypes.ModuleName, rewards))
+		val, err := app.AllianceKeeper.GetAllianceValidator(ctx, val.GetOperator())
+		require.NoError(t, err)
+		require.NoError(t, app.AllianceKeeper.AddAssetsToRewardPool(ctx, mintAddr, val, rewards))
+	}
+	claim := func(denom string) sdk.Coins {
+		val, err := app.AllianceKeeper.GetAllianceValidator(ctx, val.GetOperator())
+		require.NoError(t, err)
+		claimed, err := app.AllianceKeeper.ClaimDelegationRewards(ctx, user, val, denom)
+		require.NoError(t, err)
+		return claimed
+	}
+	addRewards()
+	claimedBefore := claim(AllianceDenom)
+	otherClaimedBefore := claim(AllianceDenomTwo)
+	fundMigrationEscrow(t, app, ctx, sdk.NewCoin(MigratedDenom, sdk.NewInt(2000_000_000)))
+
+	// WHEN: the asset is migrated at a rate of 2 and the validator earns the same rewards again
+	require.NoError(t, app.AllianceKeeper.StartAssetMigration(ctx, AllianceDenom, MigratedDenom, sdk.NewDec(2), migrationRecipient))
+	require.NoError(t, app.AllianceKeeper.MigrateAssetDelegations(ctx, 0))
+	ctx = ctx.WithBlockHeight(4)
+	addRewards()
+
+	// THEN: the rewards are split between the assets as before the migration
+	require.False(t, claimedBefore.IsZero())
+	require.False(t, otherClaimedBefore.IsZero())
+	require.Equal(t, claimedBefore, claim(MigratedDenom))
+	require.Equal(t, otherClaimedBefore, claim(AllianceDenomTwo))
+	migrated, _ := app.AllianceKeeper.GetAssetByDenom(ctx, MigratedDenom)
+	require.Equal(t, sdk.NewDec(2), migrated.GetConversionRate())
+
+	// THEN: the invariants hold
+	msg, broken := alliance.RunAllInvariants(ctx, app.AllianceKeeper)
+	require.False(t, broken, msg)
+}
+
+func TestAssetMigrationBlocksDelegations(t *testing.T) {
+	// GIVEN: an asset that is being migrated
+	app, ctx, val, user := setupEndBlockerTest(t, time.Now().UTC())
+	fundMigrationEscrow(t, app, ctx, sdk.NewCoin(MigratedDenom, sdk.NewInt(2000_000_000)))
+	require.NoError(t, app.AllianceKeeper.StartAssetMigration(ctx, AllianceDenom, MigratedDenom, sdk.NewDec(2), migrationRecipient))
+
+	// WHEN: the delegations of the old and the new denom are changed
+	_, delegateErr := app.AllianceKeeper.Delegate(ctx, user, val, sdk.NewCoin(MigratedDenom, sdk.NewInt(1)))
+	_, undelegateErr := app.AllianceKeeper.Undelegate(ctx, user, val, sdk.NewCoin(AllianceDenom, sdk.NewInt(1)))
+	secondErr := app.AllianceKeeper.StartAssetMigration(ctx, AllianceDenomTwo, "other", sdk.NewDec(2), migrationRecipient)
+
+	// THEN: the changes and a second migration are rejected
+	require.ErrorIs(t, delegateErr, types.ErrAssetMigrationInProgress)
+	require.ErrorIs(t, undelegateErr, types.ErrAssetMigrationInProgress)
+	require.ErrorIs(t, secondErr, types.ErrAssetMigrationInProgress)
+
+	// WHEN: the delegations are moved and the migration completes
+	require.NoError(t, app.AllianceKeeper.MigrateAssetDelegations(ctx, 0))
+
+	// THEN: the delegation of the new denom can be changed
+	val, err := app.AllianceKeeper.GetAllianceValidator(ctx, val.GetOperator())
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Undelegate(ctx, user, val, sdk.NewCoin(MigratedDenom, sdk.NewInt(1)))
+	require.NoError(t, err)
+}
+
+func TestMigrateAssetDelegationsInBatches(t *testing.T) {
+	// GIVEN: two delegations of an asset that is being migrated
+	app, ctx, val, user := setupEndBlockerTest(t, time.Now().UTC())
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 2, sdk.NewCoins(sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000))))
+	_, err := app.AllianceKeeper.Delegate(ctx, addrs[1], val, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+	fundMigrationEscrow(t, app, ctx, sdk.NewCoin(MigratedDenom, sdk.NewInt(2002_000_000)))
+	require.NoError(t, app.AllianceKeeper.StartAssetMigration(ctx, AllianceDenom, MigratedDenom, sdk.NewDec(2), migrationRecipient))
+
+	// WHEN: a single delegation is checked per batch
+	require.NoError(t, app.AllianceKeeper.MigrateAssetDelegations(ctx, 1))
+
+	// THEN: the migration is still in progress
+	migration, found := app.AllianceKeeper.GetAssetMigration(ctx)
+	require.True(t, found)
+	require.NotEmpty(t, migration.NextKey)
+
+	// WHEN: the remaining delegations are checked by the end blocker
+	ctx = ctx.WithEventManager(sdk.NewEventManager())
+	alliance.EndBlocker(ctx, app.AllianceKeeper)
+
+	// THEN: both delegations use the new denom and the migration is completed
+	_, found = app.AllianceKeeper.GetAssetMigration(ctx)
+	require.False(t, found)
+	for _, delAddr := range []sdk.AccAddress{user, addrs[1]} {
+		_, found = app.AllianceKeeper.GetDelegation(ctx, delAddr, val.GetOperator(), AllianceDenom)
+		require.False(t, found)
+		_, found = app.AllianceKeeper.GetDelegation(ctx, delAddr, val.GetOperator(), MigratedDenom)
+		require.True(t, found)
+	}
+	msg, broken := alliance.RunAllInvariants(ctx, app.AllianceKeeper)
+	require.False(t, broken, msg)
+}
@@ -0,0 +1,112 @@
+package tests_test
+
+import (
+	"testing"
+	"time"
+
+	test_helpers "github.com/terra-money/alliance/app"
+	"github.com/terra-money/alliance/x/alliance"
+	"github.com/terra-money/alliance/x/alliance/keeper"
+	"github.com/terra-money/alliance/x/alliance/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	minttypes "github.com/cosmos/cosmos-sdk/x/mint/types"
+	"github.com/cosmos/cosmos-sdk/x/staking/teststaking"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelegatorPortfolio(t *testing.T) {
+	app, ctx := createTestContext(t)
+	startTime := time.Now().UTC()
+	ctx = ctx.WithBlockTime(startTime).WithBlockHeight(1)
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: types.DefaultParams(),
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset(AllianceDenom, sdk.NewDec(2), sdk.ZeroDec(), sdk.NewDec(5), sdk.ZeroDec(), startTime),
+		},
+	})
+	mintPoolAddr := app.AccountKeeper.GetModuleAddress(minttypes.ModuleName)
+	delegations := app.StakingKeeper.GetAllDelegations(ctx)
+	valAddr1, err := sdk.ValAddressFromBech32(delegations[0].ValidatorAddress)
+	require.NoError(t, err)
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 2, sdk.NewCoins(sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000))))
+	delAddr := addrs[0]
+	valAddr2 := sdk.ValAddress(addrs[1])
+	test_helpers.RegisterNewValidator(t, app, ctx, teststaking.NewValidator(t, valAddr2, test_helpers.CreateTestPubKeys(1)[0]))
+	queryServer := keeper.NewQueryServerImpl(app.AllianceKeeper)
+
+	// An empty portfolio
+	res, err := queryServer.DelegatorPortfolio(ctx, &types.QueryDelegatorPortfolioRequest{DelegatorAddr: delAddr.String()})
+	require.NoError(t, err)
+	require.Empty(t, res.Delegations)
+	require.True(t, res.TotalVotingPower.IsZero())
+
+	val1, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr1)
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Delegate(ctx, delAddr, val1, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+	val1, err = app.AllianceKeeper.GetAllianceValidator(ctx, valAddr1)
+	require.NoError(t, err)
+	val2, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr2)
+	require.NoError(t, err)
+	redelegationTime, err := app.AllianceKeeper.Redelegate(ctx, delAddr, val1, val2, sdk.NewCoin(AllianceDenom, sdk.NewInt(300_000)))
+	require.NoError(t, err)
+	val1, err = app.AllianceKeeper.GetAllianceValidator(ctx, valAddr1)
+	require.NoError(t, err)
+	undelegationTime, err := app.AllianceKeeper.Undelegate(ctx, delAddr, val1, sdk.NewCoin(AllianceDenom, sdk.NewInt(200_000)))
+	require.NoError(t, err)
+	alliance.EndBlocker(ctx, app.AllianceKeeper)
+
+	err = app.BankKeeper.MintCoins(ctx, minttypes.ModuleName, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000))))
+	require.NoError(t, err)
+	val1, err = app.AllianceKeeper.GetAllianceValidator(ctx, valAddr1)
+	require.NoError(t, err)
+	err = app.AllianceKeeper.AddAssetsToRewardPool(ctx, mintPoolAddr, val1, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000))))
+	require.NoError(t, err)
+
+	res, err = queryServer.DelegatorPortfolio(ctx, &types.QueryDelegatorPortfolioRequest{DelegatorAddr: delAddr.String()})
+	require.NoError(t, err)
+	require.Len(t, res.Delegations, 2)
+	balances := sdk.NewCoins()
+	for _, delegation := range res.Delegations {
+		balances = balances.Add(delegation.Balance)
+		rewards, err := queryServer.AllianceDelegationRewards(ctx, &types.QueryAllianceDelegationRewardsRequest{
+			DelegatorAddr: delAddr.String(),
+			ValidatorAddr: delegation.Delegation.ValidatorAddress,
+			Denom:         AllianceDenom,
+		})
+		require.NoError(t, err)
+		require.True(t, sdk.Coins(rewards.Rewards).IsEqual(delegation.PendingRewards))
+	}
+	require.Equal(t, sdk.NewCoins(sdk.NewCoin(AllianceDenom, sdk.NewInt(800_000))), balances)
+	require.Equal(t, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000))), res.TotalPendingRewards)
+
+	require.Len(t, res.Redelegations, 1)
+	require.Equal(t, valAddr2.String(), res.Redelegations[0].Redelegation.DstValidatorAddress)
+	require.Equal(t, *redelegationTime, res.Redelegations[0].CompletionTime)
+	require.Len(t, res.Undelegations, 1)
+	require.Equal(t, sdk.NewCoin(AllianceDenom, sdk.NewInt(200_000)), res.Undelegations[0].Undelegation.Balance)
+	require.Equal(t, *undelegationTime, res.Undelegations[0].CompletionTime)
+
+	// The only alliance delegator owns all the voting power of the module
+	moduleBonded := app.AllianceKeeper.GetModuleBondedAmount(ctx, valAddr1).Add(app.AllianceKeeper.GetModuleBondedAmount(ctx, valAddr2))
+	require.True(t, moduleBonded.IsPositive())
+	require.True(t, res.TotalVotingPower.Sub(moduleBonded).Abs().LT(sdk.NewDecWithPrec(1, 6)))
+
+	// Other delegators have an empty portfolio
+	res, err = queryServer.DelegatorPortfolio(ctx, &types.QueryDelegatorPortfolioRequest{DelegatorAddr: addrs[1].String()})
+	require.NoError(t, err)
+	require.Empty(t, res.Delegations)
+	require.Empty(t, res.Undelegations)
+	require.Empty(t, res.Redelegations)
+
+	// Completed undelegations are removed from the index of the delegator
+	ctx = ctx.WithBlockTime(undelegationTime.Add(time.Second))
+	require.NoError(t, app.AllianceKeeper.CompleteUndelegations(ctx))
+	iter := app.AllianceKeeper.IterateUndelegationsByDelegator(ctx, delAddr)
+	require.False(t, iter.Valid())
+	iter.Close()
+	res, err = queryServer.DelegatorPortfolio(ctx, &types.QueryDelegatorPortfolioRequest{DelegatorAddr: delAddr.String()})
+	require.NoError(t, err)
+	require.Empty(t, res.Undelegations)
+}
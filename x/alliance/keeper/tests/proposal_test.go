@@ -171,3 +171,77 @@ func TestDeleteAlliance(t *testing.T) {
 		},
 	})
 }
+
+func TestCreateAllianceFailsAboveRewardWeightCap(t *testing.T) {
+	// GIVEN
+	app, ctx := createTestContext(t)
+	startTime := time.Now()
+	ctx = ctx.WithBlockTime(startTime).WithBlockHeight(1)
+	params := types.DefaultParams()
+	params.MaxTotalRewardWeight = sdk.NewDec(3)
+	params.RewardWeightCapMode = types.RewardWeightCapModeReject
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: params,
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset("uluna", sdk.NewDec(2), sdk.ZeroDec(), sdk.NewDec(5), sdk.NewDec(0), startTime),
+		},
+	})
+
+	// WHEN
+	createErr := app.AllianceKeeper.CreateAlliance(ctx, &types.MsgCreateAllianceProposal{
+		Denom:             "uatom",
+		RewardWeight:      sdk.NewDec(2),
+		RewardWeightRange: types.RewardWeightRange{Min: sdk.NewDec(0), Max: sdk.NewDec(5)},
+		TakeRate:          sdk.ZeroDec(),
+		RewardChangeRate:  sdk.OneDec(),
+	})
+	createWithinCapErr := app.AllianceKeeper.CreateAlliance(ctx, &types.MsgCreateAllianceProposal{
+		Denom:             "uatom",
+		RewardWeight:      sdk.NewDec(1),
+		RewardWeightRange: types.RewardWeightRange{Min: sdk.NewDec(0), Max: sdk.NewDec(5)},
+		TakeRate:          sdk.ZeroDec(),
+		RewardChangeRate:  sdk.OneDec(),
+	})
+
+	// THEN
+	require.ErrorIs(t, createErr, types.ErrRewardWeightCapExceeded)
+	require.NoError(t, createWithinCapErr)
+	require.Equal(t, sdk.OneDec(), app.AllianceKeeper.GetRewardWeightScale(ctx))
+}
+
+func TestUpdateAllianceFailsAboveRewardWeightCap(t *testing.T) {
+	// GIVEN
+	app, ctx := createTestContext(t)
+	startTime := time.Now()
+	ctx = ctx.WithBlockTime(startTime).WithBlockHeight(1)
+	params := types.DefaultParams()
+	params.MaxTotalRewardWeight = sdk.NewDec(3)
+	params.RewardWeightCapMode = types.RewardWeightCapModeReject
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: params,
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset("uluna", sdk.NewDec(2), sdk.ZeroDec(), sdk.NewDec(5), sdk.NewDec(0), startTime),
+			types.NewAllianceAsset("uatom", sdk.NewDec(1), sdk.ZeroDec(), sdk.NewDec(5), sdk.NewDec(0), startTime),
+		},
+	})
+
+	// WHEN
+	updateErr := app.AllianceKeeper.UpdateAlliance(ctx, &types.MsgUpdateAllianceProposal{
+		Denom:            "uluna",
+		RewardWeight:     sdk.NewDec(3),
+		TakeRate:         sdk.ZeroDec(),
+		RewardChangeRate: sdk.OneDec(),
+	})
+	updateWithinCapErr := app.AllianceKeeper.UpdateAlliance(ctx, &types.MsgUpdateAllianceProposal{
+		Denom:            "uatom",
+		RewardWeight:     sdk.NewDec(1),
+		TakeRate:         sdk.ZeroDec(),
+		RewardChangeRate: sdk.OneDec(),
+	})
+
+	// THEN
+	require.ErrorIs(t, updateErr, types.ErrRewardWeightCapExceeded)
+	require.NoError(t, updateWithinCapErr)
+	asset, _ := app.AllianceKeeper.GetAssetByDenom(ctx, "uluna")
+	require.Equal(t, sdk.NewDec(2), asset.RewardWeight)
+}
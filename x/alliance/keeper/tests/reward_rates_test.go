@@ -0,0 +1,95 @@
+package tests_test
+
+import (
+	"testing"
+	"time"
+
+	test_helpers "github.com/terra-money/alliance/app"
+	"github.com/terra-money/alliance/x/alliance"
+	"github.com/terra-money/alliance/x/alliance/keeper"
+	"github.com/terra-money/alliance/x/alliance/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	minttypes "github.com/cosmos/cosmos-sdk/x/mint/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllianceRewardRates(t *testing.T) {
+	app, ctx := createTestContext(t)
+	startTime := time.Now().UTC()
+	ctx = ctx.WithBlockTime(startTime).WithBlockHeight(1)
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: types.DefaultParams(),
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset(AllianceDenom, sdk.NewDec(2), sdk.ZeroDec(), sdk.NewDec(5), sdk.ZeroDec(), startTime),
+		},
+	})
+	mintPoolAddr := app.AccountKeeper.GetModuleAddress(minttypes.ModuleName)
+	delegations := app.StakingKeeper.GetAllDelegations(ctx)
+	valAddr, err := sdk.ValAddressFromBech32(delegations[0].ValidatorAddress)
+	require.NoError(t, err)
+	val, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr)
+	require.NoError(t, err)
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 1, sdk.NewCoins(sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000))))
+	_, err = app.AllianceKeeper.Delegate(ctx, addrs[0], val, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+
+	// The first checkpoint is recorded right away and the next one only after the interval
+	err = app.AllianceKeeper.CheckpointRewardRatesHook(ctx)
+	require.NoError(t, err)
+	ctx = ctx.WithBlockTime(startTime.Add(30 * time.Minute)).WithBlockHeight(2)
+	err = app.AllianceKeeper.CheckpointRewardRatesHook(ctx)
+	require.NoError(t, err)
+	last, found := app.AllianceKeeper.GetLastRewardWeightsCheckpoint(ctx)
+	require.True(t, found)
+	require.Equal(t, startTime, last.Time)
+	require.Equal(t, sdk.NewDecCoins(sdk.NewDecCoinFromDec(AllianceDenom, sdk.NewDec(2))), last.RewardWeights)
+
+	// Every token earns 1000 / 1000_000 stake within the hour
+	err = app.BankKeeper.MintCoins(ctx, minttypes.ModuleName, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000))))
+	require.NoError(t, err)
+	val, err = app.AllianceKeeper.GetAllianceValidator(ctx, valAddr)
+	require.NoError(t, err)
+	err = app.AllianceKeeper.AddAssetsToRewardPool(ctx, mintPoolAddr, val, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000))))
+	require.NoError(t, err)
+	ctx = ctx.WithBlockTime(startTime.Add(time.Hour)).WithBlockHeight(3)
+	alliance.EndBlocker(ctx, app.AllianceKeeper)
+
+	annualRate := sdk.NewDecCoins(sdk.NewDecCoinFromDec("stake", sdk.MustNewDecFromStr("8.76")))
+	queryServer := keeper.NewQueryServerImpl(app.AllianceKeeper)
+	res, err := queryServer.AllianceRewardRates(ctx, &types.QueryAllianceRewardRatesRequest{Denom: AllianceDenom})
+	require.NoError(t, err)
+	require.Equal(t, startTime, res.StartTime)
+	require.Equal(t, startTime.Add(time.Hour), res.EndTime)
+	require.Equal(t, annualRate, res.AnnualRates)
+	require.Equal(t, []types.ValidatorRewardRate{{ValidatorAddress: valAddr.String(), AnnualRates: annualRate}}, res.Validators)
+
+	res, err = queryServer.AllianceRewardRates(ctx, &types.QueryAllianceRewardRatesRequest{
+		Denom:         AllianceDenom,
+		ValidatorAddr: valAddr.String(),
+		Window:        "1h",
+	})
+	require.NoError(t, err)
+	require.Equal(t, annualRate, res.AnnualRates)
+
+	_, err = queryServer.AllianceRewardRates(ctx, &types.QueryAllianceRewardRatesRequest{Denom: AllianceDenom, Window: "a week"})
+	require.Error(t, err)
+	_, err = queryServer.AllianceRewardRates(ctx, &types.QueryAllianceRewardRatesRequest{Denom: "unknown"})
+	require.Error(t, err)
+
+	// The checkpoints older than the retention are pruned
+	ctx = ctx.WithBlockTime(startTime.Add(app.AllianceKeeper.RewardRateRetention(ctx) + 2*time.Hour)).WithBlockHeight(4)
+	err = app.AllianceKeeper.CheckpointRewardRatesHook(ctx)
+	require.NoError(t, err)
+	var checkpoints []types.RewardRateCheckpoint
+	app.AllianceKeeper.IterateRewardRateCheckpoints(ctx, func(checkpoint types.RewardRateCheckpoint) bool {
+		checkpoints = append(checkpoints, checkpoint)
+		return false
+	})
+	require.Len(t, checkpoints, 1)
+	require.Equal(t, ctx.BlockTime(), checkpoints[0].Time)
+	res, err = queryServer.AllianceRewardRates(ctx, &types.QueryAllianceRewardRatesRequest{Denom: AllianceDenom})
+	require.NoError(t, err)
+	require.True(t, res.AnnualRates.IsZero())
+	require.Empty(t, res.Validators)
+}
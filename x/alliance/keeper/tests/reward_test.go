@@ -600,10 +600,8 @@ func TestClaimRewardsAfterRewardsRatesChange(t *testing.T) {
 	var snapshot types.RewardWeightChangeSnapshot
 	require.True(t, iter.Valid())
 	app.AppCodec().MustUnmarshal(iter.Value(), &snapshot)
-	require.Equal(t, types.RewardWeightChangeSnapshot{
-		PrevRewardWeight: sdk.NewDec(2),
-		RewardHistories:  val1.GlobalRewardHistory,
-	}, snapshot)
+	asset, _ := app.AllianceKeeper.GetAssetByDenom(ctx, AllianceDenom)
+	require.Equal(t, types.NewRewardWeightChangeSnapshot(sdk.NewDec(2), val1, asset), snapshot)
 	iter.Close()
 
 	// Accumulate rewards in pool
@@ -0,0 +1,120 @@
+package tests_test
+
+import (
+	"testing"
+	"time"
+
+	test_helpers "github.com/terra-money/alliance/app"
+	"github.com/terra-money/alliance/x/alliance"
+	"github.com/terra-money/alliance/x/alliance/keeper"
+	"github.com/terra-money/alliance/x/alliance/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	minttypes "github.com/cosmos/cosmos-sdk/x/mint/types"
+	"github.com/cosmos/cosmos-sdk/x/staking/teststaking"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulateAllianceAction(t *testing.T) {
+	app, ctx := createTestContext(t)
+	startTime := time.Now().UTC()
+	ctx = ctx.WithBlockTime(startTime).WithBlockHeight(1)
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: types.DefaultParams(),
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset(AllianceDenom, sdk.NewDec(2), sdk.ZeroDec(), sdk.NewDec(5), sdk.ZeroDec(), startTime),
+		},
+	})
+	mintPoolAddr := app.AccountKeeper.GetModuleAddress(minttypes.ModuleName)
+	delegations := app.StakingKeeper.GetAllDelegations(ctx)
+	valAddr1, err := sdk.ValAddressFromBech32(delegations[0].ValidatorAddress)
+	require.NoError(t, err)
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 2, sdk.NewCoins(sdk.NewCoin(AllianceDenom, sdk.NewInt(2000_000))))
+	delAddr := addrs[0]
+	valAddr2 := sdk.ValAddress(addrs[1])
+	test_helpers.RegisterNewValidator(t, app, ctx, teststaking.NewValidator(t, valAddr2, test_helpers.CreateTestPubKeys(1)[0]))
+	queryServer := keeper.NewQueryServerImpl(app.AllianceKeeper)
+
+	// Simulating a delegation does not change the state
+	res, err := queryServer.SimulateAllianceAction(ctx, &types.QuerySimulateAllianceActionRequest{
+		Action:        types.AllianceActionDelegate,
+		DelegatorAddr: delAddr.String(),
+		ValidatorAddr: valAddr1.String(),
+		Coin:          sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)),
+	})
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)), res.RemainingTokens)
+	require.True(t, res.Shares.IsPositive())
+	require.True(t, res.ClaimedRewards.IsZero())
+	require.Len(t, res.Validators, 1)
+	require.True(t, res.Validators[0].TokensAfter.GT(res.Validators[0].TokensBefore))
+	_, found := app.AllianceKeeper.GetDelegation(ctx, delAddr, valAddr1, AllianceDenom)
+	require.False(t, found)
+
+	// The simulated delegation matches the actual one
+	val1, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr1)
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Delegate(ctx, delAddr, val1, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+	alliance.EndBlocker(ctx, app.AllianceKeeper)
+	delegation, found := app.AllianceKeeper.GetDelegation(ctx, delAddr, valAddr1, AllianceDenom)
+	require.True(t, found)
+	require.Equal(t, res.Shares, delegation.Shares)
+	validator, found := app.StakingKeeper.GetValidator(ctx, valAddr1)
+	require.True(t, found)
+	require.Equal(t, res.Validators[0].TokensAfter, validator.GetTokens())
+
+	err = app.BankKeeper.MintCoins(ctx, minttypes.ModuleName, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000))))
+	require.NoError(t, err)
+	val1, err = app.AllianceKeeper.GetAllianceValidator(ctx, valAddr1)
+	require.NoError(t, err)
+	err = app.AllianceKeeper.AddAssetsToRewardPool(ctx, mintPoolAddr, val1, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000))))
+	require.NoError(t, err)
+
+	// Redelegations claim the pending rewards and move voting power from one validator to the other
+	res, err = queryServer.SimulateAllianceAction(ctx, &types.QuerySimulateAllianceActionRequest{
+		Action:           types.AllianceActionRedelegate,
+		DelegatorAddr:    delAddr.String(),
+		ValidatorAddr:    valAddr1.String(),
+		DstValidatorAddr: valAddr2.String(),
+		Coin:             sdk.NewCoin(AllianceDenom, sdk.NewInt(400_000)),
+	})
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000))), res.ClaimedRewards)
+	require.Equal(t, ctx.BlockTime().Add(app.StakingKeeper.UnbondingTime(ctx)), res.CompletionTime)
+	require.Equal(t, delegation.Shares.Mul(sdk.MustNewDecFromStr("0.4")), res.Shares)
+	require.True(t, res.DstShares.IsPositive())
+	require.Equal(t, sdk.NewCoin(AllianceDenom, sdk.NewInt(600_000)), res.RemainingTokens)
+	require.Len(t, res.Validators, 2)
+	require.True(t, res.Validators[0].TokensAfter.LT(res.Validators[0].TokensBefore))
+	require.True(t, res.Validators[1].TokensAfter.GT(res.Validators[1].TokensBefore))
+
+	// Undelegations are returned after the unbonding time
+	res, err = queryServer.SimulateAllianceAction(ctx, &types.QuerySimulateAllianceActionRequest{
+		Action:        types.AllianceActionUndelegate,
+		DelegatorAddr: delAddr.String(),
+		ValidatorAddr: valAddr1.String(),
+		Coin:          sdk.NewCoin(AllianceDenom, sdk.NewInt(999_999)),
+	})
+	require.NoError(t, err)
+	require.Equal(t, delegation.Shares.Mul(sdk.MustNewDecFromStr("0.999999")), res.Shares)
+	require.Equal(t, sdk.NewCoin(AllianceDenom, sdk.NewInt(1)), res.RemainingTokens)
+	require.Equal(t, ctx.BlockTime().Add(app.StakingKeeper.UnbondingTime(ctx)), res.CompletionTime)
+	require.Equal(t, sdk.NewCoin(AllianceDenom, sdk.NewInt(999_999)), res.Amount)
+
+	// Failing actions return the error of the keeper
+	_, err = queryServer.SimulateAllianceAction(ctx, &types.QuerySimulateAllianceActionRequest{
+		Action:        types.AllianceActionUndelegate,
+		DelegatorAddr: delAddr.String(),
+		ValidatorAddr: valAddr1.String(),
+		Coin:          sdk.NewCoin(AllianceDenom, sdk.NewInt(2000_000)),
+	})
+	require.Error(t, err)
+	_, err = queryServer.SimulateAllianceAction(ctx, &types.QuerySimulateAllianceActionRequest{
+		Action:        types.AllianceActionUndelegate,
+		DelegatorAddr: delAddr.String(),
+		ValidatorAddr: valAddr1.String(),
+		Coin:          sdk.NewCoin("unknown", sdk.NewInt(1)),
+	})
+	require.Error(t, err)
+}
@@ -6,9 +6,12 @@ import (
 
 	test_helpers "github.com/terra-money/alliance/app"
 	"github.com/terra-money/alliance/x/alliance"
+	"github.com/terra-money/alliance/x/alliance/keeper"
 	"github.com/terra-money/alliance/x/alliance/types"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	minttypes "github.com/cosmos/cosmos-sdk/x/mint/types"
 	"github.com/cosmos/cosmos-sdk/x/staking/teststaking"
 	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
 	"github.com/stretchr/testify/require"
@@ -472,3 +475,163 @@ func TestSlashingIncorrectAmount(t *testing.T) {
 	err = app.AllianceKeeper.SlashValidator(ctx, sdk.ValAddress(addrs[0]), sdk.NewDec(-1))
 	require.EqualErrorf(t, err, "slashed fraction must be greater than 0 and less than or equal to 1: -1.000000000000000000", "")
 }
+
+func TestSlashingKeepsRewardsAccruedBeforeSlash(t *testing.T) {
+	app, ctx := createTestContext(t)
+	startTime := time.Now().UTC()
+	ctx = ctx.WithBlockTime(startTime).WithBlockHeight(1)
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: types.DefaultParams(),
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset(AllianceDenom, sdk.NewDec(2), sdk.ZeroDec(), sdk.NewDec(5), sdk.ZeroDec(), startTime),
+		},
+	})
+	mintPoolAddr := app.AccountKeeper.GetModuleAddress(minttypes.ModuleName)
+	valAddr, err := sdk.ValAddressFromBech32(app.StakingKeeper.GetAllDelegations(ctx)[0].ValidatorAddress)
+	require.NoError(t, err)
+	delAddr := test_helpers.AddTestAddrsIncremental(app, ctx, 1, sdk.NewCoins(sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000))))[0]
+	queryServer := keeper.NewQueryServerImpl(app.AllianceKeeper)
+
+	// GIVEN: a delegation that accrued rewards
+	val, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr)
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Delegate(ctx, delAddr, val, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+	alliance.EndBlocker(ctx, app.AllianceKeeper)
+	err = app.BankKeeper.MintCoins(ctx, minttypes.ModuleName, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000))))
+	require.NoError(t, err)
+	val, err = app.AllianceKeeper.GetAllianceValidator(ctx, valAddr)
+	require.NoError(t, err)
+	err = app.AllianceKeeper.AddAssetsToRewardPool(ctx, mintPoolAddr, val, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000))))
+	require.NoError(t, err)
+	// The query claims the rewards, so it runs on a copy of the state
+	cacheCtx, _ := ctx.CacheContext()
+	rewardsReq := &types.QueryAllianceDelegationRewardsRequest{
+		DelegatorAddr: delAddr.String(),
+		ValidatorAddr: valAddr.String(),
+		Denom:         AllianceDenom,
+	}
+	rewards, err := queryServer.AllianceDelegationRewards(cacheCtx, rewardsReq)
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000))), sdk.NewCoins(rewards.Rewards...))
+	balanceBefore := app.BankKeeper.GetBalance(ctx, delAddr, "stake")
+
+	// WHEN: the validator is slashed by half
+	require.NoError(t, app.AllianceKeeper.SlashValidator(ctx, valAddr, sdk.MustNewDecFromStr("0.5")))
+
+	// THEN: nothing is paid out by the slash and the rewards accrued before it can still be claimed in full
+	require.Equal(t, balanceBefore, app.BankKeeper.GetBalance(ctx, delAddr, "stake"))
+	cacheCtx, _ = ctx.CacheContext()
+	rewards, err = queryServer.AllianceDelegationRewards(cacheCtx, rewardsReq)
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000))), sdk.NewCoins(rewards.Rewards...))
+
+	_, stop := alliance.RunAllInvariants(ctx, app.AllianceKeeper)
+	require.False(t, stop)
+}
+
+func TestSlashingKeepsRewardsOfOtherValidators(t *testing.T) {
+	app, ctx := createTestContext(t)
+	startTime := time.Now().UTC()
+	ctx = ctx.WithBlockTime(startTime).WithBlockHeight(1)
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: types.DefaultParams(),
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset(AllianceDenom, sdk.NewDec(2), sdk.ZeroDec(), sdk.NewDec(5), sdk.ZeroDec(), startTime),
+		},
+	})
+	mintPoolAddr := app.AccountKeeper.GetModuleAddress(minttypes.ModuleName)
+	valAddr1, err := sdk.ValAddressFromBech32(app.StakingKeeper.GetAllDelegations(ctx)[0].ValidatorAddress)
+	require.NoError(t, err)
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 3, sdk.NewCoins(sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000))))
+	valAddr2 := sdk.ValAddress(addrs[2])
+	test_helpers.RegisterNewValidator(t, app, ctx, teststaking.NewValidator(t, valAddr2, test_helpers.CreateTestPubKeys(1)[0]))
+
+	// GIVEN: delegations to two validators that accrued rewards
+	for i, valAddr := range []sdk.ValAddress{valAddr1, valAddr2} {
+		val, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr)
+		require.NoError(t, err)
+		_, err = app.AllianceKeeper.Delegate(ctx, addrs[i], val, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+		require.NoError(t, err)
+	}
+	alliance.EndBlocker(ctx, app.AllianceKeeper)
+	for _, valAddr := range []sdk.ValAddress{valAddr1, valAddr2} {
+		err = app.BankKeeper.MintCoins(ctx, minttypes.ModuleName, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000))))
+		require.NoError(t, err)
+		val, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr)
+		require.NoError(t, err)
+		err = app.AllianceKeeper.AddAssetsToRewardPool(ctx, mintPoolAddr, val, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000))))
+		require.NoError(t, err)
+	}
+
+	// WHEN: the first validator is slashed in the next block, which moves alliance tokens to the second validator
+	ctx = ctx.WithBlockHeight(2)
+	require.NoError(t, app.AllianceKeeper.SlashValidator(ctx, valAddr1, sdk.MustNewDecFromStr("0.5")))
+
+	// THEN: the delegators of both validators can claim the rewards accrued before the slash on the tokens they had
+	// before it, so the moved tokens do not earn them again
+	queryServer := keeper.NewQueryServerImpl(app.AllianceKeeper)
+	for i, valAddr := range []sdk.ValAddress{valAddr1, valAddr2} {
+		cacheCtx, _ := ctx.CacheContext()
+		rewards, err := queryServer.AllianceDelegationRewards(cacheCtx, &types.QueryAllianceDelegationRewardsRequest{
+			DelegatorAddr: addrs[i].String(),
+			ValidatorAddr: valAddr.String(),
+			Denom:         AllianceDenom,
+		})
+		require.NoError(t, err)
+		require.Equal(t, sdk.NewCoins(sdk.NewCoin("stake", sdk.NewInt(1000))), sdk.NewCoins(rewards.Rewards...))
+	}
+
+	_, stop := alliance.RunAllInvariants(ctx, app.AllianceKeeper)
+	require.False(t, stop)
+}
+
+func TestSlashingRedelegationsLeavesValidatorRewardsInDistribution(t *testing.T) {
+	app, ctx := createTestContext(t)
+	startTime := time.Now().UTC()
+	ctx = ctx.WithBlockTime(startTime).WithBlockHeight(1)
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: types.DefaultParams(),
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset(AllianceDenom, sdk.NewDec(2), sdk.ZeroDec(), sdk.NewDec(5), sdk.ZeroDec(), startTime),
+		},
+	})
+	valAddr1, err := sdk.ValAddressFromBech32(app.StakingKeeper.GetAllDelegations(ctx)[0].ValidatorAddress)
+	require.NoError(t, err)
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 2, sdk.NewCoins(sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000))))
+	valAddr2 := sdk.ValAddress(addrs[1])
+	test_helpers.RegisterNewValidator(t, app, ctx, teststaking.NewValidator(t, valAddr2, test_helpers.CreateTestPubKeys(1)[0]))
+	val1, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr1)
+	require.NoError(t, err)
+	val2, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr2)
+	require.NoError(t, err)
+
+	// GIVEN: a redelegation from the first validator to the second one, which has rewards in the distribution module
+	_, err = app.AllianceKeeper.Delegate(ctx, addrs[0], val1, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Redelegate(ctx, addrs[0], val1, val2, sdk.NewCoin(AllianceDenom, sdk.NewInt(500_000)))
+	require.NoError(t, err)
+	err = app.AllianceKeeper.RebalanceBondTokenWeights(ctx, app.AllianceKeeper.GetAllAssets(ctx))
+	require.NoError(t, err)
+	ctx = ctx.WithBlockHeight(2)
+	val2, err = app.AllianceKeeper.GetAllianceValidator(ctx, valAddr2)
+	require.NoError(t, err)
+	bondDenom := app.StakingKeeper.BondDenom(ctx)
+	rewards := sdk.NewCoins(sdk.NewCoin(bondDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, app.BankKeeper.MintCoins(ctx, minttypes.ModuleName, rewards))
+	require.NoError(t, app.BankKeeper.SendCoinsFromModuleToModule(ctx, minttypes.ModuleName, distrtypes.ModuleName, rewards))
+	app.DistrKeeper.AllocateTokensToValidator(ctx, val2.Validator, sdk.NewDecCoinsFromCoins(rewards...))
+	outstanding := app.DistrKeeper.GetValidatorOutstandingRewards(ctx, valAddr2)
+
+	// WHEN: the first validator is slashed, which slashes the redelegation as well
+	require.NoError(t, app.AllianceKeeper.SlashValidator(ctx, valAddr1, sdk.MustNewDecFromStr("0.1")))
+
+	// THEN: the rewards of the second validator are not added to its reward indices while the slash moves tokens
+	require.Equal(t, outstanding, app.DistrKeeper.GetValidatorOutstandingRewards(ctx, valAddr2))
+	info, found := app.AllianceKeeper.GetAllianceValidatorInfo(ctx, valAddr2)
+	require.True(t, found)
+	require.Equal(t, val2.GlobalRewardHistory, info.GlobalRewardHistory)
+
+	_, stop := alliance.RunAllInvariants(ctx, app.AllianceKeeper)
+	require.False(t, stop)
+}
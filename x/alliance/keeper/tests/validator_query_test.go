@@ -0,0 +1,101 @@
+package tests_test
+
+import (
+	"testing"
+	"time"
+
+	test_helpers "github.com/terra-money/alliance/app"
+	"github.com/terra-money/alliance/x/alliance"
+	"github.com/terra-money/alliance/x/alliance/keeper"
+	"github.com/terra-money/alliance/x/alliance/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	"github.com/cosmos/cosmos-sdk/x/staking/teststaking"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryValidatorsBondedAmountAndSorting(t *testing.T) {
+	app, ctx := createTestContext(t)
+	startTime := time.Now().UTC()
+	ctx = ctx.WithBlockTime(startTime).WithBlockHeight(1)
+	app.AllianceKeeper.InitGenesis(ctx, &types.GenesisState{
+		Params: types.DefaultParams(),
+		Assets: []types.AllianceAsset{
+			types.NewAllianceAsset(AllianceDenom, sdk.NewDec(2), sdk.ZeroDec(), sdk.NewDec(5), sdk.ZeroDec(), startTime),
+			types.NewAllianceAsset(AllianceDenomTwo, sdk.NewDec(10), sdk.ZeroDec(), sdk.NewDec(12), sdk.ZeroDec(), startTime),
+		},
+	})
+	delegations := app.StakingKeeper.GetAllDelegations(ctx)
+	valAddr1, err := sdk.ValAddressFromBech32(delegations[0].ValidatorAddress)
+	require.NoError(t, err)
+	addrs := test_helpers.AddTestAddrsIncremental(app, ctx, 2, sdk.NewCoins(
+		sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)),
+		sdk.NewCoin(AllianceDenomTwo, sdk.NewInt(1000_000)),
+	))
+	delAddr := addrs[0]
+	valAddr2 := sdk.ValAddress(addrs[1])
+	test_helpers.RegisterNewValidator(t, app, ctx, teststaking.NewValidator(t, valAddr2, test_helpers.CreateTestPubKeys(1)[0]))
+	queryServer := keeper.NewQueryServerImpl(app.AllianceKeeper)
+
+	val1, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr1)
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Delegate(ctx, delAddr, val1, sdk.NewCoin(AllianceDenom, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+	val2, err := app.AllianceKeeper.GetAllianceValidator(ctx, valAddr2)
+	require.NoError(t, err)
+	_, err = app.AllianceKeeper.Delegate(ctx, delAddr, val2, sdk.NewCoin(AllianceDenomTwo, sdk.NewInt(1000_000)))
+	require.NoError(t, err)
+	alliance.EndBlocker(ctx, app.AllianceKeeper)
+
+	// The bonded amount of a validator is split over its assets
+	res, err := queryServer.AllianceValidator(ctx, &types.QueryAllianceValidatorRequest{ValidatorAddr: valAddr1.String()})
+	require.NoError(t, err)
+	require.True(t, res.BondedAmount.IsPositive())
+	require.Equal(t, app.AllianceKeeper.GetModuleBondedAmount(ctx, valAddr1), res.BondedAmount)
+	require.True(t, res.BondedAmount.Sub(res.BondedAmountPerAsset.AmountOf(AllianceDenom)).Abs().LT(sdk.NewDecWithPrec(1, 6)))
+
+	// Filtering by another asset leaves the bonded amount but hides the shares of the validator
+	res, err = queryServer.AllianceValidator(ctx, &types.QueryAllianceValidatorRequest{
+		ValidatorAddr: valAddr1.String(),
+		Denom:         AllianceDenomTwo,
+	})
+	require.NoError(t, err)
+	require.True(t, res.BondedAmount.IsPositive())
+	require.Empty(t, res.TotalDelegationShares)
+	require.Empty(t, res.TotalStaked)
+	require.Empty(t, res.BondedAmountPerAsset)
+
+	// Only the validators with delegations of the asset are returned
+	all, err := queryServer.AllAllianceValidators(ctx, &types.QueryAllAllianceValidatorsRequest{Denom: AllianceDenomTwo})
+	require.NoError(t, err)
+	require.Len(t, all.Validators, 1)
+	require.Equal(t, valAddr2.String(), all.Validators[0].ValidatorAddr)
+	require.Equal(t, sdk.NewDecCoins(sdk.NewDecCoinFromDec(AllianceDenomTwo, sdk.NewDec(1000_000))), sdk.DecCoins(all.Validators[0].TotalStaked))
+
+	// The asset with the larger reward weight gives its validator the larger bonded amount
+	all, err = queryServer.AllAllianceValidators(ctx, &types.QueryAllAllianceValidatorsRequest{
+		SortByStaked: true,
+		Pagination:   &query.PageRequest{CountTotal: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, all.Validators, 2)
+	require.Equal(t, uint64(2), all.Pagination.Total)
+	require.Equal(t, valAddr2.String(), all.Validators[0].ValidatorAddr)
+	require.True(t, all.Validators[0].BondedAmount.GT(all.Validators[1].BondedAmount))
+
+	all, err = queryServer.AllAllianceValidators(ctx, &types.QueryAllAllianceValidatorsRequest{
+		SortByStaked: true,
+		Pagination:   &query.PageRequest{Offset: 1, Limit: 1},
+	})
+	require.NoError(t, err)
+	require.Len(t, all.Validators, 1)
+	require.Equal(t, valAddr1.String(), all.Validators[0].ValidatorAddr)
+
+	// Sorting requires offset pagination
+	_, err = queryServer.AllAllianceValidators(ctx, &types.QueryAllAllianceValidatorsRequest{
+		SortByStaked: true,
+		Pagination:   &query.PageRequest{Key: []byte{1}},
+	})
+	require.Error(t, err)
+}
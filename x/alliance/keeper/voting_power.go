@@ -0,0 +1,37 @@
+package keeper
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/terra-money/alliance/x/alliance/types"
+)
+
+// bondedAmountPerAsset splits the bond tokens that the module has delegated to a validator over the assets in
+// proportion to the bond tokens the rebalance expects for each of them. Unbonded validators have no voting power, so
+// nothing is attributed to their assets.
+func (k Keeper) bondedAmountPerAsset(ctx sdk.Context, assets []*types.AllianceAsset, inputs rebalanceInputs, validator types.AllianceValidator) (bonded sdk.Dec, perAsset sdk.DecCoins) {
+	bonded = k.currentBondedAmount(ctx, inputs, validator)
+	perAsset = sdk.NewDecCoins()
+	if !validator.IsBonded() {
+		return bonded, perAsset
+	}
+	expected, expectedPerAsset := expectedBondAmount(assets, inputs, validator)
+	if !expected.IsPositive() {
+		return bonded, perAsset
+	}
+	for _, coin := range expectedPerAsset {
+		perAsset = perAsset.Add(sdk.NewDecCoinFromDec(coin.Denom, bonded.Mul(coin.Amount).Quo(expected)))
+	}
+	return bonded, perAsset
+}
+
+// delegationVotingPower attributes the bond tokens of an asset on a validator to a delegation by its delegation
+// shares, including the boost shares of its locks
+func (k Keeper) delegationVotingPower(ctx sdk.Context, delegation types.Delegation, validator types.AllianceValidator, denom string, assetBondedAmount sdk.Dec) sdk.Dec {
+	totalShares := validator.TotalDelegationSharesWithDenom(denom).Add(validator.BoostedDelegationSharesWithDenom(denom))
+	if !totalShares.IsPositive() {
+		return sdk.ZeroDec()
+	}
+	shares := delegation.Shares.Add(k.GetDelegationBoostShares(ctx, delegation))
+	return assetBondedAmount.Mul(shares).Quo(totalShares)
+}
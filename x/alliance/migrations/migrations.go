@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	alliancekeeper "github.com/terra-money/alliance/x/alliance/keeper"
+	v4 "github.com/terra-money/alliance/x/alliance/migrations/v4"
+	v5 "github.com/terra-money/alliance/x/alliance/migrations/v5"
+	v6 "github.com/terra-money/alliance/x/alliance/migrations/v6"
+)
+
+// Migration migrates the store of the alliance module from a consensus version to the next one
+type Migration struct {
+	FromVersion uint64
+	Migrate     func(ctx sdk.Context) error
+}
+
+// Migrations returns the store migrations of the alliance module in order. Each migration lives in the package of the
+// consensus version it migrates to, and adding one requires bumping the consensus version of the module.
+func Migrations(k alliancekeeper.Keeper) []Migration {
+	return []Migration{
+		{FromVersion: 3, Migrate: v4.Migrate(k)},
+		{FromVersion: 4, Migrate: v5.Migrate(k)},
+		{FromVersion: 5, Migrate: v6.Migrate(k)},
+	}
+}
@@ -0,0 +1,158 @@
+package tests_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/store/prefix"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	paramstypes "github.com/cosmos/cosmos-sdk/x/params/types"
+	upgradetypes "github.com/cosmos/cosmos-sdk/x/upgrade/types"
+	"github.com/stretchr/testify/require"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+
+	"github.com/terra-money/alliance/app"
+	v6upgrade "github.com/terra-money/alliance/app/upgrades/v6"
+	"github.com/terra-money/alliance/x/alliance"
+	"github.com/terra-money/alliance/x/alliance/keeper"
+	"github.com/terra-money/alliance/x/alliance/migrations"
+	v5 "github.com/terra-money/alliance/x/alliance/migrations/v5"
+	"github.com/terra-money/alliance/x/alliance/types"
+)
+
+// v4Store is the state of the alliance module at consensus version 4. The params are the raw values of the legacy
+// param store, the assets and delegations are stored as they are.
+type v4Store struct {
+	Params      map[string]json.RawMessage `json:"params"`
+	Assets      []json.RawMessage          `json:"assets"`
+	Delegations []json.RawMessage          `json:"delegations"`
+}
+
+// loadV4Store replaces the alliance params with the params of the fixture and writes its assets and delegations
+// directly to the store, so that the delegations are not indexed yet
+func loadV4Store(t *testing.T, app *app.App, ctx sdk.Context) (assets []types.AllianceAsset, delegations []types.Delegation) {
+	bz, err := os.ReadFile("testdata/v4_store.json")
+	require.NoError(t, err)
+	var fixture v4Store
+	require.NoError(t, json.Unmarshal(bz, &fixture))
+
+	paramStore := prefix.NewStore(ctx.KVStore(app.GetKey(paramstypes.StoreKey)), []byte(types.ModuleName+"/"))
+	iter := paramStore.Iterator(nil, nil)
+	var keys [][]byte
+	for ; iter.Valid(); iter.Next() {
+		keys = append(keys, iter.Key())
+	}
+	iter.Close()
+	for _, key := range keys {
+		paramStore.Delete(key)
+	}
+	for key, value := range fixture.Params {
+		paramStore.Set([]byte(key), value)
+	}
+
+	cdc := app.AppCodec()
+	store := ctx.KVStore(app.GetKey(types.StoreKey))
+	for _, raw := range fixture.Assets {
+		var asset types.AllianceAsset
+		require.NoError(t, cdc.UnmarshalJSON(raw, &asset))
+		app.AllianceKeeper.SetAsset(ctx, asset)
+		assets = append(assets, asset)
+	}
+	for _, raw := range fixture.Delegations {
+		var delegation types.Delegation
+		require.NoError(t, cdc.UnmarshalJSON(raw, &delegation))
+		delAddr := sdk.MustAccAddressFromBech32(delegation.DelegatorAddress)
+		valAddr, err := sdk.ValAddressFromBech32(delegation.ValidatorAddress)
+		require.NoError(t, err)
+		store.Set(types.GetDelegationKey(delAddr, valAddr, delegation.Denom), cdc.MustMarshal(&delegation))
+		delegations = append(delegations, delegation)
+	}
+	return assets, delegations
+}
+
+func TestMigrationsCoverConsensusVersions(t *testing.T) {
+	app := app.Setup(t)
+	ms := migrations.Migrations(app.AllianceKeeper)
+	require.NotEmpty(t, ms)
+	for i := 1; i < len(ms); i++ {
+		require.Equal(t, ms[i-1].FromVersion+1, ms[i].FromVersion)
+	}
+	module := alliance.NewAppModule(app.AppCodec(), app.AllianceKeeper, app.StakingKeeper, app.AccountKeeper, app.BankKeeper, app.InterfaceRegistry())
+	require.Equal(t, module.ConsensusVersion(), ms[len(ms)-1].FromVersion+1)
+}
+
+func TestMigrateV4ToV5(t *testing.T) {
+	app := app.Setup(t)
+	ctx := app.BaseApp.NewContext(false, tmproto.Header{})
+	assets, _ := loadV4Store(t, app, ctx)
+
+	err := v5.Migrate(app.AllianceKeeper)(ctx)
+	require.NoError(t, err)
+
+	// The params of v4 are kept and the new params are set to their defaults
+	expected := types.DefaultParams()
+	expected.RewardDelayTime = time.Hour * 24
+	expected.TakeRateClaimInterval = time.Minute * 10
+	expected.LastTakeRateClaimTime = time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	// An empty list is read back from the param store as nil
+	expected.LockDurations = nil
+	res, err := keeper.NewQueryServerImpl(app.AllianceKeeper).Params(ctx, &types.QueryParamsRequest{})
+	require.NoError(t, err)
+	require.Equal(t, expected, res.Params)
+
+	// The assets are not changed
+	for _, asset := range assets {
+		migrated, found := app.AllianceKeeper.GetAssetByDenom(ctx, asset.Denom)
+		require.True(t, found)
+		require.Equal(t, asset, migrated)
+	}
+}
+
+func TestUpgradeFromV4(t *testing.T) {
+	app := app.Setup(t)
+	ctx := app.BaseApp.NewContext(false, tmproto.Header{Height: 10})
+	_, delegations := loadV4Store(t, app, ctx)
+	// An undelegation queued before the index of undelegations by delegator existed
+	delAddr := sdk.MustAccAddressFromBech32(delegations[0].DelegatorAddress)
+	completionTime := ctx.BlockTime().Add(time.Hour)
+	queued := types.QueuedUndelegation{Entries: []*types.Undelegation{{
+		DelegatorAddress: delegations[0].DelegatorAddress,
+		ValidatorAddress: delegations[0].ValidatorAddress,
+		Balance:          sdk.NewCoin(delegations[0].Denom, sdk.NewInt(1000)),
+	}}}
+	ctx.KVStore(app.GetKey(types.StoreKey)).Set(types.GetUndelegationQueueKey(completionTime, delAddr), app.AppCodec().MustMarshal(&queued))
+	versions := app.UpgradeKeeper.GetModuleVersionMap(ctx)
+	versions[types.ModuleName] = 4
+	app.UpgradeKeeper.SetModuleVersionMap(ctx, versions)
+	_, stop := alliance.DelegationIndexInvariant(app.AllianceKeeper)(ctx)
+	require.True(t, stop)
+
+	// WHEN: applying the upgrade of the upgrade proposal
+	app.UpgradeKeeper.ApplyUpgrade(ctx, upgradetypes.Plan{Name: v6upgrade.UpgradeName, Height: ctx.BlockHeight()})
+
+	// THEN: all migrations since v4 ran
+	require.Equal(t, uint64(6), app.UpgradeKeeper.GetModuleVersionMap(ctx)[types.ModuleName])
+	require.Equal(t, time.Hour*24, app.AllianceKeeper.RewardDelayTime(ctx))
+	for _, delegation := range delegations {
+		valAddr, err := sdk.ValAddressFromBech32(delegation.ValidatorAddress)
+		require.NoError(t, err)
+		var indexed []types.Delegation
+		app.AllianceKeeper.IterateValidatorDelegations(ctx, valAddr, func(d types.Delegation) bool {
+			indexed = append(indexed, d)
+			return false
+		})
+		require.Equal(t, []types.Delegation{delegation}, indexed)
+	}
+	_, stop = alliance.DelegationIndexInvariant(app.AllianceKeeper)(ctx)
+	require.False(t, stop)
+	iter := app.AllianceKeeper.IterateUndelegationsByDelegator(ctx, delAddr)
+	require.True(t, iter.Valid())
+	indexedTime, err := types.ParseUndelegationByDelegatorIndexKey(iter.Key())
+	require.NoError(t, err)
+	require.True(t, completionTime.Equal(indexedTime))
+	iter.Next()
+	require.False(t, iter.Valid())
+	iter.Close()
+}
@@ -0,0 +1,27 @@
+package v5
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	alliancekeeper "github.com/terra-money/alliance/x/alliance/keeper"
+	"github.com/terra-money/alliance/x/alliance/types"
+)
+
+func Migrate(k alliancekeeper.Keeper) func(ctx sdk.Context) error {
+	return func(ctx sdk.Context) error {
+		err := migrateParamsWithNewDefaults(ctx, k)
+		if err != nil {
+			return err
+		}
+		return nil
+	}
+}
+
+func migrateParamsWithNewDefaults(ctx sdk.Context, k alliancekeeper.Keeper) error {
+	params := types.DefaultParams()
+	params.RewardDelayTime = k.RewardDelayTime(ctx)
+	params.TakeRateClaimInterval = k.RewardClaimInterval(ctx)
+	params.LastTakeRateClaimTime = k.LastRewardClaimTime(ctx)
+	k.SetParams(ctx, params)
+	return nil
+}
@@ -0,0 +1,68 @@
+package v6
+
+import (
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	alliancekeeper "github.com/terra-money/alliance/x/alliance/keeper"
+	"github.com/terra-money/alliance/x/alliance/types"
+)
+
+func Migrate(k alliancekeeper.Keeper) func(ctx sdk.Context) error {
+	return func(ctx sdk.Context) error {
+		err := migrateDelegationsByValidatorIndex(ctx, k)
+		if err != nil {
+			return err
+		}
+		err = migrateUndelegationsByDelegatorIndex(ctx, k)
+		if err != nil {
+			return err
+		}
+		return nil
+	}
+}
+
+// migrateDelegationsByValidatorIndex backfills the index of delegations by validator by storing every delegation again
+func migrateDelegationsByValidatorIndex(ctx sdk.Context, k alliancekeeper.Keeper) error {
+	var delegations []types.Delegation
+	k.IterateDelegations(ctx, func(delegation types.Delegation) (stop bool) {
+		delegations = append(delegations, delegation)
+		return false
+	})
+	for _, delegation := range delegations {
+		delAddr, err := sdk.AccAddressFromBech32(delegation.DelegatorAddress)
+		if err != nil {
+			return err
+		}
+		valAddr, err := sdk.ValAddressFromBech32(delegation.ValidatorAddress)
+		if err != nil {
+			return err
+		}
+		k.SetDelegation(ctx, delAddr, valAddr, delegation.Denom, delegation)
+	}
+	return nil
+}
+
+// migrateUndelegationsByDelegatorIndex backfills the index of queued undelegations by delegator by storing every queue
+// entry again
+func migrateUndelegationsByDelegatorIndex(ctx sdk.Context, k alliancekeeper.Keeper) error {
+	var queues []types.QueuedUndelegation
+	var completionTimes []time.Time
+	k.IterateUndelegations(ctx, func(undelegation types.QueuedUndelegation, completionTime time.Time) (stop bool) {
+		queues = append(queues, undelegation)
+		completionTimes = append(completionTimes, completionTime)
+		return false
+	})
+	for i, queue := range queues {
+		if len(queue.Entries) == 0 {
+			continue
+		}
+		delAddr, err := sdk.AccAddressFromBech32(queue.Entries[0].DelegatorAddress)
+		if err != nil {
+			return err
+		}
+		k.SetQueuedUndelegations(ctx, completionTimes[i], delAddr, queue)
+	}
+	return nil
+}
@@ -17,7 +17,7 @@ import (
 
 	"github.com/terra-money/alliance/x/alliance/client/cli"
 	"github.com/terra-money/alliance/x/alliance/keeper"
-	migrationsv4 "github.com/terra-money/alliance/x/alliance/migrations/v4"
+	"github.com/terra-money/alliance/x/alliance/migrations"
 	"github.com/terra-money/alliance/x/alliance/types"
 
 	"github.com/cosmos/cosmos-sdk/client"
@@ -133,14 +133,16 @@ func (a AppModule) LegacyQuerierHandler(_ *codec.LegacyAmino) sdk.Querier {
 func (a AppModule) RegisterServices(cfg module.Configurator) {
 	types.RegisterMsgServer(cfg.MsgServer(), keeper.NewMsgServerImpl(a.keeper))
 	types.RegisterQueryServer(cfg.QueryServer(), keeper.NewQueryServerImpl(a.keeper))
-	err := cfg.RegisterMigration(types.ModuleName, 3, migrationsv4.Migrate(a.keeper))
-	if err != nil {
-		panic(fmt.Sprintf("failed to migrate x/alliance from version 3 to 4: %v", err))
+	for _, m := range migrations.Migrations(a.keeper) {
+		err := cfg.RegisterMigration(types.ModuleName, m.FromVersion, m.Migrate)
+		if err != nil {
+			panic(fmt.Sprintf("failed to migrate x/alliance from version %d to %d: %v", m.FromVersion, m.FromVersion+1, err))
+		}
 	}
 }
 
 func (a AppModule) ConsensusVersion() uint64 {
-	return 4
+	return 6
 }
 
 func (a AppModule) GenerateGenesisState(simState *module.SimulationState) {
@@ -148,7 +150,7 @@ func (a AppModule) GenerateGenesisState(simState *module.SimulationState) {
 }
 
 func (a AppModule) ProposalContents(_ module.SimulationState) []simtypes.WeightedProposalContent {
-	return nil
+	return simulation2.ProposalContents(a.keeper)
 }
 
 func (a AppModule) RandomizedParams(_ *rand.Rand) []simtypes.ParamChange {
@@ -19,6 +19,14 @@ func NewAllianceProposalHandler(k keeper.Keeper) govtypes.Handler {
 			return k.UpdateAlliance(ctx, c)
 		case *types.MsgDeleteAllianceProposal:
 			return k.DeleteAlliance(ctx, c)
+		case *types.MsgMigrateAllianceProposal:
+			return k.MigrateAlliance(ctx, c)
+		case *types.MsgCreateAssetGroupProposal:
+			return k.CreateAssetGroup(ctx, c)
+		case *types.MsgUpdateAssetGroupProposal:
+			return k.UpdateAssetGroup(ctx, c)
+		case *types.MsgDeleteAssetGroupProposal:
+			return k.DeleteAssetGroup(ctx, c)
 
 		default:
 			return cosmoserrors.Wrapf(sdkerrors.ErrUnknownRequest, "unrecognized alliance proposal content type: %T", c)